@@ -0,0 +1,452 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	Server   ServerConfig   `yaml:"server"`
+	Database DatabaseConfig `yaml:"database"`
+	JWT      JWTConfig      `yaml:"jwt"`
+	CORS     CORSConfig     `yaml:"cors"`
+	PayU     PayUConfig     `yaml:"payu"`
+	URLs     URLConfig      `yaml:"urls"`
+	OIDC     OIDCConfig     `yaml:"oidc"`
+	API      APIConfig      `yaml:"api"`
+	Storage  StorageConfig  `yaml:"storage"`
+	Shows    ShowsConfig    `yaml:"shows"`
+}
+
+type ServerConfig struct {
+	Port              string        `yaml:"port"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+	// ShutdownTimeout is how long in-flight requests get to finish after
+	// SIGTERM before the server closes their connections.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	Port     string `yaml:"port"`
+	SSLMode  string `yaml:"sslmode"`
+}
+
+type JWTConfig struct {
+	Secret string        `yaml:"secret"`
+	TTL    time.Duration `yaml:"ttl"`
+}
+
+type CORSConfig struct {
+	AllowOrigins []string `yaml:"allow_origins"`
+}
+
+type PayUConfig struct {
+	MerchantKey  string `yaml:"merchant_key"`
+	MerchantSalt string `yaml:"merchant_salt"`
+	BaseURL      string `yaml:"base_url"`
+}
+
+type URLConfig struct {
+	BaseURL         string `yaml:"base_url"`
+	FrontendBaseURL string `yaml:"frontend_base_url"`
+}
+
+type OIDCConfig struct {
+	IssuerURL    string   `yaml:"issuer_url"`
+	ClientID     string   `yaml:"client_id"`
+	ClientSecret string   `yaml:"client_secret"`
+	RedirectURL  string   `yaml:"redirect_url"`
+	Scopes       []string `yaml:"scopes"`
+}
+
+type APIConfig struct {
+	// LegacySunset is announced in the Sunset header of the deprecated
+	// unversioned routes, zero omits the header.
+	LegacySunset time.Time `yaml:"legacy_sunset"`
+}
+
+type ShowsConfig struct {
+	// CleaningBuffer is the least time between two shows on a screen.
+	CleaningBuffer time.Duration `yaml:"cleaning_buffer"`
+	// Padding is added to the runtime of a movie, for ads and the interval,
+	// when the end time of a show is left to be computed.
+	Padding time.Duration `yaml:"padding"`
+}
+
+// Storage drivers.
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+type StorageConfig struct {
+	// Driver is where uploaded images are kept, local or s3.
+	Driver   string `yaml:"driver"`
+	LocalDir string `yaml:"local_dir"`
+	// MaxUploadSize is the largest image accepted, in bytes.
+	MaxUploadSize int64    `yaml:"max_upload_size"`
+	S3            S3Config `yaml:"s3"`
+}
+
+// S3Config addresses an S3 compatible bucket, AWS or a stand-in such as MinIO.
+type S3Config struct {
+	Endpoint        string `yaml:"endpoint"`
+	Region          string `yaml:"region"`
+	Bucket          string `yaml:"bucket"`
+	AccessKeyID     string `yaml:"access_key_id"`
+	SecretAccessKey string `yaml:"secret_access_key"`
+	// PathStyle addresses the bucket as endpoint/bucket rather than as a
+	// subdomain, which most stand-ins need.
+	PathStyle bool `yaml:"path_style"`
+}
+
+type envDuration struct {
+	key   string
+	value *time.Duration
+}
+
+// timeouts pairs the server timeouts with the environment variables that
+// override them.
+func (s *ServerConfig) timeouts() []envDuration {
+	return []envDuration{
+		{"SERVER_READ_HEADER_TIMEOUT", &s.ReadHeaderTimeout},
+		{"SERVER_READ_TIMEOUT", &s.ReadTimeout},
+		{"SERVER_WRITE_TIMEOUT", &s.WriteTimeout},
+		{"SERVER_IDLE_TIMEOUT", &s.IdleTimeout},
+		{"SERVER_SHUTDOWN_TIMEOUT", &s.ShutdownTimeout},
+	}
+}
+
+func (d DatabaseConfig) DSN() string {
+	return fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+		d.Host, d.User, d.Password, d.Name, d.Port, d.SSLMode)
+}
+
+func (o OIDCConfig) Enabled() bool {
+	return o.IssuerURL != ""
+}
+
+func defaults() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:              "8080",
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			WriteTimeout:      30 * time.Second,
+			IdleTimeout:       60 * time.Second,
+			ShutdownTimeout:   20 * time.Second,
+		},
+		Database: DatabaseConfig{Port: "5432", SSLMode: "disable"},
+		JWT:      JWTConfig{TTL: 72 * time.Hour},
+		CORS:     CORSConfig{AllowOrigins: []string{"http://localhost:5173"}},
+		OIDC:     OIDCConfig{Scopes: []string{"openid", "email", "profile"}},
+		API:      APIConfig{LegacySunset: time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)},
+		Storage: StorageConfig{
+			Driver:        StorageLocal,
+			LocalDir:      "uploads",
+			MaxUploadSize: 5 << 20,
+			S3:            S3Config{Region: "us-east-1", PathStyle: true},
+		},
+		Shows: ShowsConfig{CleaningBuffer: 15 * time.Minute, Padding: 20 * time.Minute},
+	}
+}
+
+// LoadDatabase loads configuration like Load but only validates the database
+// settings, for commands such as migrate that never serve requests.
+func LoadDatabase() (DatabaseConfig, error) {
+	cfg, err := load()
+	if err != nil {
+		return DatabaseConfig{}, err
+	}
+	if err := cfg.Database.Validate(); err != nil {
+		return DatabaseConfig{}, err
+	}
+	return cfg.Database, nil
+}
+
+// Load builds the configuration from, in increasing order of precedence,
+// built-in defaults, the YAML file named by CONFIG_FILE, and the process
+// environment. A .env file (or the file named by ENV_FILE) is loaded into the
+// environment first when present.
+func Load() (*Config, error) {
+	cfg, err := load()
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func load() (*Config, error) {
+	envFile := os.Getenv("ENV_FILE")
+	if envFile == "" {
+		envFile = ".env"
+	}
+	if err := godotenv.Load(envFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("loading %s: %w", envFile, err)
+	}
+
+	cfg := defaults()
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("reading config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("parsing config file %s: %w", path, err)
+		}
+	}
+
+	if err := cfg.applyEnv(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (cfg *Config) applyEnv() error {
+	setString(&cfg.Server.Port, "PORT")
+	for _, timeout := range cfg.Server.timeouts() {
+		if err := setDuration(timeout.value, timeout.key); err != nil {
+			return err
+		}
+	}
+
+	setString(&cfg.Database.Host, "DB_HOST")
+	setString(&cfg.Database.User, "DB_USER")
+	setString(&cfg.Database.Password, "DB_PASSWORD")
+	setString(&cfg.Database.Name, "DB_NAME")
+	setString(&cfg.Database.Port, "DB_PORT")
+	setString(&cfg.Database.SSLMode, "DB_SSLMODE")
+
+	setString(&cfg.JWT.Secret, "JWT_SECRET")
+	if err := setDuration(&cfg.JWT.TTL, "JWT_TTL"); err != nil {
+		return err
+	}
+
+	setList(&cfg.CORS.AllowOrigins, "CORS_ALLOW_ORIGINS", ",")
+
+	setString(&cfg.PayU.MerchantKey, "PAYU_MERCHANT_KEY")
+	setString(&cfg.PayU.MerchantSalt, "PAYU_MERCHANT_SALT")
+	setString(&cfg.PayU.BaseURL, "PAYU_BASE_URL")
+
+	setString(&cfg.URLs.BaseURL, "BASE_URL")
+	setString(&cfg.URLs.FrontendBaseURL, "FRONTEND_BASE_URL")
+
+	setString(&cfg.OIDC.IssuerURL, "OIDC_ISSUER_URL")
+	setString(&cfg.OIDC.ClientID, "OIDC_CLIENT_ID")
+	setString(&cfg.OIDC.ClientSecret, "OIDC_CLIENT_SECRET")
+	setString(&cfg.OIDC.RedirectURL, "OIDC_REDIRECT_URL")
+	setList(&cfg.OIDC.Scopes, "OIDC_SCOPES", " ")
+
+	if err := setDate(&cfg.API.LegacySunset, "API_LEGACY_SUNSET"); err != nil {
+		return err
+	}
+
+	setString(&cfg.Storage.Driver, "STORAGE_DRIVER")
+	setString(&cfg.Storage.LocalDir, "STORAGE_LOCAL_DIR")
+	if err := setInt64(&cfg.Storage.MaxUploadSize, "STORAGE_MAX_UPLOAD_SIZE"); err != nil {
+		return err
+	}
+	setString(&cfg.Storage.S3.Endpoint, "S3_ENDPOINT")
+	setString(&cfg.Storage.S3.Region, "S3_REGION")
+	setString(&cfg.Storage.S3.Bucket, "S3_BUCKET")
+	setString(&cfg.Storage.S3.AccessKeyID, "S3_ACCESS_KEY_ID")
+	setString(&cfg.Storage.S3.SecretAccessKey, "S3_SECRET_ACCESS_KEY")
+	if err := setBool(&cfg.Storage.S3.PathStyle, "S3_PATH_STYLE"); err != nil {
+		return err
+	}
+
+	if err := setDuration(&cfg.Shows.CleaningBuffer, "SHOW_CLEANING_BUFFER"); err != nil {
+		return err
+	}
+	if err := setDuration(&cfg.Shows.Padding, "SHOW_PADDING"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate reports every missing or malformed setting at once so a
+// misconfigured deployment fails at startup rather than mid-request.
+func (cfg *Config) Validate() error {
+	var problems []string
+	require := func(value, name string) {
+		if strings.TrimSpace(value) == "" {
+			problems = append(problems, name+" is required")
+		}
+	}
+	requireURL := func(value, name string) {
+		require(value, name)
+		if value == "" {
+			return
+		}
+		if u, err := url.Parse(value); err != nil || u.Scheme == "" || u.Host == "" {
+			problems = append(problems, name+" must be an absolute URL")
+		}
+	}
+
+	require(cfg.Server.Port, "PORT")
+	for _, timeout := range cfg.Server.timeouts() {
+		if *timeout.value <= 0 {
+			problems = append(problems, timeout.key+" must be positive")
+		}
+	}
+	if err := cfg.Database.Validate(); err != nil {
+		problems = append(problems, err.Error())
+	}
+	require(cfg.JWT.Secret, "JWT_SECRET")
+	if cfg.JWT.TTL <= 0 {
+		problems = append(problems, "JWT_TTL must be positive")
+	}
+	if len(cfg.CORS.AllowOrigins) == 0 {
+		problems = append(problems, "CORS_ALLOW_ORIGINS is required")
+	}
+	require(cfg.PayU.MerchantKey, "PAYU_MERCHANT_KEY")
+	require(cfg.PayU.MerchantSalt, "PAYU_MERCHANT_SALT")
+	requireURL(cfg.PayU.BaseURL, "PAYU_BASE_URL")
+	requireURL(cfg.URLs.BaseURL, "BASE_URL")
+	requireURL(cfg.URLs.FrontendBaseURL, "FRONTEND_BASE_URL")
+
+	if cfg.OIDC.Enabled() {
+		requireURL(cfg.OIDC.IssuerURL, "OIDC_ISSUER_URL")
+		require(cfg.OIDC.ClientID, "OIDC_CLIENT_ID")
+		requireURL(cfg.OIDC.RedirectURL, "OIDC_REDIRECT_URL")
+	}
+
+	switch cfg.Storage.Driver {
+	case StorageLocal:
+		require(cfg.Storage.LocalDir, "STORAGE_LOCAL_DIR")
+	case StorageS3:
+		requireURL(cfg.Storage.S3.Endpoint, "S3_ENDPOINT")
+		require(cfg.Storage.S3.Region, "S3_REGION")
+		require(cfg.Storage.S3.Bucket, "S3_BUCKET")
+		require(cfg.Storage.S3.AccessKeyID, "S3_ACCESS_KEY_ID")
+		require(cfg.Storage.S3.SecretAccessKey, "S3_SECRET_ACCESS_KEY")
+	default:
+		problems = append(problems, "STORAGE_DRIVER must be local or s3")
+	}
+	if cfg.Storage.MaxUploadSize <= 0 {
+		problems = append(problems, "STORAGE_MAX_UPLOAD_SIZE must be positive")
+	}
+	if cfg.Shows.CleaningBuffer < 0 {
+		problems = append(problems, "SHOW_CLEANING_BUFFER must not be negative")
+	}
+	if cfg.Shows.Padding < 0 {
+		problems = append(problems, "SHOW_PADDING must not be negative")
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func (d DatabaseConfig) Validate() error {
+	var missing []string
+	for name, value := range map[string]string{
+		"DB_HOST": d.Host,
+		"DB_USER": d.User,
+		"DB_NAME": d.Name,
+		"DB_PORT": d.Port,
+	} {
+		if strings.TrimSpace(value) == "" {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("missing database settings: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+func setString(dst *string, key string) {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		*dst = value
+	}
+}
+
+func setList(dst *[]string, key, sep string) {
+	value, ok := os.LookupEnv(key)
+	if !ok || strings.TrimSpace(value) == "" {
+		return
+	}
+	var items []string
+	for _, item := range strings.Split(value, sep) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*dst = items
+}
+
+func setDuration(dst *time.Duration, key string) error {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", key, err)
+	}
+	*dst = d
+	return nil
+}
+
+func setInt64(dst *int64, key string) error {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return nil
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", key, err)
+	}
+	*dst = n
+	return nil
+}
+
+func setBool(dst *bool, key string) error {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", key, err)
+	}
+	*dst = b
+	return nil
+}
+
+func setDate(dst *time.Time, key string) error {
+	value, ok := os.LookupEnv(key)
+	if !ok || value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return fmt.Errorf("invalid %s, expected YYYY-MM-DD: %w", key, err)
+	}
+	*dst = t
+	return nil
+}
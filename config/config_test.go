@@ -0,0 +1,196 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFile stores content in a file of a fresh temporary directory.
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// loadWith loads the configuration from the YAML and .env contents given and
+// the env variables, any of them may be empty.
+func loadWith(t *testing.T, yamlContent, dotenv string, env map[string]string) (*Config, error) {
+	t.Helper()
+	t.Setenv("CONFIG_FILE", "")
+	if yamlContent != "" {
+		t.Setenv("CONFIG_FILE", writeFile(t, "config.yaml", yamlContent))
+	}
+	t.Setenv("ENV_FILE", filepath.Join(t.TempDir(), "missing.env"))
+	if dotenv != "" {
+		t.Setenv("ENV_FILE", writeFile(t, ".env", dotenv))
+		// godotenv sets the variables for good, unset them after the test
+		for _, line := range strings.Split(dotenv, "\n") {
+			if key, _, ok := strings.Cut(line, "="); ok {
+				if _, set := os.LookupEnv(key); !set {
+					t.Cleanup(func() { os.Unsetenv(key) })
+				}
+			}
+		}
+	}
+	for key, value := range env {
+		t.Setenv(key, value)
+	}
+	return load()
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	yamlContent := `
+server:
+  port: "7000"
+  read_timeout: 20s
+database:
+  host: yaml-host
+  name: yaml-db
+jwt:
+  secret: yaml-secret
+shows:
+  padding: 10m
+`
+	dotenv := "DB_HOST=dotenv-host\nJWT_SECRET=dotenv-secret\n"
+	// empty variables count as unset
+	cfg, err := loadWith(t, yamlContent, dotenv, map[string]string{
+		"DB_HOST":              "env-host",
+		"PORT":                 "",
+		"SERVER_READ_TIMEOUT":  "",
+		"SERVER_WRITE_TIMEOUT": "",
+		"DB_NAME":              "",
+		"SHOW_PADDING":         "",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		got  interface{}
+		want interface{}
+	}{
+		{"default", cfg.Server.WriteTimeout, 30 * time.Second},
+		{"YAML over default", cfg.Server.Port, "7000"},
+		{"YAML over default duration", cfg.Shows.Padding, 10 * time.Minute},
+		{"empty env keeps YAML", cfg.Server.ReadTimeout, 20 * time.Second},
+		{".env over YAML", cfg.JWT.Secret, "dotenv-secret"},
+		{"env over .env", cfg.Database.Host, "env-host"},
+		{"YAML without override", cfg.Database.Name, "yaml-db"},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestLoadRejectsMalformedValues(t *testing.T) {
+	tests := []struct {
+		key, value string
+	}{
+		{"SERVER_READ_TIMEOUT", "soon"},
+		{"JWT_TTL", "72"},
+		{"SHOW_CLEANING_BUFFER", "15 minutes"},
+		{"SHOW_PADDING", "x"},
+		{"API_LEGACY_SUNSET", "30/04/2027"},
+		{"STORAGE_MAX_UPLOAD_SIZE", "5MB"},
+		{"S3_PATH_STYLE", "maybe"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			_, err := loadWith(t, "", "", map[string]string{tt.key: tt.value})
+			if err == nil || !strings.Contains(err.Error(), tt.key) {
+				t.Fatalf("got %v, want an error naming %s", err, tt.key)
+			}
+		})
+	}
+
+	_, err := loadWith(t, "jwt: [", "", nil)
+	if err == nil || !strings.Contains(err.Error(), "parsing config file") {
+		t.Errorf("malformed YAML: got %v", err)
+	}
+}
+
+// validConfig returns settings that pass Validate.
+func validConfig() *Config {
+	cfg := defaults()
+	cfg.Database = DatabaseConfig{Host: "localhost", User: "postgres", Name: "movies", Port: "5432"}
+	cfg.JWT.Secret = "secret"
+	cfg.PayU = PayUConfig{MerchantKey: "key", MerchantSalt: "salt", BaseURL: "https://test.payu.in"}
+	cfg.URLs = URLConfig{BaseURL: "http://localhost:8080", FrontendBaseURL: "http://localhost:5173"}
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(cfg *Config)
+		want   string
+	}{
+		{"valid", func(cfg *Config) {}, ""},
+		{"missing JWT secret", func(cfg *Config) { cfg.JWT.Secret = " " }, "JWT_SECRET is required"},
+		{"zero JWT ttl", func(cfg *Config) { cfg.JWT.TTL = 0 }, "JWT_TTL must be positive"},
+		{"negative timeout", func(cfg *Config) { cfg.Server.IdleTimeout = -time.Second }, "SERVER_IDLE_TIMEOUT must be positive"},
+		{"missing database settings", func(cfg *Config) { cfg.Database.Host, cfg.Database.Name = "", "" }, "missing database settings: DB_HOST, DB_NAME"},
+		{"no CORS origins", func(cfg *Config) { cfg.CORS.AllowOrigins = nil }, "CORS_ALLOW_ORIGINS is required"},
+		{"relative URL", func(cfg *Config) { cfg.URLs.FrontendBaseURL = "/app" }, "FRONTEND_BASE_URL must be an absolute URL"},
+		{"OIDC without client", func(cfg *Config) { cfg.OIDC.IssuerURL = "https://idp.test" }, "OIDC_CLIENT_ID is required"},
+		{"unknown storage driver", func(cfg *Config) { cfg.Storage.Driver = "ftp" }, "STORAGE_DRIVER must be local or s3"},
+		{"s3 without bucket", func(cfg *Config) {
+			cfg.Storage.Driver = StorageS3
+			cfg.Storage.S3 = S3Config{Endpoint: "http://localhost:9000", Region: "us-east-1", AccessKeyID: "id", SecretAccessKey: "key"}
+		}, "S3_BUCKET is required"},
+		{"zero upload size", func(cfg *Config) { cfg.Storage.MaxUploadSize = 0 }, "STORAGE_MAX_UPLOAD_SIZE must be positive"},
+		{"negative cleaning buffer", func(cfg *Config) { cfg.Shows.CleaningBuffer = -time.Minute }, "SHOW_CLEANING_BUFFER must not be negative"},
+		{"negative padding", func(cfg *Config) { cfg.Shows.Padding = -time.Minute }, "SHOW_PADDING must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.change(cfg)
+			err := cfg.Validate()
+			switch {
+			case tt.want == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)):
+				t.Fatalf("got %v, want %q", err, tt.want)
+			}
+		})
+	}
+
+	// every problem is reported at once
+	cfg := validConfig()
+	cfg.JWT.Secret = ""
+	cfg.Storage.Driver = "ftp"
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "JWT_SECRET") || !strings.Contains(err.Error(), "STORAGE_DRIVER") {
+		t.Errorf("got %v, want both problems", err)
+	}
+}
+
+func TestExampleConfigLoads(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "config.example.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadWith(t, string(data), "", map[string]string{
+		"PAYU_MERCHANT_KEY":  "key",
+		"PAYU_MERCHANT_SALT": "salt",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC); !cfg.API.LegacySunset.Equal(want) {
+		t.Errorf("legacy sunset %v, want %v", cfg.API.LegacySunset, want)
+	}
+}
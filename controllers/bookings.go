@@ -1,65 +1,59 @@
 package controllers
 
 import (
+	"backend/errs"
 	"backend/models"
+	"backend/repository"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
-func GetBookingDetails(c *gin.Context) {
+type BookingHandler struct {
+	bookings repository.BookingRepository
+	shows    repository.ShowRepository
+}
+
+func NewBookingHandler(bookings repository.BookingRepository, shows repository.ShowRepository) *BookingHandler {
+	return &BookingHandler{bookings: bookings, shows: shows}
+}
+
+func (h *BookingHandler) GetBookingDetails(c *gin.Context) {
 	txnID := c.Param("txnid")
 
-	type result struct {
-		TxnID    string
-		Amount   float64
-		Status   string
-		Seats    string
-		ShowID   int
-		Movie    string
-		Theatre  string
-		Date     string
-		ShowTime string
+	booking, err := h.bookings.GetByTxnID(c.Request.Context(), txnID)
+	if err != nil {
+		errs.Abort(c, errs.NotFound("Booking not found"))
+		return
 	}
 
-	var r result
-	if err := models.DB.Table("bookings").
-		Select(`
-		bookings.txn_id,
-		bookings.amount,
-		bookings.status,
-		bookings.seats,
-		bookings.show_id,
-		movies.movie_name AS movie,
-		theatres.theatre_name AS theatre,
-		shows.date,
-		shows.start_time
-	`).
-		Joins(`JOIN shows ON bookings.show_id = shows.show_id`).
-		Joins(`JOIN movies ON shows.movie_id = movies.movie_id`).
-		Joins(`JOIN theatres ON shows.theatre_id = theatres.theatre_id`).
-		Where("bookings.txn_id = ?", txnID).
-		Scan(&r).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+	show, err := h.shows.Get(c.Request.Context(), booking.ShowID)
+	if err != nil {
+		errs.Abort(c, errs.NotFound("Booking not found"))
 		return
 	}
 
 	var seatList []string
-	if r.Seats != "" {
-		seatList = strings.Split(r.Seats, ",")
+	if booking.Seats != "" {
+		seatList = strings.Split(booking.Seats, ",")
 	}
 
 	response := models.BookingDetailsResponse{
-		TxnID:    r.TxnID,
-		Amount:   r.Amount,
-		Status:   r.Status,
+		TxnID:    booking.TxnID,
+		Amount:   float64(booking.Amount),
+		Status:   booking.Status,
 		Seats:    seatList,
-		Movie:    r.Movie,
-		Theatre:  r.Theatre,
-		Date:     r.Date,
-		ShowTime: r.ShowTime,
-		ShowID:   r.ShowID,
+		Movie:    show.Movie.MovieName,
+		Theatre:  show.Theatre.TheatreName,
+		Date:     show.Date.Format(time.RFC3339Nano),
+		ShowTime: show.StartTime.Format(time.RFC3339Nano),
+		ShowID:   int(show.ShowID),
+		StartsAt: show.StartsAt,
+
+		Certification:   show.Movie.Certification,
+		IDCheckRequired: show.Movie.Certification == models.CertificationA,
 	}
 
 	c.JSON(http.StatusOK, response)
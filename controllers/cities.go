@@ -1,73 +1,81 @@
 package controllers
 
 import (
-	"backend/models"
+	"context"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
+
+	"backend/errs"
+	"backend/models"
+	"backend/repository"
 )
 
-func CreateCity(c *gin.Context) {
-	var city models.City
-	if err := c.ShouldBindJSON(&city); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+type CityHandler struct {
+	cities repository.CityRepository
+	states repository.StateRepository
+}
+
+func NewCityHandler(cities repository.CityRepository, states repository.StateRepository) *CityHandler {
+	return &CityHandler{cities: cities, states: states}
+}
+
+// checkState reports a missing state as a bad request, the city refers to it.
+func (h *CityHandler) checkState(ctx context.Context, stateID int) error {
+	if _, err := h.states.Get(ctx, stateID); err != nil {
+		if err == repository.ErrNotFound {
+			return errs.BadRequest("Associated state not found")
+		}
+		return err
 	}
+	return nil
+}
 
-	db, ok := c.MustGet("db").(*gorm.DB)
-	if !ok {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Database connection not found"})
+func (h *CityHandler) CreateCity(c *gin.Context) {
+	var city models.City
+	if err := c.ShouldBindJSON(&city); err != nil {
+		errs.Abort(c, errs.Binding(err))
 		return
 	}
 
-	var state models.State
-	if err := db.First(&state, city.StateID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Associated state not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.checkState(c.Request.Context(), city.StateID); err != nil {
+		errs.Abort(c, err)
 		return
 	}
 
-	if err := db.Create(&city).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.cities.Create(c.Request.Context(), &city); err != nil {
+		errs.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, city)
 }
 
-func GetCities(c *gin.Context) {
-	db := c.MustGet("db").(*gorm.DB)
-
-	var cities []models.City
-	if err := db.Find(&cities).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+func (h *CityHandler) GetCities(c *gin.Context) {
+	cities, err := h.cities.List(c.Request.Context())
+	if err != nil {
+		errs.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, cities)
 }
 
-func GetCityByID(c *gin.Context) {
+func (h *CityHandler) GetCityByID(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid city ID"})
+		errs.Abort(c, errs.BadRequest("Invalid city ID"))
 		return
 	}
 
-	db := c.MustGet("db").(*gorm.DB)
-
-	var city models.City
-	if err := db.First(&city, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "City not found"})
+	city, err := h.cities.Get(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("City not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			errs.Abort(c, err)
 		}
 		return
 	}
@@ -75,75 +83,64 @@ func GetCityByID(c *gin.Context) {
 	c.JSON(http.StatusOK, city)
 }
 
-func DeleteCity(c *gin.Context) {
+func (h *CityHandler) DeleteCity(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid city ID"})
+		errs.Abort(c, errs.BadRequest("Invalid city ID"))
 		return
 	}
 
-	db := c.MustGet("db").(*gorm.DB)
-
-	var city models.City
-	if err := db.First(&city, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "City not found"})
+	if err := h.cities.Delete(c.Request.Context(), id); err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("City not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			errs.Abort(c, err)
 		}
 		return
 	}
 
-	if err := db.Delete(&city).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
 	c.JSON(http.StatusOK, gin.H{"message": "City deleted successfully"})
 }
 
-func UpdateCity(c *gin.Context) {
+func (h *CityHandler) UpdateCity(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid city ID"})
+		errs.Abort(c, errs.BadRequest("Invalid city ID"))
 		return
 	}
 
-	db := c.MustGet("db").(*gorm.DB)
-
-	var city models.City
-	if err := db.First(&city, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "City not found"})
+	city, err := h.cities.Get(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("City not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			errs.Abort(c, err)
 		}
 		return
 	}
 
 	var input models.City
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		errs.Abort(c, errs.Binding(err))
 		return
 	}
 
-	var state models.State
-	if err := db.First(&state, input.StateID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Associated state not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.checkState(c.Request.Context(), input.StateID); err != nil {
+		errs.Abort(c, err)
 		return
 	}
 
 	city.CityName = input.CityName
 	city.StateID = input.StateID
+	// scheduled shows keep their instants and time zone
+	if input.Timezone != "" {
+		city.Timezone = input.Timezone
+	}
 
-	if err := db.Save(&city).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.cities.Update(c.Request.Context(), city); err != nil {
+		errs.Abort(c, err)
 		return
 	}
 
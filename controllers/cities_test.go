@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"backend/models"
+	"backend/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCitiesNeedAnExistingState(t *testing.T) {
+	repos := memory.New()
+	ctx := context.Background()
+	state := models.State{StateName: "Goa"}
+	if err := repos.States.Create(ctx, &state); err != nil {
+		t.Fatal(err)
+	}
+	city := models.City{StateID: state.StateID, CityName: "Panaji"}
+	if err := repos.Cities.Create(ctx, &city); err != nil {
+		t.Fatal(err)
+	}
+
+	h := NewCityHandler(repos.Cities, repos.States)
+	router := testRouter(func(router *gin.Engine) {
+		router.POST("/cities", h.CreateCity)
+		router.PUT("/cities/:id", h.UpdateCity)
+		router.DELETE("/cities/:id", h.DeleteCity)
+	})
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   interface{}
+		want   int
+	}{
+		{"create", http.MethodPost, "/cities", models.City{StateID: state.StateID, CityName: "Margao"}, http.StatusCreated},
+		{"create in unknown state", http.MethodPost, "/cities", models.City{StateID: 99, CityName: "Margao"}, http.StatusBadRequest},
+		{"move to unknown state", http.MethodPut, "/cities/1", models.City{StateID: 99, CityName: "Panaji"}, http.StatusBadRequest},
+		{"update unknown city", http.MethodPut, "/cities/99", models.City{StateID: state.StateID, CityName: "Panaji"}, http.StatusNotFound},
+		{"delete", http.MethodDelete, "/cities/1", nil, http.StatusOK},
+		{"delete again", http.MethodDelete, "/cities/1", nil, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := serve(t, router, tt.method, tt.path, tt.body); w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
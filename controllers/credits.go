@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/errs"
+	"backend/models"
+	"backend/repository"
+)
+
+// CreditInput is the body of POST /movies/:id/credits. Actors may name the
+// character they play, crew members must name their job.
+type CreditInput struct {
+	PersonID     int    `json:"person_id" binding:"required,min=1"`
+	Role         string `json:"role" binding:"required,oneof=actor director crew"`
+	Character    string `json:"character" binding:"max=255"`
+	Job          string `json:"job" binding:"max=100"`
+	BillingOrder int    `json:"billing_order" binding:"min=0" doc:"Position in the credits, lower first"`
+}
+
+// MovieCredits is the cast and crew of a movie in billing order, directors
+// lead the crew.
+type MovieCredits struct {
+	MovieID int              `json:"movie_id"`
+	Cast    []CreditResponse `json:"cast"`
+	Crew    []CreditResponse `json:"crew"`
+}
+
+type CreditResponse struct {
+	CreditID     int    `json:"credit_id"`
+	PersonID     int    `json:"person_id"`
+	Name         string `json:"name"`
+	ProfileURL   string `json:"profile_url"`
+	Role         string `json:"role"`
+	Character    string `json:"character,omitempty"`
+	Job          string `json:"job,omitempty"`
+	BillingOrder int    `json:"billing_order"`
+}
+
+func (h *PersonHandler) GetMovieCredits(c *gin.Context) {
+	movieID, ok := h.movieID(c)
+	if !ok {
+		return
+	}
+
+	credits, err := h.people.ListCredits(c.Request.Context(), movieID)
+	if err != nil {
+		errs.Abort(c, err)
+		return
+	}
+
+	response := MovieCredits{MovieID: movieID, Cast: []CreditResponse{}, Crew: []CreditResponse{}}
+	for _, credit := range credits {
+		entry := CreditResponse{
+			CreditID:     credit.CreditID,
+			PersonID:     credit.PersonID,
+			Name:         credit.Person.Name,
+			ProfileURL:   credit.Person.ProfileURL,
+			Role:         credit.Role,
+			Character:    credit.Character,
+			Job:          credit.Job,
+			BillingOrder: credit.BillingOrder,
+		}
+		if credit.Role == models.CreditRoleActor {
+			response.Cast = append(response.Cast, entry)
+		} else {
+			response.Crew = append(response.Crew, entry)
+		}
+	}
+	sort.SliceStable(response.Crew, func(i, j int) bool {
+		return response.Crew[i].Role == models.CreditRoleDirector && response.Crew[j].Role != models.CreditRoleDirector
+	})
+
+	c.JSON(http.StatusOK, response)
+}
+
+func (h *PersonHandler) AddMovieCredit(c *gin.Context) {
+	movieID, ok := h.movieID(c)
+	if !ok {
+		return
+	}
+
+	var input CreditInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		errs.Abort(c, errs.Binding(err))
+		return
+	}
+	switch {
+	case input.Role != models.CreditRoleActor && input.Character != "":
+		errs.Abort(c, errs.Validation("character", "is only for actors"))
+		return
+	case input.Role == models.CreditRoleCrew && input.Job == "":
+		errs.Abort(c, errs.Validation("job", "is required for crew"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	if _, err := h.people.Get(ctx, input.PersonID); err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.Validation("person_id", "does not exist"))
+		} else {
+			errs.Abort(c, err)
+		}
+		return
+	}
+
+	credit := models.MovieCredit{
+		MovieID:      movieID,
+		PersonID:     input.PersonID,
+		Role:         input.Role,
+		Character:    input.Character,
+		Job:          input.Job,
+		BillingOrder: input.BillingOrder,
+		CreatedAt:    time.Now(),
+	}
+	if err := h.people.AddCredit(ctx, &credit); err != nil {
+		if err == repository.ErrDuplicate {
+			errs.Abort(c, errs.Conflict("The person already has this role in the movie"))
+		} else {
+			errs.Abort(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, credit)
+}
+
+func (h *PersonHandler) DeleteMovieCredit(c *gin.Context) {
+	movieID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errs.Abort(c, errs.BadRequest("Invalid movie ID"))
+		return
+	}
+	creditID, err := strconv.Atoi(c.Param("credit_id"))
+	if err != nil {
+		errs.Abort(c, errs.BadRequest("Invalid credit ID"))
+		return
+	}
+
+	if err := h.people.DeleteCredit(c.Request.Context(), movieID, creditID); err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Credit not found"))
+		} else {
+			errs.Abort(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Credit deleted successfully"})
+}
+
+// movieID reads the id path parameter of a movie that exists and writes the
+// error response when it cannot.
+func (h *PersonHandler) movieID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errs.Abort(c, errs.BadRequest("Invalid movie ID"))
+		return 0, false
+	}
+
+	if _, err := h.movies.Get(c.Request.Context(), id); err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Movie not found"))
+		} else {
+			errs.Abort(c, err)
+		}
+		return 0, false
+	}
+	return id, true
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"backend/migrations"
+)
+
+// readinessTimeout bounds the database checks so a hung connection fails the
+// probe instead of stalling it.
+const readinessTimeout = 2 * time.Second
+
+var errDatabaseNotConfigured = errors.New("database is not configured")
+
+type HealthHandler struct {
+	db *gorm.DB
+}
+
+func NewHealthHandler(db *gorm.DB) *HealthHandler {
+	return &HealthHandler{db: db}
+}
+
+type Health struct {
+	Status string `json:"status"`
+}
+
+// Readiness reports each check. Error names the check that failed, the cause
+// is only logged.
+type Readiness struct {
+	Status                string `json:"status"`
+	Database              string `json:"database"`
+	SchemaVersion         int    `json:"schema_version"`
+	ExpectedSchemaVersion int    `json:"expected_schema_version"`
+	Error                 string `json:"error,omitempty"`
+}
+
+// Healthz reports that the process is up and serving, it does not touch the
+// database so a database outage does not get the process restarted.
+func (h *HealthHandler) Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, Health{Status: "ok"})
+}
+
+// Readyz reports whether requests can be served: the database answers and its
+// schema is at the version this binary was built with. It responds 503 with
+// the same body otherwise.
+func (h *HealthHandler) Readyz(c *gin.Context) {
+	ready := Readiness{Status: "unavailable", Database: "unavailable"}
+	if err := h.check(c.Request.Context(), &ready); err != nil {
+		log.Println("Readiness check failed:", err)
+		ready.Error = "database unavailable"
+		if ready.Database == "ok" {
+			ready.Error = "schema out of date"
+		}
+		c.JSON(http.StatusServiceUnavailable, ready)
+		return
+	}
+	ready.Status = "ready"
+	c.JSON(http.StatusOK, ready)
+}
+
+func (h *HealthHandler) check(ctx context.Context, ready *Readiness) error {
+	latest, err := migrations.Latest()
+	if err != nil {
+		return err
+	}
+	ready.ExpectedSchemaVersion = latest
+
+	if h.db == nil {
+		return errDatabaseNotConfigured
+	}
+	ctx, cancel := context.WithTimeout(ctx, readinessTimeout)
+	defer cancel()
+
+	sqlDB, err := h.db.DB()
+	if err != nil {
+		return err
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		return err
+	}
+	ready.Database = "ok"
+
+	current, err := migrations.CurrentVersion(h.db.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("reading schema version: %w", err)
+	}
+	ready.SchemaVersion = current
+	if current != latest {
+		return fmt.Errorf("database schema is at version %d, expected %d", current, latest)
+	}
+	return nil
+}
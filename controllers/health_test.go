@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestReadyzHidesTheCause(t *testing.T) {
+	h := NewHealthHandler(nil)
+	router := testRouter(func(router *gin.Engine) { router.GET("/readyz", h.Readyz) })
+
+	w := serve(t, router, http.MethodGet, "/readyz", nil)
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status = %d, want 503", w.Code)
+	}
+	var ready Readiness
+	if err := json.Unmarshal(w.Body.Bytes(), &ready); err != nil {
+		t.Fatal(err)
+	}
+	if ready.Database != "unavailable" || ready.Error != "database unavailable" {
+		t.Errorf("unexpected readiness %+v", ready)
+	}
+}
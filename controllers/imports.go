@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/config"
+	"backend/errs"
+	"backend/repository"
+	"backend/tmdb"
+)
+
+// maxImportSize bounds the exports accepted over HTTP, larger ones go
+// through the import command.
+const maxImportSize = 64 << 20
+
+type ImportHandler struct {
+	tx             repository.Transactor
+	showPadding    time.Duration
+	cleaningBuffer time.Duration
+}
+
+func NewImportHandler(cfg *config.Config, tx repository.Transactor) *ImportHandler {
+	return &ImportHandler{tx: tx, showPadding: cfg.Shows.Padding, cleaningBuffer: cfg.Shows.CleaningBuffer}
+}
+
+// ImportTMDB imports the TMDB export sent as the request body. With
+// dry_run=true it reports what would change without writing anything.
+func (h *ImportHandler) ImportTMDB(c *gin.Context) {
+	opts := tmdb.DefaultOptions()
+	opts.ShowPadding = h.showPadding
+	opts.CleaningBuffer = h.cleaningBuffer
+	if value := c.Query("dry_run"); value != "" {
+		dryRun, err := strconv.ParseBool(value)
+		if err != nil {
+			errs.Abort(c, errs.Validation("dry_run", "must be true or false"))
+			return
+		}
+		opts.DryRun = dryRun
+	}
+	if country := c.Query("country"); country != "" {
+		opts.Country = country
+	}
+
+	movies, err := tmdb.Decode(http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			errs.Abort(c, errs.BadRequest("Export is larger than 64 MiB, use the import command"))
+		} else {
+			errs.Abort(c, &errs.Error{Code: errs.CodeBadRequest, Message: "Request body is not a TMDB export", Cause: err})
+		}
+		return
+	}
+
+	report, err := tmdb.Import(c.Request.Context(), h.tx, movies, opts)
+	if err != nil {
+		errs.Abort(c, errs.Internal("Import failed, nothing was written", err))
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
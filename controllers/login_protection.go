@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/errs"
+	"backend/models"
+	"backend/repository"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	loginAttemptWindow       = 15 * time.Minute
+	maxFailedLoginsPerIP     = 20
+	maxFailedLoginsPerUser   = 5
+	loginDelayAfterFailures  = 3
+	maxLoginDelay            = time.Minute
+	accountLockoutDuration   = 15 * time.Minute
+	securityEventIPThrottled = "ip_throttled"
+	securityEventLocked      = "account_locked"
+	securityEventUnlocked    = "account_unlocked"
+	securityEventLockedLogin = "locked_account_login"
+	securityEventSlowedLogin = "slowed_down_login"
+)
+
+// loginDelay returns how long an account has to wait after its last failed
+// login before another attempt is accepted. It doubles with every failure
+// past loginDelayAfterFailures and is capped at maxLoginDelay.
+func loginDelay(failures int) time.Duration {
+	if failures < loginDelayAfterFailures {
+		return 0
+	}
+	delay := time.Duration(math.Pow(2, float64(failures-loginDelayAfterFailures))) * time.Second
+	if delay > maxLoginDelay {
+		return maxLoginDelay
+	}
+	return delay
+}
+
+func abortWithRetryAfter(c *gin.Context, code errs.Code, message string, retryAfter time.Duration) {
+	if retryAfter < time.Second {
+		retryAfter = time.Second
+	}
+	errs.Abort(c, errs.Throttled(code, message, retryAfter))
+}
+
+func (h *UserHandler) failedLoginsFromIP(c *gin.Context, ip string) (int64, error) {
+	return h.security.CountFailedLoginsFromIP(c.Request.Context(), ip, time.Now().Add(-loginAttemptWindow))
+}
+
+func (h *UserHandler) recordLoginAttempt(c *gin.Context, user *models.User, email, ip string, success bool) {
+	attempt := models.LoginAttempt{
+		Email:     email,
+		IPAddress: ip,
+		Success:   success,
+		CreatedAt: time.Now(),
+	}
+	if user != nil {
+		attempt.UserID = &user.UserID
+	}
+	if err := h.security.RecordLoginAttempt(c.Request.Context(), &attempt); err != nil {
+		log.Println("Failed to record login attempt:", err)
+	}
+}
+
+// recordFailedLogin records a failed attempt from ip, which had ipFailures
+// failures within loginAttemptWindow before it, and the ip_throttled event
+// when this one reaches maxFailedLoginsPerIP.
+func (h *UserHandler) recordFailedLogin(c *gin.Context, user *models.User, email, ip string, ipFailures int64) {
+	h.recordLoginAttempt(c, user, email, ip, false)
+	if ipFailures+1 == maxFailedLoginsPerIP {
+		h.recordSecurityEvent(c, nil, email, ip, securityEventIPThrottled,
+			fmt.Sprintf("%d failed logins within %s", maxFailedLoginsPerIP, loginAttemptWindow))
+	}
+}
+
+func (h *UserHandler) recordSecurityEvent(c *gin.Context, userID *int, email, ip, eventType, details string) {
+	event := models.SecurityEvent{
+		UserID:    userID,
+		Email:     email,
+		IPAddress: ip,
+		EventType: eventType,
+		Details:   details,
+		CreatedAt: time.Now(),
+	}
+	if err := h.security.RecordEvent(c.Request.Context(), &event); err != nil {
+		log.Println("Failed to record security event:", err)
+	}
+}
+
+// registerFailedLogin bumps the consecutive failure counter of user and locks
+// the account once maxFailedLoginsPerUser is reached. Failures older than
+// loginAttemptWindow no longer count towards the lockout. The counter is
+// bumped in the database so concurrent failures are all counted.
+func (h *UserHandler) registerFailedLogin(c *gin.Context, user *models.User, ip string) {
+	now := time.Now()
+	until := now.Add(accountLockoutDuration)
+	failures, err := h.users.RegisterFailedLogin(c.Request.Context(), user.UserID, now, loginAttemptWindow, maxFailedLoginsPerUser, until)
+	if err != nil {
+		log.Println("Failed to update failed login counter:", err)
+		return
+	}
+	if failures == maxFailedLoginsPerUser {
+		h.recordSecurityEvent(c, &user.UserID, user.Email, ip, securityEventLocked,
+			fmt.Sprintf("%d consecutive failed logins, locked until %s", failures, until.Format(time.RFC3339)))
+	}
+}
+
+func (h *UserHandler) resetFailedLogins(c *gin.Context, user *models.User) {
+	if user.FailedLoginAttempts == 0 && user.LastFailedLoginAt == nil && user.LockedUntil == nil {
+		return
+	}
+	if err := h.users.UpdateLoginState(c.Request.Context(), user.UserID, 0, nil, nil); err != nil {
+		log.Println("Failed to reset failed login counter:", err)
+	}
+}
+
+func (h *UserHandler) UnlockUser(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errs.Abort(c, errs.BadRequest("Invalid user ID"))
+		return
+	}
+
+	user, err := h.users.Get(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("User not found"))
+		} else {
+			errs.Abort(c, err)
+		}
+		return
+	}
+
+	if err := h.users.UpdateLoginState(c.Request.Context(), user.UserID, 0, nil, nil); err != nil {
+		errs.Abort(c, errs.Internal("Failed to unlock user", err))
+		return
+	}
+
+	admin := c.MustGet("user").(map[string]interface{})
+	h.recordSecurityEvent(c, &user.UserID, user.Email, c.ClientIP(), securityEventUnlocked,
+		fmt.Sprintf("unlocked by admin user %v", admin["user_id"]))
+
+	c.JSON(http.StatusOK, gin.H{"message": "User unlocked successfully"})
+}
+
+func (h *UserHandler) GetSecurityEvents(c *gin.Context) {
+	filter := repository.SecurityEventFilter{
+		EventType: c.Query("event_type"),
+		Limit:     200,
+	}
+	if userIDParam := c.Query("user_id"); userIDParam != "" {
+		userID, err := strconv.Atoi(userIDParam)
+		if err != nil {
+			errs.Abort(c, errs.BadRequest("Invalid user ID"))
+			return
+		}
+		filter.UserID = &userID
+	}
+
+	events, err := h.security.ListEvents(c.Request.Context(), filter)
+	if err != nil {
+		errs.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
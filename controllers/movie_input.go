@@ -0,0 +1,303 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/errs"
+	"backend/models"
+)
+
+// MovieInput is the body of POST /movies and PUT /movies/:id. The status is
+// not part of it, it is derived from the dates and the scheduled shows.
+// Either genres or, from older clients, genre is required.
+type MovieInput struct {
+	MovieName        string     `json:"movie_name" binding:"required,max=255"`
+	MovieDescription string     `json:"movie_description" binding:"required"`
+	Duration         *Minutes   `json:"duration" binding:"required,min=1"`
+	Languages        []string   `json:"languages" binding:"required,min=1,dive,required"`
+	Genre            string     `json:"genre" binding:"omitempty,max=100" doc:"Deprecated, a single genre used when genres is not given"`
+	Genres           []string   `json:"genres" binding:"omitempty,max=10,dive,required,max=100"`
+	PosterURL        string     `json:"poster_url" binding:"omitempty,url"`
+	TrailerURL       string     `json:"trailer_url" binding:"omitempty,url"`
+	Certification    string     `json:"certification" binding:"omitempty,oneof=U UA A"`
+	ReleaseDate      *MovieDate `json:"release_date"`
+	OriginalLanguage string     `json:"original_language" binding:"omitempty,max=50"`
+	StartDate        *MovieDate `json:"start_date" binding:"required"`
+	EndDate          *MovieDate `json:"end_date" binding:"required"`
+}
+
+// MoviePatch is the body of PATCH /movies/:id, only the fields present are
+// changed. Like a JSON merge patch, null clears an optional field.
+type MoviePatch struct {
+	MovieName        *string    `json:"movie_name" binding:"omitempty,min=1,max=255"`
+	MovieDescription *string    `json:"movie_description" binding:"omitempty,min=1"`
+	Duration         *Minutes   `json:"duration" binding:"omitempty,min=1"`
+	Languages        []string   `json:"languages" binding:"omitempty,min=1,dive,required"`
+	Genre            *string    `json:"genre" binding:"omitempty,min=1,max=100" doc:"Deprecated, replaces genres with this one genre"`
+	Genres           []string   `json:"genres" binding:"omitempty,min=1,max=10,dive,required,max=100"`
+	PosterURL        *string    `json:"poster_url" binding:"omitempty,url"`
+	TrailerURL       *string    `json:"trailer_url" binding:"omitempty,url"`
+	Certification    *string    `json:"certification" binding:"omitempty,oneof=U UA A"`
+	ReleaseDate      *MovieDate `json:"release_date"`
+	OriginalLanguage *string    `json:"original_language" binding:"omitempty,max=50"`
+	StartDate        *MovieDate `json:"start_date"`
+	EndDate          *MovieDate `json:"end_date"`
+
+	// nulls are the fields sent as null, which decode like absent ones
+	nulls map[string]bool
+}
+
+func (in *MovieInput) UnmarshalJSON(data []byte) error {
+	type plain MovieInput
+	return nameFieldError(data, (*plain)(in), json.Unmarshal(data, (*plain)(in)))
+}
+
+func (in *MoviePatch) UnmarshalJSON(data []byte) error {
+	type plain MoviePatch
+	if err := nameFieldError(data, (*plain)(in), json.Unmarshal(data, (*plain)(in))); err != nil {
+		return err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	in.nulls = map[string]bool{}
+	for name, value := range raw {
+		if string(value) == "null" {
+			in.nulls[name] = true
+		}
+	}
+	return nil
+}
+
+// nameFieldError fills in the field of a type error raised by one of v's
+// custom field types, which encoding/json does not do consistently, so the
+// client is told which field was wrong. Two fields can share a type, so each
+// candidate is decoded again to find the one that fails.
+func nameFieldError(data []byte, v interface{}, err error) error {
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Field != "" {
+		return err
+	}
+	var raw map[string]json.RawMessage
+	if json.Unmarshal(data, &raw) != nil {
+		return err
+	}
+	t := reflect.TypeOf(v).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Type != typeErr.Type && field.Type != reflect.PointerTo(typeErr.Type) {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		value, ok := raw[name]
+		if !ok {
+			continue
+		}
+		if json.Unmarshal(value, reflect.New(field.Type).Interface()) != nil {
+			typeErr.Field = name
+			return typeErr
+		}
+	}
+	return err
+}
+
+func (in *MovieInput) apply(movie *models.Movie) error {
+	languages, err := json.Marshal(in.Languages)
+	if err != nil {
+		return err
+	}
+	genres := in.Genres
+	if len(genres) == 0 && in.Genre != "" {
+		genres = []string{in.Genre}
+	}
+	if len(genres) == 0 {
+		return errs.Validation("genres", "is required")
+	}
+	if err := setGenres(movie, genres); err != nil {
+		return err
+	}
+	movie.MovieName = in.MovieName
+	movie.MovieDescription = in.MovieDescription
+	movie.Duration = int(*in.Duration)
+	movie.Languages = languages
+	if movie.PosterURL != in.PosterURL {
+		// thumbnails belong to an uploaded poster
+		movie.PosterThumbnails = nil
+	}
+	movie.PosterURL = in.PosterURL
+	movie.TrailerURL = in.TrailerURL
+	movie.Certification = in.Certification
+	movie.ReleaseDate = nil
+	if in.ReleaseDate != nil {
+		movie.ReleaseDate = &in.ReleaseDate.Time
+	}
+	movie.OriginalLanguage = in.OriginalLanguage
+	movie.StartDate = in.StartDate.Time
+	movie.EndDate = in.EndDate.Time
+	return nil
+}
+
+func (in *MoviePatch) apply(movie *models.Movie) error {
+	invalid := map[string]string{}
+	for name := range in.nulls {
+		switch name {
+		case "certification":
+			movie.Certification = ""
+		case "release_date":
+			movie.ReleaseDate = nil
+		case "poster_url":
+			movie.PosterURL = ""
+			movie.PosterThumbnails = nil
+		case "trailer_url":
+			movie.TrailerURL = ""
+		case "original_language":
+			movie.OriginalLanguage = ""
+		case "movie_name", "movie_description", "duration", "languages", "genre", "genres", "start_date", "end_date":
+			invalid[name] = "cannot be cleared"
+		}
+	}
+	if len(invalid) > 0 {
+		return errs.Invalid(invalid)
+	}
+
+	if in.MovieName != nil {
+		movie.MovieName = *in.MovieName
+	}
+	if in.MovieDescription != nil {
+		movie.MovieDescription = *in.MovieDescription
+	}
+	if in.Duration != nil {
+		movie.Duration = int(*in.Duration)
+	}
+	if in.Languages != nil {
+		languages, err := json.Marshal(in.Languages)
+		if err != nil {
+			return err
+		}
+		movie.Languages = languages
+	}
+	switch {
+	case in.Genres != nil:
+		if err := setGenres(movie, in.Genres); err != nil {
+			return err
+		}
+	case in.Genre != nil:
+		if err := setGenres(movie, []string{*in.Genre}); err != nil {
+			return err
+		}
+	}
+	if in.PosterURL != nil && *in.PosterURL != movie.PosterURL {
+		movie.PosterURL = *in.PosterURL
+		movie.PosterThumbnails = nil
+	}
+	if in.TrailerURL != nil {
+		movie.TrailerURL = *in.TrailerURL
+	}
+	if in.Certification != nil {
+		movie.Certification = *in.Certification
+	}
+	if in.ReleaseDate != nil {
+		movie.ReleaseDate = &in.ReleaseDate.Time
+	}
+	if in.OriginalLanguage != nil {
+		movie.OriginalLanguage = *in.OriginalLanguage
+	}
+	if in.StartDate != nil {
+		movie.StartDate = in.StartDate.Time
+	}
+	if in.EndDate != nil {
+		movie.EndDate = in.EndDate.Time
+	}
+	return nil
+}
+
+// setGenres stores genres, the first one also goes to the single genre older
+// clients read.
+func setGenres(movie *models.Movie, genres []string) error {
+	data, err := json.Marshal(genres)
+	if err != nil {
+		return err
+	}
+	movie.Genres = data
+	movie.Genre = genres[0]
+	return nil
+}
+
+// validateMovieDates checks the dates of the movie as it will be saved.
+func validateMovieDates(movie *models.Movie) error {
+	if movie.EndDate.Before(movie.StartDate) {
+		return errs.Validation("end_date", "must not be before start_date")
+	}
+	return nil
+}
+
+// Minutes is a duration in minutes. It is accepted as a JSON number or, as
+// older clients send it, a numeric string.
+type Minutes int
+
+func (m *Minutes) UnmarshalJSON(data []byte) error {
+	if unquoted, err := strconv.Unquote(string(data)); err == nil {
+		data = []byte(strings.TrimSpace(unquoted))
+	}
+	n, err := strconv.Atoi(string(data))
+	if err != nil {
+		return &json.UnmarshalTypeError{Value: string(data), Type: reflect.TypeOf(*m)}
+	}
+	*m = Minutes(n)
+	return nil
+}
+
+func (Minutes) Expected() string {
+	return "a whole number of minutes"
+}
+
+// MovieDate is a calendar date accepted as an ISO 8601 string, either a date
+// (2025-01-31) or a timestamp whose date is used, or as the legacy
+// {"day", "month", "year"} object.
+type MovieDate struct {
+	time.Time
+}
+
+func (d *MovieDate) UnmarshalJSON(data []byte) error {
+	invalid := &json.UnmarshalTypeError{Value: string(data), Type: reflect.TypeOf(*d)}
+
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		var parts DateParts
+		if err := json.Unmarshal(data, &parts); err != nil {
+			return invalid
+		}
+		t := time.Date(parts.Year, time.Month(parts.Month), parts.Day, 0, 0, 0, 0, time.UTC)
+		// time.Date normalizes overflow, e.g. February 30th to March 2nd
+		if t.Year() != parts.Year || int(t.Month()) != parts.Month || t.Day() != parts.Day {
+			return invalid
+		}
+		d.Time = t
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return invalid
+	}
+	if t, err := time.Parse(time.DateOnly, s); err == nil {
+		d.Time = t
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return invalid
+	}
+	d.Time = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return nil
+}
+
+func (MovieDate) Expected() string {
+	return `a date as YYYY-MM-DD or {"day", "month", "year"}`
+}
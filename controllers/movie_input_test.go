@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"backend/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMovieDateDecoding(t *testing.T) {
+	for _, tc := range []struct {
+		json string
+		want string // empty when the value is rejected
+	}{
+		{`"2030-01-31"`, "2030-01-31"},
+		{`"2030-01-31T22:30:00+05:30"`, "2030-01-31"},
+		{`"2030-01-31T22:30:00Z"`, "2030-01-31"},
+		{`{"day": 29, "month": 2, "year": 2028}`, "2028-02-29"},
+		{`{"day": 30, "month": 2, "year": 2030}`, ""},
+		{`{"day": "1", "month": 2, "year": 2030}`, ""},
+		{`"31/01/2030"`, ""},
+		{`20300131`, ""},
+	} {
+		var date MovieDate
+		err := json.Unmarshal([]byte(tc.json), &date)
+		switch {
+		case tc.want == "" && err == nil:
+			t.Errorf("%s: accepted as %s", tc.json, date.Format(time.DateOnly))
+		case tc.want != "" && err != nil:
+			t.Errorf("%s: %v", tc.json, err)
+		case tc.want != "" && date.Format(time.DateOnly) != tc.want:
+			t.Errorf("%s: got %s, want %s", tc.json, date.Format(time.DateOnly), tc.want)
+		case tc.want != "" && date.Location() != time.UTC:
+			t.Errorf("%s: decoded in %s, want UTC", tc.json, date.Location())
+		}
+	}
+}
+
+func TestMinutesDecoding(t *testing.T) {
+	for _, tc := range []struct {
+		json string
+		want Minutes
+		ok   bool
+	}{
+		{`120`, 120, true},
+		{`"120"`, 120, true},
+		{`" 95 "`, 95, true},
+		{`"two hours"`, 0, false},
+		{`1.5`, 0, false},
+		{`true`, 0, false},
+	} {
+		var m Minutes
+		err := json.Unmarshal([]byte(tc.json), &m)
+		if (err == nil) != tc.ok || m != tc.want {
+			t.Errorf("%s: got %d, %v", tc.json, m, err)
+		}
+	}
+}
+
+func TestMoviePayloads(t *testing.T) {
+	repos := memory.New()
+	movies := NewMovieHandler(testConfig(), repos.Transactor, repos.Movies, repos.Shows)
+	router := testRouter(func(router *gin.Engine) {
+		router.POST("/movies", movies.CreateMovie)
+		router.PATCH("/movies/:id", movies.PatchMovie)
+	})
+	valid := func() map[string]interface{} {
+		return map[string]interface{}{
+			"movie_name": "Payload", "movie_description": "Decoding", "languages": []string{"English"},
+			"genres": []string{"Drama"}, "duration": "95",
+			"start_date": map[string]int{"day": 1, "month": 1, "year": 2030},
+			"end_date":   "2030-01-31T23:00:00Z",
+		}
+	}
+
+	w := serve(t, router, http.MethodPost, "/movies", valid())
+	if w.Code != http.StatusCreated {
+		t.Fatalf("legacy date and string duration: %d %s", w.Code, w.Body)
+	}
+	stored, err := repos.Movies.List(context.Background())
+	if err != nil || len(stored) != 1 {
+		t.Fatalf("stored %v, %v", stored, err)
+	}
+	movie := stored[0]
+	if movie.Duration != 95 || movie.StartDate.Format(time.DateOnly) != "2030-01-01" || movie.EndDate.Format(time.DateOnly) != "2030-01-31" {
+		t.Errorf("stored duration %d from %s to %s", movie.Duration, movie.StartDate, movie.EndDate)
+	}
+
+	// a type error names the field even when two fields share the type
+	for field, value := range map[string]interface{}{
+		"end_date": map[string]int{"day": 31, "month": 2, "year": 2030},
+		"duration": "ninety",
+	} {
+		body := valid()
+		body[field] = value
+		w := serve(t, router, http.MethodPost, "/movies", body)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("invalid %s: expected 400, got %d", field, w.Code)
+			continue
+		}
+		if fields := errorBody(t, w).Fields; len(fields) != 1 || fields[field] == "" {
+			t.Errorf("invalid %s: fields %v", field, fields)
+		}
+	}
+
+	// required fields are named by the validator, routes.New registers the
+	// JSON names with it
+	w = serve(t, router, http.MethodPost, "/movies", map[string]interface{}{"movie_name": "Missing"})
+	if fields := errorBody(t, w).Fields; w.Code != http.StatusBadRequest || len(fields) < 5 {
+		t.Errorf("missing fields: %d %v", w.Code, fields)
+	}
+
+	path := "/movies/" + strconv.Itoa(movie.MovieID)
+	if w := serve(t, router, http.MethodPatch, path, map[string]interface{}{"end_date": "2030-01-15"}); w.Code != http.StatusOK {
+		t.Errorf("patching the end date: %d %s", w.Code, w.Body)
+	}
+	if w := serve(t, router, http.MethodPatch, path, map[string]interface{}{"end_date": "2029-12-31"}); w.Code != http.StatusBadRequest || errorBody(t, w).Fields["end_date"] == "" {
+		t.Errorf("end before start: %d %s", w.Code, w.Body)
+	}
+	updated, _ := repos.Movies.Get(context.Background(), movie.MovieID)
+	if updated.Duration != 95 || updated.EndDate.Format(time.DateOnly) != "2030-01-15" {
+		t.Errorf("patch changed duration to %d and end to %s", updated.Duration, updated.EndDate)
+	}
+
+	// null clears optional fields and is refused for the others
+	if w := serve(t, router, http.MethodPatch, path, map[string]interface{}{"release_date": "2029-12-25", "certification": "UA"}); w.Code != http.StatusOK {
+		t.Fatalf("setting the release date: %d %s", w.Code, w.Body)
+	}
+	if w := serve(t, router, http.MethodPatch, path, map[string]interface{}{"release_date": nil, "certification": nil}); w.Code != http.StatusOK {
+		t.Fatalf("clearing the release date: %d %s", w.Code, w.Body)
+	}
+	updated, _ = repos.Movies.Get(context.Background(), movie.MovieID)
+	if updated.ReleaseDate != nil || updated.Certification != "" || updated.MovieName != "Payload" {
+		t.Errorf("after clearing: release date %v, certification %q, name %q", updated.ReleaseDate, updated.Certification, updated.MovieName)
+	}
+	w = serve(t, router, http.MethodPatch, path, map[string]interface{}{"movie_name": nil, "end_date": nil})
+	if fields := errorBody(t, w).Fields; w.Code != http.StatusBadRequest || fields["movie_name"] == "" || fields["end_date"] == "" {
+		t.Errorf("clearing required fields: %d %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/errs"
+	"backend/models"
+	"backend/repository"
+)
+
+// TotalCountHeader carries the number of matches of a listing that is not
+// wrapped in a page.
+const TotalCountHeader = "X-Total-Count"
+
+const (
+	defaultMoviePageSize = 20
+	maxMoviePageSize     = 100
+)
+
+// MoviePage is a page of movie search results.
+type MoviePage struct {
+	Items  []MovieResponse `json:"items"`
+	Total  int64           `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+var movieSorts = map[string]repository.MovieSort{
+	"rating":       repository.MovieSortRating,
+	"release_date": repository.MovieSortReleaseDate,
+	"name":         repository.MovieSortName,
+}
+
+// movieStatuses are the derived statuses a search can filter on, matched
+// case-insensitively.
+var movieStatuses = []string{
+	models.MovieStatusUpcoming,
+	models.MovieStatusNowShowing,
+	models.MovieStatusNotScheduled,
+	models.MovieStatusExpired,
+}
+
+// movieFilter reads the search query parameters. city_id scopes the shows
+// the status is derived from. Every invalid parameter is reported at once.
+// defaultLimit applies when limit is not given, 0 returns every match.
+func movieFilter(c *gin.Context, defaultLimit int) (repository.MovieFilter, error) {
+	filter := repository.MovieFilter{
+		Query:    strings.TrimSpace(c.Query("q")),
+		Genre:    strings.TrimSpace(c.Query("genre")),
+		Language: strings.TrimSpace(c.Query("language")),
+		Status:   strings.TrimSpace(c.Query("status")),
+	}
+	invalid := map[string]string{}
+
+	for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := c.Query(name)
+		if value == "" {
+			continue
+		}
+		t, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			invalid[name] = "must be a date as YYYY-MM-DD"
+			continue
+		}
+		*dst = &t
+	}
+	if filter.From != nil && filter.To != nil && filter.To.Before(*filter.From) {
+		invalid["to"] = "must not be before from"
+	}
+
+	if filter.Status != "" && !slices.ContainsFunc(movieStatuses, func(status string) bool {
+		return strings.EqualFold(status, filter.Status)
+	}) {
+		invalid["status"] = "must be one of " + strings.Join(movieStatuses, ", ")
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		name, descending := strings.CutPrefix(sort, "-")
+		if by, ok := movieSorts[name]; ok {
+			filter.Sort, filter.Descending = by, descending
+		} else {
+			invalid["sort"] = "must be one of rating, release_date or name, prefixed with - for descending order"
+		}
+	}
+
+	if value := c.Query("city_id"); value != "" {
+		cityID, err := strconv.Atoi(value)
+		if err != nil || cityID < 1 {
+			invalid["city_id"] = "must be a positive integer"
+		}
+		filter.CityID = cityID
+	}
+
+	filter.Limit, filter.Offset = pageParams(c, defaultLimit, invalid)
+
+	if len(invalid) > 0 {
+		return filter, errs.Invalid(invalid)
+	}
+	return filter, nil
+}
+
+// pageParams reads limit and offset, recording invalid ones in invalid.
+func pageParams(c *gin.Context, defaultLimit int, invalid map[string]string) (limit, offset int) {
+	limit = defaultLimit
+	if value := c.Query("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxMoviePageSize {
+			invalid["limit"] = "must be between 1 and " + strconv.Itoa(maxMoviePageSize)
+		}
+	}
+	if value := c.Query("offset"); value != "" {
+		var err error
+		offset, err = strconv.Atoi(value)
+		if err != nil || offset < 0 {
+			invalid["offset"] = "must be a non-negative integer"
+		}
+	}
+	return limit, offset
+}
@@ -0,0 +1,60 @@
+package controllers
+
+import (
+	"maps"
+	"net/http"
+	"slices"
+	"testing"
+
+	"backend/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMovieFilterValidation(t *testing.T) {
+	repos := memory.New()
+	movies := NewMovieHandler(testConfig(), repos.Transactor, repos.Movies, repos.Shows)
+	router := testRouter(func(router *gin.Engine) {
+		router.GET("/movies", movies.SearchMovies)
+		router.GET("/movies/coming-soon", movies.ComingSoon)
+	})
+
+	for _, tc := range []struct {
+		query string
+		// invalid lists the fields reported, none when the query is valid
+		invalid []string
+	}{
+		{"", nil},
+		{"?q=+drama+&genre=Drama&language=English&status=upcoming", nil},
+		{"?from=2030-01-01&to=2030-01-01&sort=-release_date&city_id=3&limit=100&offset=0", nil},
+		{"?from=01-01-2030", []string{"from"}},
+		{"?to=tomorrow", []string{"to"}},
+		{"?from=2030-01-02&to=2030-01-01", []string{"to"}},
+		{"?status=NOW+SHOWING", nil},
+		{"?status=foo", []string{"status"}},
+		{"?status=Showing", []string{"status"}},
+		{"?sort=popularity", []string{"sort"}},
+		{"?sort=--rating", []string{"sort"}},
+		{"?city_id=0", []string{"city_id"}},
+		{"?city_id=pune", []string{"city_id"}},
+		{"?limit=0", []string{"limit"}},
+		{"?limit=101", []string{"limit"}},
+		{"?offset=-1", []string{"offset"}},
+		// every invalid parameter is reported at once
+		{"?from=x&status=y&sort=y&city_id=-2&limit=z&offset=1.5", []string{"city_id", "from", "limit", "offset", "sort", "status"}},
+	} {
+		for _, path := range []string{"/movies", "/movies/coming-soon"} {
+			w := serve(t, router, http.MethodGet, path+tc.query, nil)
+			if tc.invalid == nil {
+				if w.Code != http.StatusOK {
+					t.Errorf("%s%s: %d %s", path, tc.query, w.Code, w.Body)
+				}
+				continue
+			}
+			body := errorBody(t, w)
+			if got := slices.Sorted(maps.Keys(body.Fields)); w.Code != http.StatusBadRequest || !slices.Equal(got, tc.invalid) {
+				t.Errorf("%s%s: %d with fields %v, want 400 with %v", path, tc.query, w.Code, got, tc.invalid)
+			}
+		}
+	}
+}
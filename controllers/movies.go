@@ -1,282 +1,287 @@
 package controllers
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 
+	"backend/config"
+	"backend/errs"
 	"backend/models"
+	"backend/repository"
+	"backend/scheduling"
 )
 
-func parseDate(dateMap map[string]interface{}) (time.Time, error) {
-	day := int(dateMap["day"].(float64))
-	month := time.Month(int(dateMap["month"].(float64)))
-	year := int(dateMap["year"].(float64))
-	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC), nil
+type MovieHandler struct {
+	tx             repository.Transactor
+	movies         repository.MovieRepository
+	shows          repository.ShowRepository
+	padding        time.Duration
+	cleaningBuffer time.Duration
 }
 
-func dateToMap(t time.Time) map[string]int {
-	return map[string]int{
-		"day":   t.Day(),
-		"month": int(t.Month()),
-		"year":  t.Year(),
+func NewMovieHandler(cfg *config.Config, tx repository.Transactor, movies repository.MovieRepository, shows repository.ShowRepository) *MovieHandler {
+	return &MovieHandler{tx: tx, movies: movies, shows: shows, padding: cfg.Shows.Padding, cleaningBuffer: cfg.Shows.CleaningBuffer}
+}
+
+// DateParts is how movie dates are sent and returned.
+type DateParts struct {
+	Day   int `json:"day"`
+	Month int `json:"month"`
+	Year  int `json:"year"`
+}
+
+func datePartsOf(t time.Time) DateParts {
+	return DateParts{
+		Day:   t.Day(),
+		Month: int(t.Month()),
+		Year:  t.Year(),
 	}
 }
 
 type MovieResponse struct {
 	models.Movie
-	StartDate map[string]int `json:"start_date"`
-	EndDate   map[string]int `json:"end_date"`
+	ReleaseDate *string   `json:"release_date" doc:"YYYY-MM-DD"`
+	StartDate   DateParts `json:"start_date"`
+	EndDate     DateParts `json:"end_date"`
 }
 
-func CreateMovie(c *gin.Context) {
-	var input map[string]interface{}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+func newMovieResponse(movie models.Movie) MovieResponse {
+	return MovieResponse{
+		Movie:       movie,
+		ReleaseDate: isoDate(movie.ReleaseDate),
+		StartDate:   datePartsOf(movie.StartDate),
+		EndDate:     datePartsOf(movie.EndDate),
 	}
+}
 
-	languagesRaw, ok := input["languages"]
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "languages field is required"})
-		return
-	}
-	languagesJSON, err := json.Marshal(languagesRaw)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid languages format"})
-		return
+func isoDate(t *time.Time) *string {
+	if t == nil {
+		return nil
 	}
+	date := t.Format(time.DateOnly)
+	return &date
+}
 
-	durationStr, ok := input["duration"].(string)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "duration must be a string"})
-		return
-	}
-	durationInt, err := strconv.Atoi(durationStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duration value"})
-		return
+func newMovieResponses(movies []models.Movie) []MovieResponse {
+	responses := make([]MovieResponse, len(movies))
+	for i, movie := range movies {
+		responses[i] = newMovieResponse(movie)
 	}
+	return responses
+}
 
-	startDateRaw, ok := input["start_date"].(map[string]interface{})
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must be an object"})
-		return
-	}
-	startDate, err := parseDate(startDateRaw)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+func (h *MovieHandler) CreateMovie(c *gin.Context) {
+	var input MovieInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		errs.Abort(c, errs.Binding(err))
 		return
 	}
 
-	endDateRaw, ok := input["end_date"].(map[string]interface{})
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be an object"})
+	var movie models.Movie
+	if err := input.apply(&movie); err != nil {
+		errs.Abort(c, err)
 		return
 	}
-	endDate, err := parseDate(endDateRaw)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
+	if err := validateMovieDates(&movie); err != nil {
+		errs.Abort(c, err)
 		return
 	}
-	movieStatus, ok := input["movie_status"].(string)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "movie_status is required and must be a string"})
+	movie.CreatedAt = time.Now()
+	movie.UpdatedAt = movie.CreatedAt
+	// the stored status is a snapshot, reads derive it afresh
+	movie.MovieStatus = models.MovieStatusOn(movie.StartDate, movie.EndDate, repository.Day(movie.CreatedAt), false)
+
+	if err := h.movies.Create(c.Request.Context(), &movie); err != nil {
+		errs.Abort(c, err)
 		return
 	}
 
-	movie := models.Movie{
-		MovieName:        input["movie_name"].(string),
-		MovieDescription: input["movie_description"].(string),
-		Duration:         durationInt,
-		Languages:        languagesJSON,
-		Genre:            input["genre"].(string),
-		PosterURL:        input["poster_url"].(string),
-		MovieStatus:      movieStatus,
-		Rating:           0.0,
-		StartDate:        startDate,
-		EndDate:          endDate,
-		CreatedAt:        time.Now(),
-		UpdatedAt:        time.Now(),
-	}
+	c.JSON(http.StatusCreated, newMovieResponse(movie))
+}
 
-	db := c.MustGet("db").(*gorm.DB)
-	if err := db.Create(&movie).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+// SearchMovies lists the movies matching the query parameters a page at a
+// time. Both listings send the total in the X-Total-Count header.
+func (h *MovieHandler) SearchMovies(c *gin.Context) {
+	filter, err := movieFilter(c, defaultMoviePageSize)
+	if err != nil {
+		errs.Abort(c, err)
 		return
 	}
+	h.searchPage(c, filter)
+}
 
-	response := MovieResponse{
-		Movie:     movie,
-		StartDate: dateToMap(movie.StartDate),
-		EndDate:   dateToMap(movie.EndDate),
-	}
-	c.JSON(http.StatusCreated, response)
+// NowShowing lists the movies that are running and have shows scheduled from
+// today on, in the city given by city_id.
+func (h *MovieHandler) NowShowing(c *gin.Context) {
+	h.listByStatus(c, models.MovieStatusNowShowing, repository.MovieSortID)
 }
 
-func GetMovies(c *gin.Context) {
-	var movies []models.Movie
-	db := c.MustGet("db").(*gorm.DB)
+// ComingSoon lists the movies whose run has not started yet, earliest release
+// first. Movies in their run without shows in the city are Not Scheduled and
+// not listed.
+func (h *MovieHandler) ComingSoon(c *gin.Context) {
+	h.listByStatus(c, models.MovieStatusUpcoming, repository.MovieSortReleaseDate)
+}
 
-	if err := db.Find(&movies).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+func (h *MovieHandler) listByStatus(c *gin.Context, status string, defaultSort repository.MovieSort) {
+	filter, err := movieFilter(c, defaultMoviePageSize)
+	if err != nil {
+		errs.Abort(c, err)
 		return
 	}
-
-	var responses []MovieResponse
-	for _, movie := range movies {
-		responses = append(responses, MovieResponse{
-			Movie:     movie,
-			StartDate: dateToMap(movie.StartDate),
-			EndDate:   dateToMap(movie.EndDate),
-		})
+	filter.Status = status
+	if c.Query("sort") == "" {
+		filter.Sort = defaultSort
 	}
-
-	c.JSON(http.StatusOK, responses)
+	h.searchPage(c, filter)
 }
 
-func GetMovieByID(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := strconv.Atoi(idParam)
+func (h *MovieHandler) searchPage(c *gin.Context, filter repository.MovieFilter) {
+	movies, total, err := h.movies.Search(c.Request.Context(), filter)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		errs.Abort(c, err)
 		return
 	}
 
-	var movie models.Movie
-	db := c.MustGet("db").(*gorm.DB)
+	c.Header(TotalCountHeader, strconv.FormatInt(total, 10))
+	c.JSON(http.StatusOK, MoviePage{
+		Items:  newMovieResponses(movies),
+		Total:  total,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	})
+}
 
-	if err := db.First(&movie, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+// GetMovies is the legacy listing, a bare array of every match unless limit
+// is given.
+func (h *MovieHandler) GetMovies(c *gin.Context) {
+	filter, err := movieFilter(c, 0)
+	if err != nil {
+		errs.Abort(c, err)
 		return
 	}
 
-	response := MovieResponse{
-		Movie:     movie,
-		StartDate: dateToMap(movie.StartDate),
-		EndDate:   dateToMap(movie.EndDate),
-	}
-	c.JSON(http.StatusOK, response)
-}
-
-func UpdateMovie(c *gin.Context) {
-	idParam := c.Param("id")
-	id, err := strconv.Atoi(idParam)
+	movies, total, err := h.movies.Search(c.Request.Context(), filter)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		errs.Abort(c, err)
 		return
 	}
 
-	var movie models.Movie
-	db := c.MustGet("db").(*gorm.DB)
+	c.Header(TotalCountHeader, strconv.FormatInt(total, 10))
+	c.JSON(http.StatusOK, newMovieResponses(movies))
+}
 
-	if err := db.First(&movie, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Movie not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		}
+func (h *MovieHandler) GetMovieByID(c *gin.Context) {
+	movie, ok := h.loadMovie(c)
+	if !ok {
 		return
 	}
+	c.JSON(http.StatusOK, newMovieResponse(*movie))
+}
 
-	var input map[string]interface{}
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
+// UpdateMovie replaces every field of a movie.
+func (h *MovieHandler) UpdateMovie(c *gin.Context) {
+	var input MovieInput
+	h.update(c, &input)
+}
 
-	languagesRaw, ok := input["languages"]
+// PatchMovie changes only the fields present in the body.
+func (h *MovieHandler) PatchMovie(c *gin.Context) {
+	var input MoviePatch
+	h.update(c, &input)
+}
+
+func (h *MovieHandler) update(c *gin.Context, input interface{ apply(*models.Movie) error }) {
+	movie, ok := h.loadMovie(c)
 	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "languages field is required"})
-		return
-	}
-	languagesJSON, err := json.Marshal(languagesRaw)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid languages format"})
 		return
 	}
 
-	durationStr, ok := input["duration"].(string)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "duration must be a string"})
+	if err := c.ShouldBindJSON(input); err != nil {
+		errs.Abort(c, errs.Binding(err))
 		return
 	}
-	durationInt, err := strconv.Atoi(durationStr)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid duration value"})
+	runtime := movie.Duration
+	if err := input.apply(movie); err != nil {
+		errs.Abort(c, err)
 		return
 	}
-
-	startDateRaw, ok := input["start_date"].(map[string]interface{})
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "start_date must be an object"})
+	if err := validateMovieDates(movie); err != nil {
+		errs.Abort(c, err)
 		return
 	}
-	startDate, err := parseDate(startDateRaw)
+	movie.UpdatedAt = time.Now()
+
+	// a new runtime moves computed show end times, it is refused when they
+	// would overlap other shows
+	ctx := c.Request.Context()
+	err := h.tx.Transaction(ctx, func(repos repository.Repositories) error {
+		if err := repos.Movies.Update(ctx, movie); err != nil || movie.Duration == runtime {
+			return err
+		}
+		retimed, err := scheduling.RecomputeEndTimes(ctx, repos.Shows, movie, h.padding, h.cleaningBuffer, movie.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if conflicts := retimeConflicts(retimed, h.cleaningBuffer); len(conflicts) > 0 {
+			err := scheduleError(conflicts)
+			err.Message = "The new duration makes shows overlap the schedule of their screen"
+			return err
+		}
+		return nil
+	})
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format"})
+		errs.Abort(c, err)
 		return
 	}
 
-	endDateRaw, ok := input["end_date"].(map[string]interface{})
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "end_date must be an object"})
-		return
-	}
-	endDate, err := parseDate(endDateRaw)
+	// new dates can change the status
+	movie, err = h.movies.Get(c.Request.Context(), movie.MovieID)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format"})
-		return
-	}
-	movieStatus, ok := input["movie_status"].(string)
-	if !ok {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "movie_status is required and must be a string"})
+		errs.Abort(c, err)
 		return
 	}
+	c.JSON(http.StatusOK, newMovieResponse(*movie))
+}
 
-	movie.MovieName = input["movie_name"].(string)
-	movie.MovieDescription = input["movie_description"].(string)
-	movie.Duration = durationInt
-	movie.Languages = languagesJSON
-	movie.Genre = input["genre"].(string)
-	movie.PosterURL = input["poster_url"].(string)
-	movie.MovieStatus = movieStatus
-	movie.StartDate = startDate
-	movie.EndDate = endDate
-	movie.UpdatedAt = time.Now()
-
-	if err := db.Save(&movie).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+// loadMovie loads the movie named by the id path parameter and writes the
+// error response when it cannot.
+func (h *MovieHandler) loadMovie(c *gin.Context) (*models.Movie, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errs.Abort(c, errs.BadRequest("Invalid movie ID"))
+		return nil, false
 	}
 
-	response := MovieResponse{
-		Movie:     movie,
-		StartDate: dateToMap(movie.StartDate),
-		EndDate:   dateToMap(movie.EndDate),
+	movie, err := h.movies.Get(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Movie not found"))
+		} else {
+			errs.Abort(c, err)
+		}
+		return nil, false
 	}
-	c.JSON(http.StatusOK, response)
+	return movie, true
 }
 
-func DeleteMovie(c *gin.Context) {
+func (h *MovieHandler) DeleteMovie(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie ID"})
+		errs.Abort(c, errs.BadRequest("Invalid movie ID"))
 		return
 	}
 
-	db := c.MustGet("db").(*gorm.DB)
-	if err := db.Delete(&models.Movie{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.movies.Delete(c.Request.Context(), id); err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Movie not found"))
+		} else {
+			errs.Abort(c, err)
+		}
 		return
 	}
 
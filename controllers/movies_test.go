@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"backend/middlewares"
+	"backend/models"
+	"backend/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRuntimeChangeCannotOverlapShows(t *testing.T) {
+	repos := memory.New()
+	movie, screen := scheduleFixtures(t, repos)
+	cfg := testConfig()
+	shows := NewShowHandler(cfg, repos.Transactor, repos.Shows, repos.Movies, repos.Theatres, repos.Cities)
+	movies := NewMovieHandler(cfg, repos.Transactor, repos.Movies, repos.Shows)
+	router := testRouter(func(router *gin.Engine) {
+		router.POST("/shows", shows.CreateShow)
+		router.PATCH("/movies/:id", movies.PatchMovie)
+	})
+	path := "/movies/" + strconv.Itoa(movie.MovieID)
+
+	// 120 minutes plus 20 of padding end the first show at 12:20, 20 minutes
+	// before the second starts
+	w := serve(t, router, http.MethodPost, "/shows", map[string]interface{}{
+		"movie_id": movie.MovieID, "theatre_id": screen.TheatreID, "screen_id": screen.ScreenID,
+		"date": "2030-01-10", "languages": []string{"English"},
+		"times": []map[string]string{{"start_time": "10:00"}, {"start_time": "12:40"}},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("scheduling: %d %s", w.Code, w.Body)
+	}
+
+	w = serve(t, router, http.MethodPatch, path, map[string]int{"duration": 130})
+	if w.Code != http.StatusConflict {
+		t.Fatalf("lengthening into the cleaning buffer: expected 409, got %d %s", w.Code, w.Body)
+	}
+	var resp middlewares.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Error.Fields) != 2 {
+		t.Errorf("fields %v, want both shows named", resp.Error.Fields)
+	}
+	stored, _ := repos.Movies.Get(context.Background(), movie.MovieID)
+	if stored.Duration != 120 {
+		t.Errorf("duration changed to %d", stored.Duration)
+	}
+	list, _ := repos.Shows.List(context.Background())
+	if got := list[0].EndTime.Format("15:04"); got != "12:20" {
+		t.Errorf("first show moved to end at %s", got)
+	}
+
+	w = serve(t, router, http.MethodPatch, path, map[string]int{"duration": 125})
+	if w.Code != http.StatusOK {
+		t.Fatalf("lengthening within the gap: %d %s", w.Code, w.Body)
+	}
+	list, _ = repos.Shows.List(context.Background())
+	if got := list[0].EndTime.Format("15:04"); got != "12:25" {
+		t.Errorf("first show ends at %s, want 12:25", got)
+	}
+}
+
+func TestSearchSortsByReleaseDate(t *testing.T) {
+	repos := memory.New()
+	day := func(d int) time.Time { return time.Date(2030, time.January, d, 0, 0, 0, 0, time.UTC) }
+	released := func(d int) *time.Time { date := day(d); return &date }
+	for _, movie := range []models.Movie{
+		{MovieName: "Undated late", StartDate: day(5)},
+		{MovieName: "Released second", StartDate: day(1), ReleaseDate: released(10)},
+		{MovieName: "Undated early", StartDate: day(2)},
+		{MovieName: "Released first", StartDate: day(9), ReleaseDate: released(3)},
+		{MovieName: "Released second too", StartDate: day(1), ReleaseDate: released(10)},
+	} {
+		movie.EndDate = day(31)
+		if err := repos.Movies.Create(context.Background(), &movie); err != nil {
+			t.Fatal(err)
+		}
+	}
+	movies := NewMovieHandler(testConfig(), repos.Transactor, repos.Movies, repos.Shows)
+	router := testRouter(func(router *gin.Engine) {
+		router.GET("/movies", movies.SearchMovies)
+	})
+
+	for sort, want := range map[string][]string{
+		"release_date":  {"Released first", "Released second", "Released second too", "Undated early", "Undated late"},
+		"-release_date": {"Undated late", "Undated early", "Released second", "Released second too", "Released first"},
+	} {
+		w := serve(t, router, http.MethodGet, "/movies?sort="+sort, nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("sort=%s: %d %s", sort, w.Code, w.Body)
+		}
+		var page MoviePage
+		if err := json.Unmarshal(w.Body.Bytes(), &page); err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, movie := range page.Items {
+			got = append(got, movie.MovieName)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("sort=%s: got %v, want %v", sort, got, want)
+		}
+	}
+}
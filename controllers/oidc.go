@@ -0,0 +1,288 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"backend/errs"
+	"backend/models"
+	"backend/oidc"
+	"backend/repository"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	oidcStateCookie             = "oidc_state"
+	oidcStateTTL                = 10 * time.Minute
+	securityEventIdentityLinked = "identity_linked"
+)
+
+type oidcStateClaims struct {
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	jwt.RegisteredClaims
+}
+
+// getOIDCProvider discovers the provider on first use and caches it. Failed
+// discoveries are not cached so a provider that was down at boot recovers.
+func (h *UserHandler) getOIDCProvider(c *gin.Context, issuer string) (*oidc.Provider, error) {
+	h.oidcMu.Lock()
+	defer h.oidcMu.Unlock()
+
+	if h.oidcProvider != nil {
+		return h.oidcProvider, nil
+	}
+	provider, err := oidc.Discover(c.Request.Context(), issuer)
+	if err != nil {
+		return nil, err
+	}
+	h.oidcProvider = provider
+	return provider, nil
+}
+
+func (h *UserHandler) OIDCLogin(c *gin.Context) {
+	cfg := h.cfg
+	settings := cfg.OIDC
+	if !settings.Enabled() {
+		errs.Abort(c, errs.New(errs.CodeNotImplemented, "OIDC login is not configured"))
+		return
+	}
+
+	provider, err := h.getOIDCProvider(c, settings.IssuerURL)
+	if err != nil {
+		log.Println("OIDC discovery failed:", err)
+		errs.Abort(c, errs.New(errs.CodeUpstream, "Identity provider unavailable"))
+		return
+	}
+
+	state, err1 := oidc.RandomString()
+	nonce, err2 := oidc.RandomString()
+	verifier, err3 := oidc.RandomString()
+	if err := errors.Join(err1, err2, err3); err != nil {
+		errs.Abort(c, errs.Internal("Failed to start login", err))
+		return
+	}
+
+	// state, nonce and PKCE verifier travel in a short lived signed cookie so
+	// the callback can be served by any instance
+	stateToken, err := jwt.NewWithClaims(jwt.SigningMethodHS256, oidcStateClaims{
+		State:    state,
+		Nonce:    nonce,
+		Verifier: verifier,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(oidcStateTTL)),
+		},
+	}).SignedString([]byte(cfg.JWT.Secret))
+	if err != nil {
+		errs.Abort(c, errs.Internal("Failed to start login", err))
+		return
+	}
+
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, stateToken, int(oidcStateTTL.Seconds()), "/", "", false, true)
+	c.Redirect(http.StatusFound, provider.AuthCodeURL(settings.ClientID, settings.RedirectURL, settings.Scopes, state, nonce, verifier))
+}
+
+func (h *UserHandler) OIDCCallback(c *gin.Context) {
+	cfg := h.cfg
+	settings := cfg.OIDC
+	if !settings.Enabled() {
+		errs.Abort(c, errs.New(errs.CodeNotImplemented, "OIDC login is not configured"))
+		return
+	}
+
+	if errParam := c.Query("error"); errParam != "" {
+		errs.Abort(c, errs.Unauthorized("Login cancelled: "+errParam))
+		return
+	}
+
+	stateCookie, err := c.Cookie(oidcStateCookie)
+	if err != nil || stateCookie == "" {
+		errs.Abort(c, errs.BadRequest("Missing login state"))
+		return
+	}
+	c.SetCookie(oidcStateCookie, "", -1, "/", "", false, true)
+
+	stateClaims := &oidcStateClaims{}
+	token, err := jwt.ParseWithClaims(stateCookie, stateClaims, func(token *jwt.Token) (interface{}, error) {
+		return []byte(cfg.JWT.Secret), nil
+	}, jwt.WithValidMethods([]string{"HS256"}))
+	if err != nil || !token.Valid || stateClaims.State != c.Query("state") {
+		errs.Abort(c, errs.BadRequest("Invalid login state"))
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		errs.Abort(c, errs.BadRequest("Missing authorization code"))
+		return
+	}
+
+	provider, err := h.getOIDCProvider(c, settings.IssuerURL)
+	if err != nil {
+		log.Println("OIDC discovery failed:", err)
+		errs.Abort(c, errs.New(errs.CodeUpstream, "Identity provider unavailable"))
+		return
+	}
+
+	tokens, err := provider.Exchange(c.Request.Context(), settings.ClientID, settings.ClientSecret, settings.RedirectURL, code, stateClaims.Verifier)
+	if err != nil {
+		log.Println("OIDC code exchange failed:", err)
+		errs.Abort(c, errs.Unauthorized("Failed to complete login"))
+		return
+	}
+
+	idClaims, err := provider.VerifyIDToken(c.Request.Context(), tokens.IDToken, settings.ClientID, stateClaims.Nonce)
+	if err != nil {
+		log.Println("OIDC id token rejected:", err)
+		errs.Abort(c, errs.Unauthorized("Failed to complete login"))
+		return
+	}
+
+	user, err := h.findOrCreateOIDCUser(c, provider.Issuer, idClaims)
+	if err != nil {
+		log.Println("OIDC user linking failed:", err)
+		errs.Abort(c, err)
+		return
+	}
+
+	if _, err := h.startSession(c, user); err != nil {
+		errs.Abort(c, errs.Internal("Failed to generate token", err))
+		return
+	}
+
+	c.Redirect(http.StatusFound, cfg.URLs.FrontendBaseURL+"/")
+}
+
+// findOrCreateOIDCUser resolves the local account for an OIDC identity. A
+// known (issuer, subject) pair wins, then an existing account with the same
+// verified email is linked, otherwise a new account is created. The unique
+// (issuer, subject) index rejects a concurrent duplicate link. Locked accounts
+// are refused like on a password login.
+//
+// Registration never verifies emails, so whoever registered the address may
+// not be the owner of the identity. Linking therefore replaces the password of
+// the existing account with an unusable one and revokes its sessions, anyone
+// who pre-registered the address loses access to it.
+func (h *UserHandler) findOrCreateOIDCUser(c *gin.Context, issuer string, claims *oidc.IDTokenClaims) (*models.User, error) {
+	ctx := c.Request.Context()
+
+	identity, err := h.users.GetIdentity(ctx, issuer, claims.Subject)
+	if err == nil {
+		user, err := h.users.Get(ctx, identity.UserID)
+		if err != nil {
+			return nil, err
+		}
+		// like a password login, the lockout only shows in the audit log
+		if user.LockedUntil != nil && user.LockedUntil.After(time.Now()) {
+			h.recordSecurityEvent(c, &user.UserID, user.Email, c.ClientIP(), securityEventLockedLogin,
+				"identity provider login attempted while account is locked")
+			return nil, errs.Unauthorized("Failed to complete login")
+		}
+		return user, nil
+	}
+	if err != repository.ErrNotFound {
+		return nil, err
+	}
+
+	if claims.Email == "" || !claims.EmailVerified {
+		return nil, errs.Conflict("Identity provider did not supply a verified email")
+	}
+
+	user, err := h.users.GetByEmail(ctx, claims.Email)
+	linking := err == nil
+	if err != nil && err != repository.ErrNotFound {
+		return nil, err
+	}
+	hashedPassword, err := unusablePasswordHash()
+	if err != nil {
+		return nil, err
+	}
+
+	// the identity is created before the password is reset, a concurrent link
+	// of the same identity fails on it and rolls back without touching the
+	// account
+	now := time.Now()
+	err = h.tx.Transaction(ctx, func(repos repository.Repositories) error {
+		if !linking {
+			var err error
+			if user, err = createOIDCUser(ctx, repos.Users, claims, hashedPassword); err != nil {
+				return err
+			}
+		}
+		err := repos.Users.CreateIdentity(ctx, &models.UserIdentity{
+			UserID:    user.UserID,
+			Issuer:    issuer,
+			Subject:   claims.Subject,
+			Email:     claims.Email,
+			CreatedAt: now,
+		})
+		if err != nil || !linking {
+			return err
+		}
+		return repos.Users.ChangePassword(ctx, user.UserID, hashedPassword, "", now)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if linking {
+		user.PasswordHash = hashedPassword
+		user.UpdatedAt = now
+		h.recordSecurityEvent(c, &user.UserID, user.Email, c.ClientIP(), securityEventIdentityLinked,
+			"linked to "+issuer+", password reset and sessions revoked")
+	}
+	return user, nil
+}
+
+// unusablePasswordHash hashes a random password nobody knows, its account can
+// only sign in through the identity provider until a password is set.
+func unusablePasswordHash() (string, error) {
+	randomPassword, err := oidc.RandomString()
+	if err != nil {
+		return "", err
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(randomPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hashedPassword), nil
+}
+
+func createOIDCUser(ctx context.Context, users repository.UserRepository, claims *oidc.IDTokenClaims, passwordHash string) (*models.User, error) {
+	baseName := strings.TrimSpace(claims.Name)
+	if baseName == "" {
+		baseName = strings.Split(claims.Email, "@")[0]
+	}
+
+	name := baseName
+	for i := 2; ; i++ {
+		taken, err := users.EmailOrNameTaken(ctx, "", name, 0)
+		if err != nil {
+			return nil, err
+		}
+		if !taken {
+			break
+		}
+		name = fmt.Sprintf("%s %d", baseName, i)
+	}
+
+	user := models.User{
+		Name:         name,
+		Email:        claims.Email,
+		PasswordHash: passwordHash,
+	}
+	if err := users.Create(ctx, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
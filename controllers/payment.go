@@ -1,14 +1,16 @@
 package controllers
 
 import (
+	"backend/config"
+	"backend/errs"
 	"backend/models"
+	"backend/repository"
+	"crypto/rand"
 	"crypto/sha512"
 	"encoding/hex"
 	"fmt"
 	"log"
-	"math/rand"
 	"net/http"
-	"os"
 	"strings"
 	"time"
 
@@ -21,9 +23,25 @@ type PaymentRequest struct {
 	Seats  string  `json:"seats"`
 }
 
-func GenerateTransactionID() string {
-	rand.Seed(time.Now().UnixNano())
-	return fmt.Sprintf("TXN%v", rand.Intn(100000000))
+type PaymentHandler struct {
+	cfg      *config.Config
+	bookings repository.BookingRepository
+	shows    repository.ShowRepository
+	users    repository.UserRepository
+}
+
+func NewPaymentHandler(cfg *config.Config, bookings repository.BookingRepository, shows repository.ShowRepository, users repository.UserRepository) *PaymentHandler {
+	return &PaymentHandler{cfg: cfg, bookings: bookings, shows: shows, users: users}
+}
+
+// GenerateTransactionID returns an unguessable transaction id, 23 characters
+// long, PayU accepts at most 25.
+func GenerateTransactionID() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "TXN" + hex.EncodeToString(b), nil
 }
 
 func GenerateHash(data string) string {
@@ -32,10 +50,10 @@ func GenerateHash(data string) string {
 	return hex.EncodeToString(hash.Sum(nil))
 }
 
-func InitiatePayment(c *gin.Context) {
+func (h *PaymentHandler) InitiatePayment(c *gin.Context) {
 	userRaw, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		errs.Abort(c, errs.Unauthorized("User not authenticated"))
 		return
 	}
 
@@ -47,7 +65,7 @@ func InitiatePayment(c *gin.Context) {
 	case int:
 		userID = v
 	default:
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Invalid user ID type"})
+		errs.Abort(c, errs.Internal("Invalid user ID type", nil))
 		return
 	}
 
@@ -59,35 +77,47 @@ func InitiatePayment(c *gin.Context) {
 
 	// bind json payload to payment struct and validate
 	var request PaymentRequest
-	if err := c.BindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+	if err := c.ShouldBindJSON(&request); err != nil {
+		errs.Abort(c, errs.Binding(err))
 		return
 	}
 
-	// payu config from .env
-	merchantKey := os.Getenv("PAYU_MERCHANT_KEY")
-	merchantSalt := os.Getenv("PAYU_MERCHANT_SALT")
-	payuBaseURL := os.Getenv("PAYU_BASE_URL")
-
-	if merchantKey == "" || merchantSalt == "" || payuBaseURL == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "PayU configuration missing"})
+	show, err := h.shows.Get(c.Request.Context(), uint(request.ShowID))
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Show not found"))
+		} else {
+			errs.Abort(c, err)
+		}
 		return
 	}
+	if show.Movie.Certification == models.CertificationA {
+		if err := h.checkAdult(c, user.UserID, show.Date); err != nil {
+			errs.Abort(c, err)
+			return
+		}
+	}
 
-	transactionID := GenerateTransactionID()
+	// payu config, validated at startup
+	cfg := h.cfg
+	merchantKey := cfg.PayU.MerchantKey
+	merchantSalt := cfg.PayU.MerchantSalt
+	payuBaseURL := cfg.PayU.BaseURL
+
+	transactionID, err := GenerateTransactionID()
+	if err != nil {
+		errs.Abort(c, errs.Internal("Failed to start payment", err))
+		return
+	}
 	amountStr := fmt.Sprintf("%.2f", request.Amount)
 	productInfo := "MovieTickets"
 	firstName := user.Name
 	email := user.Email
 	phone := "9999999999"
-	baseURL := os.Getenv("BASE_URL")
-	if baseURL == "" {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "BASE_URL is not configured"})
-		return
-	}
+	baseURL := cfg.URLs.BaseURL
 
-	successURL := fmt.Sprintf("%s/api/payment/success", baseURL)
-	failureURL := fmt.Sprintf("%s/api/payment/failure", baseURL)
+	successURL := fmt.Sprintf("%s/api/v1/payment/success", baseURL)
+	failureURL := fmt.Sprintf("%s/api/v1/payment/failure", baseURL)
 
 	// payu compatible hash
 	hashString := fmt.Sprintf("%s|%s|%s|%s|%s|%s|||||||||||%s",
@@ -113,8 +143,8 @@ func InitiatePayment(c *gin.Context) {
 	}
 
 	// save booking with status pending
-	if err := models.DB.Create(&booking).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create booking"})
+	if err := h.bookings.Create(c.Request.Context(), &booking); err != nil {
+		errs.Abort(c, errs.Internal("Failed to create booking", err))
 		return
 	}
 
@@ -141,10 +171,26 @@ func InitiatePayment(c *gin.Context) {
 	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(payuForm))
 }
 
-func PaymentSuccessHandler(c *gin.Context) {
+// checkAdult refuses A-rated bookings unless the user's recorded date of
+// birth makes them an adult on the day of the show.
+func (h *PaymentHandler) checkAdult(c *gin.Context, userID int, day time.Time) error {
+	user, err := h.users.Get(c.Request.Context(), userID)
+	if err != nil {
+		return err
+	}
+	if user.DateOfBirth == nil {
+		return errs.Forbidden("Add your date of birth to your profile to book A-rated movies")
+	}
+	if models.AgeOn(*user.DateOfBirth, day) < models.AdultAge {
+		return errs.Forbidden(fmt.Sprintf("A-rated movies are for viewers aged %d and over", models.AdultAge))
+	}
+	return nil
+}
+
+func (h *PaymentHandler) PaymentSuccessHandler(c *gin.Context) {
 	// parsing the incoming form
 	if err := c.Request.ParseForm(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form data"})
+		errs.Abort(c, errs.BadRequest("Invalid form data"))
 		return
 	}
 
@@ -156,9 +202,6 @@ func PaymentSuccessHandler(c *gin.Context) {
 		}
 	}
 
-	// the params map holds all the response fields from PayU
-	log.Println("Payment Success Params:", params)
-
 	// extract the fields
 	txnID := params["txnid"]
 	postedHash := params["hash"]
@@ -168,8 +211,9 @@ func PaymentSuccessHandler(c *gin.Context) {
 	productInfo := params["productinfo"]
 	firstName := params["firstname"]
 
-	merchantSalt := os.Getenv("PAYU_MERCHANT_SALT")
-	merchantKey := os.Getenv("PAYU_MERCHANT_KEY")
+	cfg := h.cfg
+	merchantSalt := cfg.PayU.MerchantSalt
+	merchantKey := cfg.PayU.MerchantKey
 
 	// hash sequence as per PayU docs
 	hashParts := []string{
@@ -192,28 +236,25 @@ func PaymentSuccessHandler(c *gin.Context) {
 
 	// compare the hash
 	if computedHash != postedHash {
-		log.Printf("Hash mismatch:\nComputed: %s\nPosted: %s", computedHash, postedHash)
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid hash"})
+		log.Printf("Hash mismatch for transaction %s", txnID)
+		errs.Abort(c, errs.BadRequest("Invalid hash"))
 		return
 	}
 
 	// updating booking status to 'success'
-	if err := models.DB.Model(&models.Booking{}).Where("txn_id = ?", txnID).Update("status", "success").Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update booking status"})
+	if err := h.bookings.UpdateStatusByTxnID(c.Request.Context(), txnID, "success"); err != nil {
+		errs.Abort(c, errs.Internal("Failed to update booking status", err))
 		return
 	}
-	frontendBaseURL := os.Getenv("FRONTEND_BASE_URL")
-	if frontendBaseURL == "" {
-		log.Fatal("FRONTEND_BASE_URL is not set")
-	}
+	frontendBaseURL := cfg.URLs.FrontendBaseURL
 
 	c.Redirect(http.StatusFound, fmt.Sprintf("%s/payment-success?txnid=%s", frontendBaseURL, txnID))
 }
 
-func PaymentFailureHandler(c *gin.Context) {
+func (h *PaymentHandler) PaymentFailureHandler(c *gin.Context) {
 	// parse the input data
 	if err := c.Request.ParseForm(); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid form data"})
+		errs.Abort(c, errs.BadRequest("Invalid form data"))
 		return
 	}
 
@@ -225,21 +266,16 @@ func PaymentFailureHandler(c *gin.Context) {
 		}
 	}
 
-	log.Println("Payment Failure Params:", params)
-
 	// transaction id
 	txnID := params["txnid"]
 
 	// update booking status to failed
-	if err := models.DB.Model(&models.Booking{}).Where("txn_id = ?", txnID).Update("status", "failed").Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update booking status"})
+	if err := h.bookings.UpdateStatusByTxnID(c.Request.Context(), txnID, "failed"); err != nil {
+		errs.Abort(c, errs.Internal("Failed to update booking status", err))
 		return
 	}
 
-	frontendBaseURL := os.Getenv("FRONTEND_BASE_URL")
-	if frontendBaseURL == "" {
-		log.Fatal("FRONTEND_BASE_URL is not set")
-	}
+	frontendBaseURL := h.cfg.URLs.FrontendBaseURL
 
 	c.Redirect(http.StatusFound, frontendBaseURL+"/payment-failure")
 }
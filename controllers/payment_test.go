@@ -0,0 +1,103 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestARatedBookingsNeedAnAdult(t *testing.T) {
+	repos := memory.New()
+	ctx := context.Background()
+	movie, screen := scheduleFixtures(t, repos)
+	movie.Certification = models.CertificationA
+	if err := repos.Movies.Update(ctx, movie); err != nil {
+		t.Fatal(err)
+	}
+	family := &models.Movie{MovieName: "Family", Duration: 90, Certification: models.CertificationU,
+		StartDate: movie.StartDate, EndDate: movie.EndDate}
+	if err := repos.Movies.Create(ctx, family); err != nil {
+		t.Fatal(err)
+	}
+	// both shows are on 15 January 2030
+	showOf := func(m *models.Movie, start int) uint {
+		show := models.Show{MovieID: m.MovieID, TheatreID: screen.TheatreID, ScreenID: &screen.ScreenID}
+		show.SetStart(time.Date(2030, time.January, 15, 0, 0, 0, 0, time.UTC), time.Date(0, 1, 1, start, 0, 0, 0, time.UTC), time.UTC)
+		show.ComputeEndTime(m.Duration, 0)
+		if err := repos.Shows.Create(ctx, &show); err != nil {
+			t.Fatal(err)
+		}
+		return show.ShowID
+	}
+	adultShow, familyShow := showOf(movie, 10), showOf(family, 14)
+
+	born := func(day int) *time.Time {
+		date := time.Date(2012, time.January, day, 0, 0, 0, 0, time.UTC)
+		return &date
+	}
+	ids := map[string]int{}
+	for name, dob := range map[string]*time.Time{"undeclared": nil, "seventeen": born(16), "eighteen": born(15)} {
+		user := &models.User{Name: name, Email: name + "@example.com", PasswordHash: "x", DateOfBirth: dob}
+		if err := repos.Users.Create(ctx, user); err != nil {
+			t.Fatal(err)
+		}
+		ids[name] = user.UserID
+	}
+
+	h := NewPaymentHandler(testConfig(), repos.Bookings, repos.Shows, repos.Users)
+	var as string
+	router := testRouter(func(router *gin.Engine) {
+		router.POST("/payment/initiate", func(c *gin.Context) {
+			c.Set("user", map[string]interface{}{"user_id": float64(ids[as]), "name": as, "email": as + "@example.com"})
+		}, h.InitiatePayment)
+	})
+
+	for _, tc := range []struct {
+		user string
+		show uint
+		want int
+	}{
+		{"undeclared", adultShow, http.StatusForbidden},
+		{"seventeen", adultShow, http.StatusForbidden},
+		{"eighteen", adultShow, http.StatusOK},
+		{"undeclared", familyShow, http.StatusOK},
+		{"seventeen", familyShow, http.StatusOK},
+	} {
+		as = tc.user
+		w := serve(t, router, http.MethodPost, "/payment/initiate", PaymentRequest{Amount: 250, ShowID: int(tc.show), Seats: "A1"})
+		if w.Code != tc.want {
+			t.Errorf("%s booking show %d: expected %d, got %d %s", tc.user, tc.show, tc.want, w.Code, w.Body)
+		}
+	}
+
+	bookings, err := repos.Bookings.ListByUser(ctx, ids["seventeen"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bookings) != 1 || bookings[0].ShowID != familyShow {
+		t.Errorf("refused booking was saved: %+v", bookings)
+	}
+}
+
+func TestTransactionIDs(t *testing.T) {
+	// PayU accepts at most 25 characters
+	pattern := regexp.MustCompile(`^TXN[0-9a-f]{20}$`)
+	seen := map[string]bool{}
+	for range 100 {
+		id, err := GenerateTransactionID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !pattern.MatchString(id) || seen[id] {
+			t.Fatalf("transaction id %q is malformed or repeated", id)
+		}
+		seen[id] = true
+	}
+}
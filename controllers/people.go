@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/errs"
+	"backend/models"
+	"backend/repository"
+)
+
+type PersonHandler struct {
+	people repository.PersonRepository
+	movies repository.MovieRepository
+}
+
+func NewPersonHandler(people repository.PersonRepository, movies repository.MovieRepository) *PersonHandler {
+	return &PersonHandler{people: people, movies: movies}
+}
+
+// PeoplePage is a page of people ordered by name.
+type PeoplePage struct {
+	Items  []models.Person `json:"items"`
+	Total  int64           `json:"total"`
+	Limit  int             `json:"limit"`
+	Offset int             `json:"offset"`
+}
+
+// Filmography is a person with their credits, latest release first.
+type Filmography struct {
+	models.Person
+	Credits []FilmographyCredit `json:"credits"`
+}
+
+type FilmographyCredit struct {
+	CreditID  int          `json:"credit_id"`
+	Role      string       `json:"role"`
+	Character string       `json:"character,omitempty"`
+	Job       string       `json:"job,omitempty"`
+	Movie     MovieSummary `json:"movie"`
+}
+
+// MovieSummary is the part of a movie listed next to a credit.
+type MovieSummary struct {
+	MovieID       int      `json:"movie_id"`
+	MovieName     string   `json:"movie_name"`
+	PosterURL     string   `json:"poster_url"`
+	Genres        []string `json:"genres"`
+	Certification string   `json:"certification"`
+	ReleaseDate   *string  `json:"release_date" doc:"YYYY-MM-DD"`
+}
+
+func (h *PersonHandler) CreatePerson(c *gin.Context) {
+	var person models.Person
+	if err := c.ShouldBindJSON(&person); err != nil {
+		errs.Abort(c, errs.Binding(err))
+		return
+	}
+
+	// external ids are only assigned by imports
+	person.PersonID, person.ExternalID = 0, nil
+	person.CreatedAt = time.Now()
+	person.UpdatedAt = person.CreatedAt
+
+	if err := h.people.Create(c.Request.Context(), &person); err != nil {
+		errs.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, person)
+}
+
+// GetPeople lists people by name a page at a time, q narrows them to names
+// containing it.
+func (h *PersonHandler) GetPeople(c *gin.Context) {
+	invalid := map[string]string{}
+	filter := repository.PersonFilter{Query: strings.TrimSpace(c.Query("q"))}
+	filter.Limit, filter.Offset = pageParams(c, defaultMoviePageSize, invalid)
+	if len(invalid) > 0 {
+		errs.Abort(c, errs.Invalid(invalid))
+		return
+	}
+
+	people, total, err := h.people.List(c.Request.Context(), filter)
+	if err != nil {
+		errs.Abort(c, err)
+		return
+	}
+	if people == nil {
+		people = []models.Person{}
+	}
+
+	c.Header(TotalCountHeader, strconv.FormatInt(total, 10))
+	c.JSON(http.StatusOK, PeoplePage{Items: people, Total: total, Limit: filter.Limit, Offset: filter.Offset})
+}
+
+func (h *PersonHandler) GetPerson(c *gin.Context) {
+	person, ok := h.loadPerson(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, person)
+}
+
+func (h *PersonHandler) UpdatePerson(c *gin.Context) {
+	person, ok := h.loadPerson(c)
+	if !ok {
+		return
+	}
+
+	var input models.Person
+	if err := c.ShouldBindJSON(&input); err != nil {
+		errs.Abort(c, errs.Binding(err))
+		return
+	}
+
+	person.Name = input.Name
+	person.Biography = input.Biography
+	person.ProfileURL = input.ProfileURL
+	person.UpdatedAt = time.Now()
+
+	if err := h.people.Update(c.Request.Context(), person); err != nil {
+		errs.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, person)
+}
+
+func (h *PersonHandler) DeletePerson(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errs.Abort(c, errs.BadRequest("Invalid person ID"))
+		return
+	}
+
+	if err := h.people.Delete(c.Request.Context(), id); err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Person not found"))
+		} else {
+			errs.Abort(c, err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Person deleted successfully"})
+}
+
+func (h *PersonHandler) GetFilmography(c *gin.Context) {
+	person, ok := h.loadPerson(c)
+	if !ok {
+		return
+	}
+
+	credits, err := h.people.Filmography(c.Request.Context(), person.PersonID)
+	if err != nil {
+		errs.Abort(c, err)
+		return
+	}
+
+	filmography := Filmography{Person: *person, Credits: make([]FilmographyCredit, len(credits))}
+	for i, credit := range credits {
+		genres := []string{}
+		json.Unmarshal(credit.Movie.Genres, &genres)
+		filmography.Credits[i] = FilmographyCredit{
+			CreditID:  credit.CreditID,
+			Role:      credit.Role,
+			Character: credit.Character,
+			Job:       credit.Job,
+			Movie: MovieSummary{
+				MovieID:       credit.Movie.MovieID,
+				MovieName:     credit.Movie.MovieName,
+				PosterURL:     credit.Movie.PosterURL,
+				Genres:        genres,
+				Certification: credit.Movie.Certification,
+				ReleaseDate:   isoDate(credit.Movie.ReleaseDate),
+			},
+		}
+	}
+
+	c.JSON(http.StatusOK, filmography)
+}
+
+// loadPerson loads the person named by the id path parameter and writes the
+// error response when it cannot.
+func (h *PersonHandler) loadPerson(c *gin.Context) (*models.Person, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errs.Abort(c, errs.BadRequest("Invalid person ID"))
+		return nil, false
+	}
+
+	person, err := h.people.Get(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Person not found"))
+		} else {
+			errs.Abort(c, err)
+		}
+		return nil, false
+	}
+	return person, true
+}
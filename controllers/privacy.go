@@ -0,0 +1,153 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"backend/errs"
+	"backend/models"
+	"backend/repository"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type UserDataExport struct {
+	ExportedAt    time.Time              `json:"exported_at"`
+	Profile       UserProfile            `json:"profile"`
+	Identities    []models.UserIdentity  `json:"identities"`
+	Sessions      []models.Session       `json:"sessions"`
+	LoginAttempts []models.LoginAttempt  `json:"login_attempts"`
+	Bookings      []models.Booking       `json:"bookings"`
+	SeatBookings  []models.SeatBooking   `json:"seat_bookings"`
+	Payments      []models.Payment       `json:"payments"`
+	Reviews       []models.Review        `json:"reviews"`
+	SecurityLog   []models.SecurityEvent `json:"security_events"`
+}
+
+// accountDeletionReauthWindow is how recent the session has to be to delete
+// an account without its password.
+const accountDeletionReauthWindow = 5 * time.Minute
+
+type DeleteAccountInput struct {
+	Password string `json:"password"`
+	Confirm  bool   `json:"confirm"`
+}
+
+func (h *UserHandler) ExportUserData(c *gin.Context) {
+	user, ok := h.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	export := UserDataExport{
+		ExportedAt: time.Now(),
+		Profile:    newUserProfile(*user),
+	}
+
+	ctx := c.Request.Context()
+	userID := user.UserID
+	loaders := []func() error{
+		func() (err error) { export.Identities, err = h.users.ListIdentities(ctx, userID); return },
+		func() (err error) { export.Sessions, err = h.sessions.ListByUser(ctx, userID); return },
+		func() (err error) { export.LoginAttempts, err = h.security.ListLoginAttempts(ctx, userID); return },
+		func() (err error) { export.Bookings, err = h.bookings.ListByUser(ctx, userID); return },
+		func() (err error) { export.SeatBookings, err = h.bookings.ListSeatsByUser(ctx, userID); return },
+		func() (err error) { export.Payments, err = h.payments.ListByUser(ctx, userID); return },
+		func() (err error) { export.Reviews, err = h.users.ListReviews(ctx, userID); return },
+		func() (err error) {
+			export.SecurityLog, err = h.security.ListEvents(ctx, repository.SecurityEventFilter{UserID: &userID})
+			return
+		},
+	}
+	for _, load := range loaders {
+		if err := load(); err != nil {
+			errs.Abort(c, errs.Internal("Failed to export user data", err))
+			return
+		}
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="user-%d-export.json"`, user.UserID))
+	c.JSON(http.StatusOK, export)
+}
+
+// DeleteAccount anonymises the current user instead of deleting the row so
+// bookings, seat bookings and payments stay intact for financial audits.
+func (h *UserHandler) DeleteAccount(c *gin.Context) {
+	user, ok := h.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var input DeleteAccountInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		errs.Abort(c, errs.Binding(err))
+		return
+	}
+	if !input.Confirm {
+		errs.Abort(c, errs.BadRequest("confirm must be true to delete the account"))
+		return
+	}
+
+	// accounts created through OIDC have no password the user knows, they
+	// prove themselves with a session fresh from the provider instead
+	identities, err := h.users.ListIdentities(c.Request.Context(), user.UserID)
+	if err != nil {
+		errs.Abort(c, err)
+		return
+	}
+	if len(identities) == 0 || input.Password != "" {
+		if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
+			errs.Abort(c, errs.Unauthorized("Password is incorrect"))
+			return
+		}
+	} else {
+		now := time.Now()
+		session, err := h.sessions.GetActive(c.Request.Context(), c.GetString("session_id"), user.UserID, now)
+		if err != nil {
+			errs.Abort(c, err)
+			return
+		}
+		if now.Sub(session.CreatedAt) > accountDeletionReauthWindow {
+			errs.Abort(c, errs.Unauthorized("Sign in again to delete the account"))
+			return
+		}
+	}
+
+	if err := h.anonymizeUser(c, user); err != nil {
+		errs.Abort(c, errs.Internal("Failed to delete account", err))
+		return
+	}
+
+	c.SetCookie("token", "", -1, "/", "", false, true)
+	c.JSON(http.StatusOK, gin.H{"message": "Account deleted"})
+}
+
+func (h *UserHandler) anonymizeUser(c *gin.Context, user *models.User) error {
+	randomPassword, err := utils.RandomToken(32)
+	if err != nil {
+		return err
+	}
+	unusableHash, err := bcrypt.GenerateFromPassword([]byte(randomPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user.Name = fmt.Sprintf("deleted-user-%d", user.UserID)
+	user.Email = fmt.Sprintf("deleted-%d@deleted.invalid", user.UserID)
+	user.Phone = ""
+	user.DateOfBirth = nil
+	user.PasswordHash = string(unusableHash)
+	user.Preferences = nil
+	user.IsAdmin = false
+	user.FailedLoginAttempts = 0
+	user.LastFailedLoginAt = nil
+	user.LockedUntil = nil
+	user.AnonymizedAt = &now
+	user.UpdatedAt = now
+
+	return h.users.Anonymize(c.Request.Context(), user)
+}
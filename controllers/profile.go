@@ -0,0 +1,255 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"backend/errs"
+	"backend/models"
+	"backend/repository"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/datatypes"
+)
+
+type UpdateProfileInput struct {
+	Name        *string         `json:"name" binding:"omitempty,min=1,max=255"`
+	Phone       *string         `json:"phone" binding:"omitempty,max=20"`
+	Preferences json.RawMessage `json:"preferences"`
+	DateOfBirth *MovieDate      `json:"date_of_birth"`
+}
+
+// UserProfile is a user as they see themselves.
+type UserProfile struct {
+	models.User
+	DateOfBirth *string `json:"date_of_birth" doc:"YYYY-MM-DD, needed to book A-rated movies"`
+}
+
+func newUserProfile(user models.User) UserProfile {
+	return UserProfile{User: user, DateOfBirth: isoDate(user.DateOfBirth)}
+}
+
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required,min=6"`
+}
+
+type SessionResponse struct {
+	models.Session
+	Current bool `json:"current"`
+}
+
+func currentUserID(c *gin.Context) (int, bool) {
+	userRaw, exists := c.Get("user")
+	if !exists {
+		return 0, false
+	}
+	userMap, ok := userRaw.(map[string]interface{})
+	if !ok {
+		return 0, false
+	}
+	userID, ok := userMap["user_id"].(int)
+	return userID, ok
+}
+
+func (h *UserHandler) loadCurrentUser(c *gin.Context) (*models.User, bool) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		errs.Abort(c, errs.Unauthorized("Unauthorized"))
+		return nil, false
+	}
+
+	user, err := h.users.Get(c.Request.Context(), userID)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.Unauthorized("User not found"))
+		} else {
+			errs.Abort(c, err)
+		}
+		return nil, false
+	}
+	return user, true
+}
+
+func (h *UserHandler) GetProfile(c *gin.Context) {
+	user, ok := h.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": newUserProfile(*user)})
+}
+
+func (h *UserHandler) UpdateProfile(c *gin.Context) {
+	user, ok := h.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var input UpdateProfileInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		errs.Abort(c, errs.Binding(err))
+		return
+	}
+
+	var changes repository.ProfileChanges
+	if input.Name != nil && *input.Name != user.Name {
+		taken, err := h.users.EmailOrNameTaken(c.Request.Context(), "", *input.Name, user.UserID)
+		if err != nil {
+			errs.Abort(c, err)
+			return
+		}
+		if taken {
+			errs.Abort(c, errs.BadRequest("Name already in use"))
+			return
+		}
+		user.Name = *input.Name
+		changes.Name = input.Name
+	}
+	if input.Phone != nil {
+		user.Phone = *input.Phone
+		changes.Phone = input.Phone
+	}
+	if len(input.Preferences) > 0 {
+		var prefs map[string]interface{}
+		if err := json.Unmarshal(input.Preferences, &prefs); err != nil {
+			errs.Abort(c, errs.BadRequest("preferences must be a JSON object"))
+			return
+		}
+		if prefs == nil {
+			user.Preferences = nil
+		} else {
+			user.Preferences = datatypes.JSON(input.Preferences)
+		}
+		changes.Preferences = &user.Preferences
+	}
+	if input.DateOfBirth != nil {
+		birth := input.DateOfBirth.Time
+		if birth.After(time.Now()) || birth.Year() < 1900 {
+			errs.Abort(c, errs.Validation("date_of_birth", "must be a past date after 1900"))
+			return
+		}
+		user.DateOfBirth = &birth
+		changes.DateOfBirth = &birth
+	}
+	user.UpdatedAt = time.Now()
+
+	if err := h.users.UpdateProfile(c.Request.Context(), user.UserID, changes, user.UpdatedAt); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			errs.Abort(c, errs.BadRequest("Name already in use"))
+			return
+		}
+		errs.Abort(c, errs.Internal("Failed to update profile", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"user": newUserProfile(*user)})
+}
+
+func (h *UserHandler) ChangePassword(c *gin.Context) {
+	user, ok := h.loadCurrentUser(c)
+	if !ok {
+		return
+	}
+
+	var input ChangePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		errs.Abort(c, errs.Binding(err))
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.CurrentPassword)); err != nil {
+		errs.Abort(c, errs.Unauthorized("Current password is incorrect"))
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		errs.Abort(c, errs.Internal("Failed to hash password", err))
+		return
+	}
+
+	// a password change signs out every other session of the account
+	err = h.users.ChangePassword(c.Request.Context(), user.UserID, string(hashedPassword), c.GetString("session_id"), time.Now())
+	if err != nil {
+		errs.Abort(c, errs.Internal("Failed to change password", err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+}
+
+func (h *UserHandler) GetSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		errs.Abort(c, errs.Unauthorized("Unauthorized"))
+		return
+	}
+
+	sessions, err := h.sessions.ListActive(c.Request.Context(), userID, time.Now())
+	if err != nil {
+		errs.Abort(c, err)
+		return
+	}
+
+	currentSessionID := c.GetString("session_id")
+	responses := make([]SessionResponse, len(sessions))
+	for i, session := range sessions {
+		responses[i] = SessionResponse{
+			Session: session,
+			Current: session.SessionID == currentSessionID,
+		}
+	}
+
+	c.JSON(http.StatusOK, responses)
+}
+
+func (h *UserHandler) RevokeSession(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		errs.Abort(c, errs.Unauthorized("Unauthorized"))
+		return
+	}
+
+	if err := h.sessions.Revoke(c.Request.Context(), userID, c.Param("id"), time.Now()); err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Session not found"))
+		} else {
+			errs.Abort(c, err)
+		}
+		return
+	}
+
+	if c.Param("id") == c.GetString("session_id") {
+		c.SetCookie("token", "", -1, "/", "", false, true)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+func (h *UserHandler) RevokeOtherSessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		errs.Abort(c, errs.Unauthorized("Unauthorized"))
+		return
+	}
+
+	if err := h.sessions.RevokeAllExcept(c.Request.Context(), userID, c.GetString("session_id"), time.Now()); err != nil {
+		errs.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Other sessions revoked"})
+}
+
+func (h *UserHandler) Logout(c *gin.Context) {
+	userID, _ := currentUserID(c)
+	if sessionID := c.GetString("session_id"); sessionID != "" {
+		h.sessions.Revoke(c.Request.Context(), userID, sessionID, time.Now())
+	}
+
+	c.SetCookie("token", "", -1, "/", "", false, true)
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out"})
+}
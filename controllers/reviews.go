@@ -6,9 +6,10 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 
+	"backend/errs"
 	"backend/models"
+	"backend/repository"
 )
 
 type CreateReviewInput struct {
@@ -22,30 +23,29 @@ type UpdateReviewInput struct {
 	Comments string `json:"comments"`
 }
 
-func CreateReview(c *gin.Context) {
+type ReviewHandler struct {
+	reviews repository.ReviewRepository
+}
+
+func NewReviewHandler(reviews repository.ReviewRepository) *ReviewHandler {
+	return &ReviewHandler{reviews: reviews}
+}
+
+func (h *ReviewHandler) CreateReview(c *gin.Context) {
 	var input CreateReviewInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
-	}
-
-	userIDRaw, exists := c.Get("user_id")
-	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		errs.Abort(c, errs.Binding(err))
 		return
 	}
-	userID := userIDRaw.(uint)
-
-	db := c.MustGet("db").(*gorm.DB)
 
-	var existing models.Review
-	if err := db.Where("user_id = ? AND movie_id = ?", userID, input.MovieID).First(&existing).Error; err == nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "You have already reviewed this movie"})
+	userID, ok := currentUserID(c)
+	if !ok {
+		errs.Abort(c, errs.Unauthorized("Unauthorized"))
 		return
 	}
 
 	review := models.Review{
-		UserID:    userID,
+		UserID:    uint(userID),
 		MovieID:   input.MovieID,
 		Rating:    input.Rating,
 		Comments:  input.Comments,
@@ -53,41 +53,41 @@ func CreateReview(c *gin.Context) {
 		UpdatedAt: time.Now(),
 	}
 
-	if err := db.Create(&review).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.reviews.Create(c.Request.Context(), &review); err != nil {
+		if err == repository.ErrDuplicate {
+			errs.Abort(c, errs.BadRequest("You have already reviewed this movie"))
+		} else {
+			errs.Abort(c, err)
+		}
 		return
 	}
 
 	c.JSON(http.StatusCreated, review)
 }
 
-func GetReviews(c *gin.Context) {
-	db := c.MustGet("db").(*gorm.DB)
-
-	var reviews []models.Review
-	if err := db.Find(&reviews).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+func (h *ReviewHandler) GetReviews(c *gin.Context) {
+	reviews, err := h.reviews.List(c.Request.Context())
+	if err != nil {
+		errs.Abort(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, reviews)
 }
 
-func GetReviewByID(c *gin.Context) {
-	db := c.MustGet("db").(*gorm.DB)
-
+func (h *ReviewHandler) GetReviewByID(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		errs.Abort(c, errs.BadRequest("Invalid review ID"))
 		return
 	}
 
-	var review models.Review
-	if err := db.First(&review, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+	review, err := h.reviews.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Review not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			errs.Abort(c, err)
 		}
 		return
 	}
@@ -95,29 +95,27 @@ func GetReviewByID(c *gin.Context) {
 	c.JSON(http.StatusOK, review)
 }
 
-func UpdateReview(c *gin.Context) {
-	db := c.MustGet("db").(*gorm.DB)
-
+func (h *ReviewHandler) UpdateReview(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		errs.Abort(c, errs.BadRequest("Invalid review ID"))
 		return
 	}
 
-	var review models.Review
-	if err := db.First(&review, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Review not found"})
+	review, err := h.reviews.Get(c.Request.Context(), uint(id))
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Review not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			errs.Abort(c, err)
 		}
 		return
 	}
 
 	var input UpdateReviewInput
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		errs.Abort(c, errs.Binding(err))
 		return
 	}
 
@@ -125,26 +123,28 @@ func UpdateReview(c *gin.Context) {
 	review.Comments = input.Comments
 	review.UpdatedAt = time.Now()
 
-	if err := db.Save(&review).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.reviews.Update(c.Request.Context(), review); err != nil {
+		errs.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, review)
 }
 
-func DeleteReview(c *gin.Context) {
-	db := c.MustGet("db").(*gorm.DB)
-
+func (h *ReviewHandler) DeleteReview(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.ParseUint(idParam, 10, 64)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid review ID"})
+		errs.Abort(c, errs.BadRequest("Invalid review ID"))
 		return
 	}
 
-	if err := db.Delete(&models.Review{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.reviews.Delete(c.Request.Context(), uint(id)); err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Review not found"))
+		} else {
+			errs.Abort(c, err)
+		}
 		return
 	}
 
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/errs"
+	"backend/models"
+	"backend/repository"
+)
+
+func (h *TheatreHandler) CreateScreen(c *gin.Context) {
+	theatreID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errs.Abort(c, errs.BadRequest("Invalid theatre ID"))
+		return
+	}
+
+	var screen models.Screen
+	if err := c.ShouldBindJSON(&screen); err != nil {
+		errs.Abort(c, errs.Binding(err))
+		return
+	}
+
+	if _, err := h.theatres.Get(c.Request.Context(), theatreID); err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Theatre not found"))
+		} else {
+			errs.Abort(c, err)
+		}
+		return
+	}
+
+	screen.ScreenID = 0
+	screen.TheatreID = theatreID
+	screen.CreatedAt = time.Now()
+	screen.UpdatedAt = time.Now()
+
+	if err := h.theatres.CreateScreen(c.Request.Context(), &screen); err != nil {
+		errs.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, screen)
+}
+
+func (h *TheatreHandler) GetScreens(c *gin.Context) {
+	theatreID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errs.Abort(c, errs.BadRequest("Invalid theatre ID"))
+		return
+	}
+
+	screens, err := h.theatres.ListScreens(c.Request.Context(), theatreID)
+	if err != nil {
+		errs.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, screens)
+}
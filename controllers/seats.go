@@ -4,53 +4,48 @@ import (
 	"net/http"
 	"strconv"
 
+	"backend/errs"
 	"backend/models"
+	"backend/repository"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-func BookSeat(c *gin.Context) {
-	db := c.MustGet("db").(*gorm.DB)
-
+func (h *BookingHandler) BookSeat(c *gin.Context) {
 	var booking models.SeatBooking
 	if err := c.ShouldBindJSON(&booking); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request payload"})
+		errs.Abort(c, errs.BadRequest("Invalid request payload"))
 		return
 	}
 
 	if booking.Seat == "" || booking.ShowID == 0 || booking.UserID == 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Seat, ShowID, and UserID are required"})
-		return
-	}
-
-	var existing models.SeatBooking
-	if err := db.Where("show_id = ? AND seat = ?", booking.ShowID, booking.Seat).First(&existing).Error; err == nil {
-		c.JSON(http.StatusConflict, gin.H{"error": "Seat already booked"})
+		errs.Abort(c, errs.BadRequest("Seat, ShowID, and UserID are required"))
 		return
 	}
 
-	if err := db.Create(&booking).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to book seat"})
+	if err := h.bookings.BookSeat(c.Request.Context(), &booking); err != nil {
+		if err == repository.ErrSeatBooked {
+			errs.Abort(c, errs.Conflict("Seat already booked"))
+			return
+		}
+		errs.Abort(c, errs.Internal("Failed to book seat", err))
 		return
 	}
 
 	c.JSON(http.StatusCreated, gin.H{"message": "Seat booked successfully"})
 }
 
-func GetBookedSeats(c *gin.Context) {
-	db := c.MustGet("db").(*gorm.DB)
-
+func (h *BookingHandler) GetBookedSeats(c *gin.Context) {
 	showIDStr := c.Param("id")
 	showID, err := strconv.Atoi(showIDStr)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid show ID"})
+		errs.Abort(c, errs.BadRequest("Invalid show ID"))
 		return
 	}
 
-	var bookings []models.SeatBooking
-	if err := db.Where("show_id = ?", showID).Find(&bookings).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve bookings"})
+	bookings, err := h.bookings.ListSeats(c.Request.Context(), uint(showID))
+	if err != nil {
+		errs.Abort(c, errs.Internal("Failed to retrieve bookings", err))
 		return
 	}
 
@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/errs"
+	"backend/models"
+	"backend/repository"
+	"backend/scheduling"
+)
+
+// Reasons a show cannot be scheduled.
+const (
+	ConflictOverlap    = "overlap"
+	ConflictOutsideRun = "outside_run"
+	ConflictTooShort   = "too_short"
+)
+
+// ScheduleConflict is a reason a show cannot be scheduled. Field names the
+// request field at fault, e.g. times[1].
+type ScheduleConflict struct {
+	Field   string `json:"field"`
+	Reason  string `json:"reason" doc:"overlap, outside_run or too_short"`
+	Message string `json:"message"`
+	// ShowID is the scheduled show overlapped, unset when two shows of the
+	// request overlap.
+	ShowID *uint `json:"show_id,omitempty"`
+}
+
+// ScheduleCheck is what a dry run reports, the shows are not saved.
+type ScheduleCheck struct {
+	OK        bool               `json:"ok"`
+	Shows     []models.Show      `json:"shows"`
+	Conflicts []ScheduleConflict `json:"conflicts"`
+}
+
+// scheduleError turns conflicts into the error response, 409 when a show
+// overlaps another and 400 otherwise.
+func scheduleError(conflicts []ScheduleConflict) *errs.Error {
+	err := &errs.Error{Code: errs.CodeValidation, Message: "Show cannot be scheduled", Fields: map[string]string{}}
+	for _, conflict := range conflicts {
+		if conflict.Reason == ConflictOverlap {
+			err.Code = errs.CodeConflict
+			err.Message = "Show overlaps the schedule of the screen"
+		}
+		if previous, ok := err.Fields[conflict.Field]; ok {
+			err.Fields[conflict.Field] = previous + "; " + conflict.Message
+		} else {
+			err.Fields[conflict.Field] = conflict.Message
+		}
+	}
+	return err
+}
+
+// schedule runs fn in a transaction that holds the schedule of the screen, so
+// shows fn saves after checking them cannot be overlapped by a concurrent
+// request. The writes of fn are rolled back when it reports conflicts, which
+// schedule returns. A dry run only checks and skips the lock.
+func (h *ShowHandler) schedule(ctx context.Context, theatreID int, screenID *int, dryRun bool, fn func(repository.ShowRepository) ([]ScheduleConflict, error)) ([]ScheduleConflict, error) {
+	if dryRun {
+		return fn(h.shows)
+	}
+	var conflicts []ScheduleConflict
+	err := h.tx.Transaction(ctx, func(repos repository.Repositories) error {
+		if err := scheduling.LockScreens(ctx, repos.Shows, scheduling.Screen{TheatreID: theatreID, ScreenID: screenID}); err != nil {
+			return err
+		}
+		var err error
+		if conflicts, err = fn(repos.Shows); err == nil && len(conflicts) > 0 {
+			err = errScheduleConflict
+		}
+		return err
+	})
+	if err == errScheduleConflict {
+		err = nil
+	}
+	return conflicts, err
+}
+
+// errScheduleConflict rolls back a schedule transaction that found conflicts.
+var errScheduleConflict = errors.New("schedule conflict")
+
+// checkSchedule returns the conflicts of shows, which share a movie, theatre,
+// screen and date: days outside the movie's run, shows shorter than the
+// movie and overlaps, cleaning buffer included, with each other and with the
+// shows already on the screen. except is a show being rescheduled, field
+// names the request field of each show.
+func (h *ShowHandler) checkSchedule(ctx context.Context, repo repository.ShowRepository, movie *models.Movie, shows []models.Show, except uint, field func(int) string) ([]ScheduleConflict, error) {
+	conflicts := []ScheduleConflict{}
+	if len(shows) == 0 {
+		return conflicts, nil
+	}
+
+	first := shows[0]
+	day := showDay(first)
+	if day.Before(repository.Day(movie.StartDate)) || day.After(repository.Day(movie.EndDate)) {
+		for i := range shows {
+			conflicts = append(conflicts, ScheduleConflict{
+				Field:  field(i),
+				Reason: ConflictOutsideRun,
+				Message: fmt.Sprintf("%s runs from %s to %s", movie.MovieName,
+					movie.StartDate.Format(time.DateOnly), movie.EndDate.Format(time.DateOnly)),
+			})
+		}
+	}
+
+	runtime := time.Duration(movie.Duration) * time.Minute
+	for i, show := range shows {
+		start, end := show.Interval()
+		if end.Sub(start) < runtime {
+			conflicts = append(conflicts, ScheduleConflict{
+				Field:  field(i),
+				Reason: ConflictTooShort,
+				Message: fmt.Sprintf("runs %d minutes, %s needs %d",
+					int(end.Sub(start).Minutes()), movie.MovieName, movie.Duration),
+			})
+		}
+	}
+
+	scheduled, err := scheduling.OnScreen(ctx, repo, first)
+	if err != nil {
+		return nil, err
+	}
+	for i, show := range shows {
+		for _, other := range scheduling.Overlapping(show, scheduled, except, h.cleaningBuffer) {
+			conflicts = append(conflicts, overlapConflict(field(i), "overlaps ", other, h.cleaningBuffer))
+		}
+		for j, other := range shows[:i] {
+			otherStart, otherEnd := other.Interval()
+			if start, end := show.Interval(); scheduling.Overlap(start, end, otherStart, otherEnd, h.cleaningBuffer) {
+				conflicts = append(conflicts, ScheduleConflict{
+					Field:   field(i),
+					Reason:  ConflictOverlap,
+					Message: fmt.Sprintf("overlaps %s%s", field(j), bufferNote(h.cleaningBuffer)),
+				})
+			}
+		}
+	}
+	return conflicts, nil
+}
+
+// overlapConflict reports that the show named by field overlaps other, a
+// scheduled show, message leads the description of other.
+func overlapConflict(field, message string, other models.Show, buffer time.Duration) ScheduleConflict {
+	id := other.ShowID
+	return ScheduleConflict{
+		Field:  field,
+		Reason: ConflictOverlap,
+		Message: fmt.Sprintf("%sshow %d of %s from %s to %s%s", message, other.ShowID, other.Movie.MovieName,
+			other.StartTime.Format("15:04"), other.EndTime.Format("15:04"), bufferNote(buffer)),
+		ShowID: &id,
+	}
+}
+
+func bufferNote(buffer time.Duration) string {
+	if buffer == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" plus the %s cleaning buffer", strings.TrimSuffix(buffer.String(), "0s"))
+}
+
+// retimeConflicts turns the overlaps of shows retimed with a new runtime into
+// conflicts, field names each retimed show as shows[id].
+func retimeConflicts(retimed []scheduling.Retimed, buffer time.Duration) []ScheduleConflict {
+	conflicts := []ScheduleConflict{}
+	for _, r := range retimed {
+		for _, other := range r.Overlaps {
+			message := fmt.Sprintf("would end at %s and overlap ", r.Show.EndTime.Format("15:04"))
+			conflicts = append(conflicts, overlapConflict(fmt.Sprintf("shows[%d]", r.Show.ShowID), message, other, buffer))
+		}
+	}
+	return conflicts
+}
+
+// showDay is the date of a show as a UTC midnight.
+func showDay(show models.Show) time.Time {
+	return time.Date(show.Date.Year(), show.Date.Month(), show.Date.Day(), 0, 0, 0, 0, time.UTC)
+}
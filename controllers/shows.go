@@ -2,215 +2,345 @@ package controllers
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"strconv"
 	"time"
 
+	"backend/config"
+	"backend/errs"
 	"backend/models"
+	"backend/repository"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 )
 
-func CreateShow(c *gin.Context) {
-	db := c.MustGet("db").(*gorm.DB)
+type ShowHandler struct {
+	tx             repository.Transactor
+	shows          repository.ShowRepository
+	movies         repository.MovieRepository
+	theatres       repository.TheatreRepository
+	cities         repository.CityRepository
+	cleaningBuffer time.Duration
+	padding        time.Duration
+}
+
+// CreateShowInput schedules one show per entry in Times. Date is YYYY-MM-DD
+// and times are HH:MM, an omitted end time is computed from the runtime of
+// the movie.
+type CreateShowInput struct {
+	MovieID   int         `json:"movie_id" binding:"required"`
+	TheatreID int         `json:"theatre_id" binding:"required"`
+	ScreenID  *int        `json:"screen_id"`
+	Date      string      `json:"date" binding:"required"`
+	Languages []string    `json:"languages" binding:"required"`
+	Times     []ShowTimes `json:"times" binding:"required"`
+}
 
-	var input struct {
-		MovieID   int      `json:"movie_id" binding:"required"`
-		TheatreID int      `json:"theatre_id" binding:"required"`
-		Date      string   `json:"date" binding:"required"`
-		Languages []string `json:"languages" binding:"required"`
-		Times     []struct {
-			StartTime string `json:"start_time" binding:"required"`
-			EndTime   string `json:"end_time" binding:"required"`
-		} `json:"times" binding:"required"`
+type ShowTimes struct {
+	StartTime string `json:"start_time" binding:"required"`
+	EndTime   string `json:"end_time" doc:"Runtime of the movie plus the configured padding when omitted"`
+}
+
+type UpdateShowInput struct {
+	MovieID   int      `json:"movie_id" binding:"required"`
+	TheatreID int      `json:"theatre_id" binding:"required"`
+	ScreenID  *int     `json:"screen_id"`
+	Date      string   `json:"date" binding:"required"`
+	StartTime string   `json:"start_time" binding:"required"`
+	EndTime   string   `json:"end_time" doc:"Runtime of the movie plus the configured padding when omitted"`
+	Languages []string `json:"languages" binding:"required"`
+}
+
+func NewShowHandler(cfg *config.Config, tx repository.Transactor, shows repository.ShowRepository, movies repository.MovieRepository, theatres repository.TheatreRepository, cities repository.CityRepository) *ShowHandler {
+	return &ShowHandler{tx: tx, shows: shows, movies: movies, theatres: theatres, cities: cities, cleaningBuffer: cfg.Shows.CleaningBuffer, padding: cfg.Shows.Padding}
+}
+
+// setEndTime sets the end time of a show to value, HH:MM local to the
+// theatre, or computes it from the runtime of the movie when value is empty.
+func (h *ShowHandler) setEndTime(show *models.Show, movie *models.Movie, value string) error {
+	if value == "" {
+		show.ComputeEndTime(movie.Duration, h.padding)
+		return nil
+	}
+	end, err := time.Parse("15:04", value)
+	if err != nil {
+		return errs.BadRequest("Invalid end_time format. Use HH:MM")
 	}
+	show.SetEnd(end)
+	return nil
+}
 
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+// validateReferences checks that the movie, theatre and optional screen of a
+// show exist and writes a 400 response when they do not. It returns the
+// movie and the time zone of the theatre.
+func (h *ShowHandler) validateReferences(c *gin.Context, movieID, theatreID int, screenID *int) (*models.Movie, *time.Location, bool) {
+	ctx := c.Request.Context()
+
+	movie, err := h.movies.Get(ctx, movieID)
+	if err != nil {
+		errs.Abort(c, errs.BadRequest("Invalid movie_id: movie not found"))
+		return nil, nil, false
+	}
+
+	if _, err := h.theatres.Get(ctx, theatreID); err != nil {
+		errs.Abort(c, errs.BadRequest("Invalid theatre_id: theatre not found"))
+		return nil, nil, false
+	}
+
+	if screenID != nil {
+		if _, err := h.theatres.GetScreen(ctx, theatreID, *screenID); err != nil {
+			errs.Abort(c, errs.BadRequest("Invalid screen_id: screen not found in theatre"))
+			return nil, nil, false
+		}
+	}
+	loc, err := h.theatres.Location(ctx, theatreID)
+	if err != nil {
+		errs.Abort(c, err)
+		return nil, nil, false
+	}
+	return movie, loc, true
+}
+
+// parseDryRun reads the dry_run query parameter and writes a 400 response
+// when it is not a boolean.
+func parseDryRun(c *gin.Context) (dryRun, ok bool) {
+	value := c.Query("dry_run")
+	if value == "" {
+		return false, true
+	}
+	dryRun, err := strconv.ParseBool(value)
+	if err != nil {
+		errs.Abort(c, errs.Validation("dry_run", "must be true or false"))
+		return false, false
+	}
+	return dryRun, true
+}
+
+func parseShowID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 0)
+	if err != nil {
+		errs.Abort(c, errs.BadRequest("Invalid show ID"))
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// CreateShow schedules the shows unless they conflict with the schedule of
+// the screen, with dry_run=true it only reports the conflicts.
+func (h *ShowHandler) CreateShow(c *gin.Context) {
+	dryRun, ok := parseDryRun(c)
+	if !ok {
 		return
 	}
 
-	var movie models.Movie
-	if err := db.First(&movie, "movie_id = ?", input.MovieID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie_id: movie not found"})
+	var input CreateShowInput
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		errs.Abort(c, errs.Binding(err))
 		return
 	}
 
-	var theatre models.Theatre
-	if err := db.First(&theatre, "theatre_id = ?", input.TheatreID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid theatre_id: theatre not found"})
+	movie, loc, ok := h.validateReferences(c, input.MovieID, input.TheatreID, input.ScreenID)
+	if !ok {
 		return
 	}
 
 	dateParsed, err := time.Parse("2006-01-02", input.Date)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		errs.Abort(c, errs.BadRequest("Invalid date format. Use YYYY-MM-DD"))
 		return
 	}
 
 	languagesJSON, err := json.Marshal(input.Languages)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid languages"})
+		errs.Abort(c, errs.BadRequest("Invalid languages"))
 		return
 	}
 
-	var createdShows []models.Show
+	var batch []models.Show
 
 	for _, t := range input.Times {
 		startTimeParsed, err := time.Parse("15:04", t.StartTime)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format. Use HH:MM"})
-			return
-		}
-		endTimeParsed, err := time.Parse("15:04", t.EndTime)
-		if err != nil {
-			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format. Use HH:MM"})
+			errs.Abort(c, errs.BadRequest("Invalid start_time format. Use HH:MM"))
 			return
 		}
 
 		show := models.Show{
 			MovieID:   input.MovieID,
 			TheatreID: input.TheatreID,
-			Date:      dateParsed,
-			StartTime: startTimeParsed,
-			EndTime:   endTimeParsed,
+			ScreenID:  input.ScreenID,
 			Languages: languagesJSON,
 			CreatedAt: time.Now(),
 			UpdatedAt: time.Now(),
 		}
-
-		if err := db.Create(&show).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		show.SetStart(dateParsed, startTimeParsed, loc)
+		if err := h.setEndTime(&show, movie, t.EndTime); err != nil {
+			errs.Abort(c, err)
 			return
 		}
-		createdShows = append(createdShows, show)
+		batch = append(batch, show)
 	}
 
-	c.JSON(http.StatusCreated, createdShows)
+	ctx := c.Request.Context()
+	conflicts, err := h.schedule(ctx, input.TheatreID, input.ScreenID, dryRun, func(shows repository.ShowRepository) ([]ScheduleConflict, error) {
+		conflicts, err := h.checkSchedule(ctx, shows, movie, batch, 0, func(i int) string { return fmt.Sprintf("times[%d]", i) })
+		if err != nil || len(conflicts) > 0 || dryRun {
+			return conflicts, err
+		}
+		for i := range batch {
+			if err := shows.Create(ctx, &batch[i]); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	})
+	if err != nil {
+		errs.Abort(c, err)
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, ScheduleCheck{OK: len(conflicts) == 0, Shows: batch, Conflicts: conflicts})
+		return
+	}
+	if len(conflicts) > 0 {
+		errs.Abort(c, scheduleError(conflicts))
+		return
+	}
+
+	c.JSON(http.StatusCreated, batch)
 }
 
-func GetShowByID(c *gin.Context) {
-	db := c.MustGet("db").(*gorm.DB)
-	id := c.Param("id")
+func (h *ShowHandler) GetShowByID(c *gin.Context) {
+	id, ok := parseShowID(c)
+	if !ok {
+		return
+	}
 
-	var show models.Show
-	if err := db.Preload("Movie").Preload("Theatre").First(&show, "show_id = ?", id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Show not found"})
+	show, err := h.shows.Get(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Show not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		errs.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, show)
 }
 
-func GetShows(c *gin.Context) {
-	db := c.MustGet("db").(*gorm.DB)
-
-	var shows []models.Show
-	if err := db.Preload("Movie").Preload("Theatre").Find(&shows).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+func (h *ShowHandler) GetShows(c *gin.Context) {
+	shows, err := h.shows.List(c.Request.Context())
+	if err != nil {
+		errs.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, shows)
 }
 
-func UpdateShow(c *gin.Context) {
-	db := c.MustGet("db").(*gorm.DB)
-	id := c.Param("id")
+// UpdateShow reschedules a show unless it conflicts with the schedule of the
+// screen, with dry_run=true it only reports the conflicts.
+func (h *ShowHandler) UpdateShow(c *gin.Context) {
+	id, ok := parseShowID(c)
+	if !ok {
+		return
+	}
+	dryRun, ok := parseDryRun(c)
+	if !ok {
+		return
+	}
 
-	var show models.Show
-	if err := db.First(&show, "show_id = ?", id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Show not found"})
+	show, err := h.shows.Get(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Show not found"))
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		errs.Abort(c, err)
 		return
 	}
 
-	var input struct {
-		MovieID   int      `json:"movie_id" binding:"required"`
-		TheatreID int      `json:"theatre_id" binding:"required"`
-		Date      string   `json:"date" binding:"required"`
-		StartTime string   `json:"start_time" binding:"required"`
-		EndTime   string   `json:"end_time" binding:"required"`
-		Languages []string `json:"languages" binding:"required"`
-	}
+	var input UpdateShowInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		errs.Abort(c, errs.Binding(err))
 		return
 	}
 
-	var movie models.Movie
-	if err := db.First(&movie, "movie_id = ?", input.MovieID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid movie_id: movie not found"})
-		return
-	}
-
-	var theatre models.Theatre
-	if err := db.First(&theatre, "theatre_id = ?", input.TheatreID).Error; err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid theatre_id: theatre not found"})
+	movie, loc, ok := h.validateReferences(c, input.MovieID, input.TheatreID, input.ScreenID)
+	if !ok {
 		return
 	}
 
 	dateParsed, err := time.Parse("2006-01-02", input.Date)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		errs.Abort(c, errs.BadRequest("Invalid date format. Use YYYY-MM-DD"))
 		return
 	}
 	startTimeParsed, err := time.Parse("15:04", input.StartTime)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_time format. Use HH:MM"})
-		return
-	}
-	endTimeParsed, err := time.Parse("15:04", input.EndTime)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_time format. Use HH:MM"})
+		errs.Abort(c, errs.BadRequest("Invalid start_time format. Use HH:MM"))
 		return
 	}
 	languagesJSON, err := json.Marshal(input.Languages)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid languages"})
+		errs.Abort(c, errs.BadRequest("Invalid languages"))
 		return
 	}
 
 	show.MovieID = input.MovieID
 	show.TheatreID = input.TheatreID
-	show.Date = dateParsed
-	show.StartTime = startTimeParsed
-	show.EndTime = endTimeParsed
+	show.ScreenID = input.ScreenID
+	show.SetStart(dateParsed, startTimeParsed, loc)
+	if err := h.setEndTime(show, movie, input.EndTime); err != nil {
+		errs.Abort(c, err)
+		return
+	}
 	show.Languages = languagesJSON
 	show.UpdatedAt = time.Now()
-
-	if err := db.Save(&show).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	// the update response never carried the associations
+	show.Movie = models.Movie{}
+	show.Theatre = models.Theatre{}
+
+	ctx := c.Request.Context()
+	conflicts, err := h.schedule(ctx, show.TheatreID, show.ScreenID, dryRun, func(shows repository.ShowRepository) ([]ScheduleConflict, error) {
+		conflicts, err := h.checkSchedule(ctx, shows, movie, []models.Show{*show}, show.ShowID, func(int) string { return "start_time" })
+		if err != nil || len(conflicts) > 0 || dryRun {
+			return conflicts, err
+		}
+		return nil, shows.Update(ctx, show)
+	})
+	if err != nil {
+		errs.Abort(c, err)
+		return
+	}
+	if dryRun {
+		c.JSON(http.StatusOK, ScheduleCheck{OK: len(conflicts) == 0, Shows: []models.Show{*show}, Conflicts: conflicts})
+		return
+	}
+	if len(conflicts) > 0 {
+		errs.Abort(c, scheduleError(conflicts))
 		return
 	}
 
 	c.JSON(http.StatusOK, show)
 }
 
-func DeleteShow(c *gin.Context) {
-	db := c.MustGet("db").(*gorm.DB)
-	id := c.Param("id")
-
-	var show models.Show
-	if err := db.First(&show, "show_id = ?", id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Show not found"})
-			return
-		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+func (h *ShowHandler) DeleteShow(c *gin.Context) {
+	id, ok := parseShowID(c)
+	if !ok {
 		return
 	}
 
-	if err := db.Delete(&show).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.shows.Delete(c.Request.Context(), id); err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Show not found"))
+			return
+		}
+		errs.Abort(c, err)
 		return
 	}
 
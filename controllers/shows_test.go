@@ -0,0 +1,248 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/config"
+	"backend/middlewares"
+	"backend/models"
+	"backend/repository"
+	"backend/repository/memory"
+
+	"github.com/gin-gonic/gin"
+)
+
+func testConfig() *config.Config {
+	return &config.Config{Shows: config.ShowsConfig{CleaningBuffer: 15 * time.Minute, Padding: 20 * time.Minute}}
+}
+
+// testRouter serves the routes added by register with the error envelope
+// middleware, the way the real router does.
+func testRouter(register func(router *gin.Engine)) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(middlewares.Errors())
+	register(router)
+	return router
+}
+
+func serve(t *testing.T, router *gin.Engine, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+	var data []byte
+	if body != nil {
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(data))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+// errorBody decodes the error envelope of a response.
+func errorBody(t *testing.T, w *httptest.ResponseRecorder) middlewares.ErrorBody {
+	t.Helper()
+	var resp middlewares.ErrorResponse
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding %s: %v", w.Body, err)
+	}
+	return resp.Error
+}
+
+// scheduleFixtures stores a two hour movie running through January 2030 and a
+// theatre with one screen in a UTC city.
+func scheduleFixtures(t *testing.T, repos repository.Repositories) (*models.Movie, *models.Screen) {
+	t.Helper()
+	ctx := context.Background()
+	movie := &models.Movie{
+		MovieName: "Fixture",
+		Duration:  120,
+		StartDate: time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2030, time.January, 31, 0, 0, 0, 0, time.UTC),
+	}
+	city := &models.City{CityName: "Fixture", Timezone: "UTC"}
+	if err := repos.Movies.Create(ctx, movie); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Cities.Create(ctx, city); err != nil {
+		t.Fatal(err)
+	}
+	theatre := &models.Theatre{TheatreName: "Fixture", CityID: city.CityID, TotalSeats: 100}
+	if err := repos.Theatres.Create(ctx, theatre); err != nil {
+		t.Fatal(err)
+	}
+	screen := &models.Screen{TheatreID: theatre.TheatreID, ScreenName: "Audi 1", TotalSeats: 100}
+	if err := repos.Theatres.CreateScreen(ctx, screen); err != nil {
+		t.Fatal(err)
+	}
+	return movie, screen
+}
+
+func TestConcurrentShowsDoNotOverlap(t *testing.T) {
+	repos := memory.New()
+	movie, screen := scheduleFixtures(t, repos)
+	h := NewShowHandler(testConfig(), repos.Transactor, repos.Shows, repos.Movies, repos.Theatres, repos.Cities)
+	router := testRouter(func(router *gin.Engine) { router.POST("/shows", h.CreateShow) })
+
+	body := map[string]interface{}{
+		"movie_id": movie.MovieID, "theatre_id": screen.TheatreID, "screen_id": screen.ScreenID,
+		"date": "2030-01-10", "languages": []string{"English"},
+		"times": []map[string]string{{"start_time": "18:00"}},
+	}
+	const requests = 8
+	codes := make(chan int, requests)
+	var wg sync.WaitGroup
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			codes <- serve(t, router, http.MethodPost, "/shows", body).Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	statuses := map[int]int{}
+	for code := range codes {
+		statuses[code]++
+	}
+	if statuses[http.StatusCreated] != 1 || statuses[http.StatusConflict] != requests-1 {
+		t.Errorf("statuses %v, want one 201 and %d 409", statuses, requests-1)
+	}
+	shows, _ := repos.Shows.List(context.Background())
+	if len(shows) != 1 {
+		t.Errorf("%d shows saved, want 1", len(shows))
+	}
+}
+
+func TestShowsAreScheduledInTheCityTimeZone(t *testing.T) {
+	repos := memory.New()
+	movie, _ := scheduleFixtures(t, repos)
+	ctx := context.Background()
+	city := &models.City{CityName: "Mumbai", Timezone: "Asia/Kolkata"}
+	if err := repos.Cities.Create(ctx, city); err != nil {
+		t.Fatal(err)
+	}
+	theatre := &models.Theatre{TheatreName: "Mumbai", CityID: city.CityID, TotalSeats: 100}
+	if err := repos.Theatres.Create(ctx, theatre); err != nil {
+		t.Fatal(err)
+	}
+	h := NewShowHandler(testConfig(), repos.Transactor, repos.Shows, repos.Movies, repos.Theatres, repos.Cities)
+	router := testRouter(func(router *gin.Engine) {
+		router.POST("/shows", h.CreateShow)
+		router.GET("/cities/:id/showtimes", h.GetCityShowtimes)
+	})
+
+	w := serve(t, router, http.MethodPost, "/shows", map[string]interface{}{
+		"movie_id": movie.MovieID, "theatre_id": theatre.TheatreID,
+		"date": "2030-01-10", "languages": []string{"English"},
+		"times": []map[string]string{{"start_time": "23:00"}},
+	})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("scheduling: %d %s", w.Code, w.Body)
+	}
+	shows, _ := repos.Shows.List(ctx)
+	if got := shows[0].StartsAt.UTC().Format(time.RFC3339); got != "2030-01-10T17:30:00Z" {
+		t.Errorf("starts at %s, want 23:00 in Kolkata", got)
+	}
+	if got := shows[0].EndsAt.UTC().Format(time.RFC3339); got != "2030-01-10T19:50:00Z" {
+		t.Errorf("ends at %s, want 01:20 the next day in Kolkata", got)
+	}
+
+	path := "/cities/" + strconv.Itoa(city.CityID) + "/showtimes"
+	w = serve(t, router, http.MethodGet, path+"?date=2030-01-10", nil)
+	var resp CityShowtimes
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if w.Code != http.StatusOK || len(resp.Movies) != 1 {
+		t.Errorf("showtimes on the local date: %d %s", w.Code, w.Body)
+	}
+	if w := serve(t, router, http.MethodGet, "/cities/999/showtimes", nil); w.Code != http.StatusNotFound {
+		t.Errorf("today in an unknown city: expected 404, got %d", w.Code)
+	}
+}
+
+func TestScheduleConflicts(t *testing.T) {
+	repos := memory.New()
+	movie, screen := scheduleFixtures(t, repos)
+	h := NewShowHandler(testConfig(), repos.Transactor, repos.Shows, repos.Movies, repos.Theatres, repos.Cities)
+	router := testRouter(func(router *gin.Engine) { router.POST("/shows", h.CreateShow) })
+	request := func(date string, times ...map[string]string) map[string]interface{} {
+		return map[string]interface{}{
+			"movie_id": movie.MovieID, "theatre_id": screen.TheatreID, "screen_id": screen.ScreenID,
+			"date": date, "languages": []string{"English"}, "times": times,
+		}
+	}
+	at := func(start string) map[string]string { return map[string]string{"start_time": start} }
+
+	// 120 minutes plus 20 of padding: 10:00 to 12:20, and 23:00 to 01:20
+	for _, body := range []map[string]interface{}{request("2030-01-10", at("10:00")), request("2030-01-11", at("23:00"))} {
+		if w := serve(t, router, http.MethodPost, "/shows", body); w.Code != http.StatusCreated {
+			t.Fatalf("scheduling: %d %s", w.Code, w.Body)
+		}
+	}
+
+	for _, tc := range []struct {
+		name string
+		body map[string]interface{}
+		// want maps the field of each conflict to its reason
+		want map[string]string
+	}{
+		{"after the cleaning buffer", request("2030-01-10", at("12:35")), map[string]string{}},
+		{"within the cleaning buffer", request("2030-01-10", at("12:30")), map[string]string{"times[0]": ConflictOverlap}},
+		{"before the show", request("2030-01-10", at("07:30")), map[string]string{"times[0]": ConflictOverlap}},
+		{"after a show running past midnight", request("2030-01-12", at("01:00")), map[string]string{"times[0]": ConflictOverlap}},
+		{"with each other", request("2030-01-20", at("18:00"), at("19:00")), map[string]string{"times[1]": ConflictOverlap}},
+		{"outside the run", request("2030-02-05", at("18:00")), map[string]string{"times[0]": ConflictOutsideRun}},
+		{"shorter than the movie", request("2030-01-20", map[string]string{"start_time": "18:00", "end_time": "19:30"}),
+			map[string]string{"times[0]": ConflictTooShort}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := serve(t, router, http.MethodPost, "/shows?dry_run=true", tc.body)
+			if w.Code != http.StatusOK {
+				t.Fatalf("dry run: %d %s", w.Code, w.Body)
+			}
+			var check ScheduleCheck
+			if err := json.Unmarshal(w.Body.Bytes(), &check); err != nil {
+				t.Fatal(err)
+			}
+			got := map[string]string{}
+			for _, conflict := range check.Conflicts {
+				got[conflict.Field] = conflict.Reason
+			}
+			if check.OK != (len(tc.want) == 0) || !maps.Equal(got, tc.want) {
+				t.Errorf("conflicts %+v, want %v", check.Conflicts, tc.want)
+			}
+		})
+	}
+
+	shows, _ := repos.Shows.List(context.Background())
+	if len(shows) != 2 {
+		t.Fatalf("dry runs saved shows, %d scheduled", len(shows))
+	}
+	// overlaps answer 409, the other conflicts 400, and nothing is saved
+	w := serve(t, router, http.MethodPost, "/shows", request("2030-01-10", at("12:30")))
+	if w.Code != http.StatusConflict || errorBody(t, w).Fields["times[0]"] == "" {
+		t.Errorf("overlap: %d %s", w.Code, w.Body)
+	}
+	w = serve(t, router, http.MethodPost, "/shows", request("2030-02-05", at("18:00")))
+	if w.Code != http.StatusBadRequest || errorBody(t, w).Fields["times[0]"] == "" {
+		t.Errorf("outside the run: %d %s", w.Code, w.Body)
+	}
+	if shows, _ := repos.Shows.List(context.Background()); len(shows) != 2 {
+		t.Errorf("conflicting shows were saved, %d scheduled", len(shows))
+	}
+}
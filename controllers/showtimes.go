@@ -0,0 +1,175 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/errs"
+	"backend/repository"
+)
+
+// CityShowtimes is what plays in a city on one day, grouped by movie and then
+// by theatre.
+type CityShowtimes struct {
+	CityID int              `json:"city_id"`
+	Date   string           `json:"date"`
+	Movies []MovieShowtimes `json:"movies"`
+}
+
+type MovieShowtimes struct {
+	MovieID   int                `json:"movie_id"`
+	MovieName string             `json:"movie_name"`
+	Duration  int                `json:"duration"`
+	Genre     string             `json:"genre"`
+	PosterURL string             `json:"poster_url"`
+	Rating    float64            `json:"rating"`
+	Theatres  []TheatreShowtimes `json:"theatres"`
+}
+
+type TheatreShowtimes struct {
+	TheatreID       int                `json:"theatre_id"`
+	TheatreName     string             `json:"theatre_name"`
+	TheatreLocation string             `json:"theatre_location"`
+	Showtimes       []ShowtimeResponse `json:"showtimes"`
+}
+
+// ShowtimeResponse is one show, start_time and end_time are HH:MM local to
+// the theatre.
+type ShowtimeResponse struct {
+	ShowID         uint      `json:"show_id"`
+	ScreenID       *int      `json:"screen_id"`
+	ScreenName     string    `json:"screen_name,omitempty"`
+	StartsAt       time.Time `json:"starts_at"`
+	EndsAt         time.Time `json:"ends_at"`
+	StartTime      string    `json:"start_time"`
+	EndTime        string    `json:"end_time"`
+	Languages      []string  `json:"languages"`
+	TotalSeats     int       `json:"total_seats"`
+	RemainingSeats int       `json:"remaining_seats"`
+}
+
+// GetCityShowtimes lists the shows of a city on the day given by date, today
+// in the city's time zone by default, optionally narrowed to a movie or a
+// language.
+func (h *ShowHandler) GetCityShowtimes(c *gin.Context) {
+	cityID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errs.Abort(c, errs.BadRequest("Invalid city ID"))
+		return
+	}
+
+	filter := repository.ShowtimeFilter{
+		CityID:   cityID,
+		Language: c.Query("language"),
+	}
+	invalid := map[string]string{}
+	if value := c.Query("date"); value != "" {
+		date, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			invalid["date"] = "must be a date as YYYY-MM-DD"
+		}
+		filter.Date = date
+	}
+	if value := c.Query("movie_id"); value != "" {
+		movieID, err := strconv.Atoi(value)
+		if err != nil || movieID < 1 {
+			invalid["movie_id"] = "must be a positive integer"
+		}
+		filter.MovieID = movieID
+	}
+	if len(invalid) > 0 {
+		errs.Abort(c, errs.Invalid(invalid))
+		return
+	}
+	if filter.Date.IsZero() {
+		loc, err := h.cities.Location(c.Request.Context(), cityID)
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("City not found"))
+			return
+		}
+		if err != nil {
+			errs.Abort(c, err)
+			return
+		}
+		filter.Date = repository.LocalDay(time.Now(), loc)
+	}
+
+	showtimes, err := h.shows.ListShowtimes(c.Request.Context(), filter)
+	if err != nil {
+		errs.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, CityShowtimes{
+		CityID: cityID,
+		Date:   filter.Date.Format(time.DateOnly),
+		Movies: groupShowtimes(showtimes),
+	})
+}
+
+// groupShowtimes nests showtimes, which are ordered by start time, under
+// their movie and theatre, both ordered by name.
+func groupShowtimes(showtimes []repository.Showtime) []MovieShowtimes {
+	movies := []MovieShowtimes{}
+	movieIndex := map[int]int{}
+	theatreIndex := map[[2]int]int{}
+
+	for _, showtime := range showtimes {
+		show := showtime.Show
+
+		m, ok := movieIndex[show.MovieID]
+		if !ok {
+			m = len(movies)
+			movieIndex[show.MovieID] = m
+			movies = append(movies, MovieShowtimes{
+				MovieID:   show.Movie.MovieID,
+				MovieName: show.Movie.MovieName,
+				Duration:  show.Movie.Duration,
+				Genre:     show.Movie.Genre,
+				PosterURL: show.Movie.PosterURL,
+				Rating:    show.Movie.Rating,
+			})
+		}
+
+		key := [2]int{show.MovieID, show.TheatreID}
+		t, ok := theatreIndex[key]
+		if !ok {
+			t = len(movies[m].Theatres)
+			theatreIndex[key] = t
+			movies[m].Theatres = append(movies[m].Theatres, TheatreShowtimes{
+				TheatreID:       show.Theatre.TheatreID,
+				TheatreName:     show.Theatre.TheatreName,
+				TheatreLocation: show.Theatre.TheatreLocation,
+			})
+		}
+
+		languages := []string{}
+		json.Unmarshal(show.Languages, &languages)
+		theatre := &movies[m].Theatres[t]
+		theatre.Showtimes = append(theatre.Showtimes, ShowtimeResponse{
+			ShowID:         show.ShowID,
+			ScreenID:       show.ScreenID,
+			ScreenName:     showtime.ScreenName,
+			StartsAt:       show.StartsAt,
+			EndsAt:         show.EndsAt,
+			StartTime:      show.StartTime.Format("15:04"),
+			EndTime:        show.EndTime.Format("15:04"),
+			Languages:      languages,
+			TotalSeats:     showtime.Capacity,
+			RemainingSeats: max(showtime.Capacity-showtime.BookedSeats, 0),
+		})
+	}
+
+	sort.SliceStable(movies, func(i, j int) bool { return movies[i].MovieName < movies[j].MovieName })
+	for _, movie := range movies {
+		sort.SliceStable(movie.Theatres, func(i, j int) bool {
+			return movie.Theatres[i].TheatreName < movie.Theatres[j].TheatreName
+		})
+	}
+	return movies
+}
@@ -1,56 +1,62 @@
 package controllers
 
 import (
-	"backend/models"
 	"net/http"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
+
+	"backend/errs"
+	"backend/models"
+	"backend/repository"
 )
 
-func CreateState(c *gin.Context) {
+type StateHandler struct {
+	states repository.StateRepository
+}
+
+func NewStateHandler(states repository.StateRepository) *StateHandler {
+	return &StateHandler{states: states}
+}
+
+func (h *StateHandler) CreateState(c *gin.Context) {
 	var state models.State
 	if err := c.ShouldBindJSON(&state); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		errs.Abort(c, errs.Binding(err))
 		return
 	}
-	db := c.MustGet("db").(*gorm.DB)
-	if err := db.Create(&state).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+
+	if err := h.states.Create(c.Request.Context(), &state); err != nil {
+		errs.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, state)
 }
 
-func GetStates(c *gin.Context) {
-	var states []models.State
-	db := c.MustGet("db").(*gorm.DB)
-
-	if err := db.Find(&states).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+func (h *StateHandler) GetStates(c *gin.Context) {
+	states, err := h.states.List(c.Request.Context())
+	if err != nil {
+		errs.Abort(c, err)
 		return
 	}
 	c.JSON(http.StatusOK, states)
 }
 
-func GetStateByID(c *gin.Context) {
+func (h *StateHandler) GetStateByID(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid State ID"})
+		errs.Abort(c, errs.BadRequest("Invalid State ID"))
 		return
 	}
 
-	var state models.State
-	db := c.MustGet("db").(*gorm.DB)
-
-	if err := db.First(&state, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "State not found"})
+	state, err := h.states.Get(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("State not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			errs.Abort(c, err)
 		}
 		return
 	}
@@ -58,63 +64,54 @@ func GetStateByID(c *gin.Context) {
 	c.JSON(http.StatusOK, state)
 }
 
-func DeleteState(c *gin.Context) {
+func (h *StateHandler) DeleteState(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid State ID"})
+		errs.Abort(c, errs.BadRequest("Invalid State ID"))
 		return
 	}
-	db := c.MustGet("db").(*gorm.DB)
 
-	var state models.State
-	if err := db.First(&state, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "State not found"})
+	if err := h.states.Delete(c.Request.Context(), id); err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("State not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			errs.Abort(c, err)
 		}
 		return
 	}
 
-	if err := db.Delete(&state).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
-	}
-
 	c.JSON(http.StatusOK, gin.H{"message": "State deleted successfully"})
 }
 
-func UpdateState(c *gin.Context) {
+func (h *StateHandler) UpdateState(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid state ID"})
+		errs.Abort(c, errs.BadRequest("Invalid state ID"))
 		return
 	}
 
-	var state models.State
-	db := c.MustGet("db").(*gorm.DB)
-
-	if err := db.First(&state, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "State not found"})
+	state, err := h.states.Get(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("State not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			errs.Abort(c, err)
 		}
 		return
 	}
 
 	var input models.State
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		errs.Abort(c, errs.Binding(err))
 		return
 	}
 
 	state.StateName = input.StateName
 
-	if err := db.Save(&state).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.states.Update(c.Request.Context(), state); err != nil {
+		errs.Abort(c, err)
 		return
 	}
 
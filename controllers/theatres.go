@@ -6,59 +6,65 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 
+	"backend/errs"
 	"backend/models"
+	"backend/repository"
 )
 
-func CreateTheatre(c *gin.Context) {
+type TheatreHandler struct {
+	theatres repository.TheatreRepository
+}
+
+func NewTheatreHandler(theatres repository.TheatreRepository) *TheatreHandler {
+	return &TheatreHandler{theatres: theatres}
+}
+
+func (h *TheatreHandler) CreateTheatre(c *gin.Context) {
 	var theatre models.Theatre
 
 	if err := c.ShouldBindJSON(&theatre); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		errs.Abort(c, errs.Binding(err))
 		return
 	}
 
+	// thumbnails are only rendered by image uploads
+	theatre.ImageThumbnails = nil
 	theatre.CreatedAt = time.Now()
 	theatre.UpdatedAt = time.Now()
 
-	db := c.MustGet("db").(*gorm.DB)
-	if err := db.Create(&theatre).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.theatres.Create(c.Request.Context(), &theatre); err != nil {
+		errs.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, theatre)
 }
 
-func GetTheatres(c *gin.Context) {
-	var theatres []models.Theatre
-	db := c.MustGet("db").(*gorm.DB)
-
-	if err := db.Find(&theatres).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+func (h *TheatreHandler) GetTheatres(c *gin.Context) {
+	theatres, err := h.theatres.List(c.Request.Context())
+	if err != nil {
+		errs.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, theatres)
 }
 
-func GetTheatreByID(c *gin.Context) {
+func (h *TheatreHandler) GetTheatreByID(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid theatre ID"})
+		errs.Abort(c, errs.BadRequest("Invalid theatre ID"))
 		return
 	}
 
-	var theatre models.Theatre
-	db := c.MustGet("db").(*gorm.DB)
-
-	if err := db.First(&theatre, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Theatre not found"})
+	theatre, err := h.theatres.Get(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Theatre not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			errs.Abort(c, err)
 		}
 		return
 	}
@@ -66,46 +72,47 @@ func GetTheatreByID(c *gin.Context) {
 	c.JSON(http.StatusOK, theatre)
 }
 
-func DeleteTheatre(c *gin.Context) {
+func (h *TheatreHandler) DeleteTheatre(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid theatre ID"})
+		errs.Abort(c, errs.BadRequest("Invalid theatre ID"))
 		return
 	}
 
-	db := c.MustGet("db").(*gorm.DB)
-	if err := db.Delete(&models.Theatre{}, id).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.theatres.Delete(c.Request.Context(), id); err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Theatre not found"))
+		} else {
+			errs.Abort(c, err)
+		}
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Theatre deleted successfully"})
 }
 
-func UpdateTheatre(c *gin.Context) {
+func (h *TheatreHandler) UpdateTheatre(c *gin.Context) {
 	idParam := c.Param("id")
 	id, err := strconv.Atoi(idParam)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid theatre ID"})
+		errs.Abort(c, errs.BadRequest("Invalid theatre ID"))
 		return
 	}
 
-	var theatre models.Theatre
-	db := c.MustGet("db").(*gorm.DB)
-
-	if err := db.First(&theatre, id).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Theatre not found"})
+	theatre, err := h.theatres.Get(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Theatre not found"))
 		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			errs.Abort(c, err)
 		}
 		return
 	}
 
 	var input models.Theatre
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		errs.Abort(c, errs.Binding(err))
 		return
 	}
 
@@ -114,11 +121,15 @@ func UpdateTheatre(c *gin.Context) {
 	theatre.CityID = input.CityID
 	theatre.TheatreStatus = input.TheatreStatus
 	theatre.TotalSeats = input.TotalSeats
+	if theatre.TheatreImage != input.TheatreImage {
+		// thumbnails belong to an uploaded image
+		theatre.ImageThumbnails = nil
+	}
 	theatre.TheatreImage = input.TheatreImage
 	theatre.UpdatedAt = time.Now()
 
-	if err := db.Save(&theatre).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.theatres.Update(c.Request.Context(), theatre); err != nil {
+		errs.Abort(c, err)
 		return
 	}
 
@@ -0,0 +1,279 @@
+package controllers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"backend/config"
+	"backend/errs"
+	"backend/media"
+	"backend/repository"
+	"backend/storage"
+)
+
+// MediaPath is where stored files are served.
+const MediaPath = "/media/"
+
+// Thumbnail widths, posters follow the TMDB size names.
+var (
+	posterWidths       = []int{185, 342, 780}
+	theatreImageWidths = []int{320, 640, 1280}
+)
+
+type UploadHandler struct {
+	storage  storage.Storage
+	movies   repository.MovieRepository
+	theatres repository.TheatreRepository
+	maxSize  int64
+	mediaURL string
+}
+
+func NewUploadHandler(cfg *config.Config, store storage.Storage, movies repository.MovieRepository, theatres repository.TheatreRepository) *UploadHandler {
+	return &UploadHandler{
+		storage:  store,
+		movies:   movies,
+		theatres: theatres,
+		maxSize:  cfg.Storage.MaxUploadSize,
+		mediaURL: strings.TrimSuffix(cfg.URLs.BaseURL, "/") + MediaPath,
+	}
+}
+
+// ImageUpload is where an uploaded image and its thumbnails are served.
+type ImageUpload struct {
+	URL        string            `json:"url"`
+	Thumbnails map[string]string `json:"thumbnails" doc:"Thumbnail URLs by width, e.g. w185"`
+}
+
+// UploadPoster replaces the poster of a movie with the image in the image
+// field of a multipart form.
+func (h *UploadHandler) UploadPoster(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errs.Abort(c, errs.BadRequest("Invalid movie ID"))
+		return
+	}
+	movie, err := h.movies.Get(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Movie not found"))
+		} else {
+			errs.Abort(c, err)
+		}
+		return
+	}
+
+	upload, err := h.store(c, fmt.Sprintf("movies/%d/poster", id), posterWidths)
+	if err != nil {
+		errs.Abort(c, err)
+		return
+	}
+	thumbnails, err := json.Marshal(upload.Thumbnails)
+	if err != nil {
+		errs.Abort(c, err)
+		return
+	}
+
+	previous := h.uploadedKeys(movie.PosterURL, movie.PosterThumbnails)
+	movie.PosterURL = upload.URL
+	movie.PosterThumbnails = thumbnails
+	movie.UpdatedAt = time.Now()
+	if err := h.movies.Update(c.Request.Context(), movie); err != nil {
+		errs.Abort(c, err)
+		return
+	}
+	h.discard(c.Request.Context(), previous, upload)
+
+	c.JSON(http.StatusOK, upload)
+}
+
+// UploadTheatreImage replaces the image of a theatre with the image in the
+// image field of a multipart form.
+func (h *UploadHandler) UploadTheatreImage(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		errs.Abort(c, errs.BadRequest("Invalid theatre ID"))
+		return
+	}
+	theatre, err := h.theatres.Get(c.Request.Context(), id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			errs.Abort(c, errs.NotFound("Theatre not found"))
+		} else {
+			errs.Abort(c, err)
+		}
+		return
+	}
+
+	upload, err := h.store(c, fmt.Sprintf("theatres/%d/image", id), theatreImageWidths)
+	if err != nil {
+		errs.Abort(c, err)
+		return
+	}
+	thumbnails, err := json.Marshal(upload.Thumbnails)
+	if err != nil {
+		errs.Abort(c, err)
+		return
+	}
+
+	previous := h.uploadedKeys(theatre.TheatreImage, theatre.ImageThumbnails)
+	theatre.TheatreImage = upload.URL
+	theatre.ImageThumbnails = thumbnails
+	theatre.UpdatedAt = time.Now()
+	if err := h.theatres.Update(c.Request.Context(), theatre); err != nil {
+		errs.Abort(c, err)
+		return
+	}
+	h.discard(c.Request.Context(), previous, upload)
+
+	c.JSON(http.StatusOK, upload)
+}
+
+// ServeMedia serves stored files. Keys contain a hash of the content, so a
+// key never changes content and responses are cached for good.
+func (h *UploadHandler) ServeMedia(c *gin.Context) {
+	key := strings.TrimPrefix(c.Param("key"), "/")
+	if !storage.ValidKey(key) {
+		errs.Abort(c, errs.NotFound("Media not found"))
+		return
+	}
+
+	object, err := h.storage.Get(c.Request.Context(), key)
+	if err != nil {
+		if errors.Is(err, storage.ErrNotFound) {
+			errs.Abort(c, errs.NotFound("Media not found"))
+		} else {
+			errs.Abort(c, errs.Internal("Failed to read media", err))
+		}
+		return
+	}
+	defer object.Body.Close()
+
+	sum := sha256.Sum256([]byte(key))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Header("Cache-Control", "public, max-age=31536000, immutable")
+	c.Header("ETag", etag)
+	c.Header("X-Content-Type-Options", "nosniff")
+	if !object.ModTime.IsZero() {
+		c.Header("Last-Modified", object.ModTime.UTC().Format(http.TimeFormat))
+	}
+
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		if candidate = strings.TrimSpace(candidate); candidate == etag || candidate == "*" {
+			c.Status(http.StatusNotModified)
+			return
+		}
+	}
+
+	c.DataFromReader(http.StatusOK, object.Size, object.ContentType, object.Body, nil)
+}
+
+// store checks the uploaded image and stores it with its thumbnails under
+// prefix, in a directory named after a hash of its content.
+func (h *UploadHandler) store(c *gin.Context, prefix string, widths []int) (*ImageUpload, error) {
+	data, err := h.readImage(c)
+	if err != nil {
+		return nil, err
+	}
+
+	img, err := media.Decode(data)
+	switch {
+	case errors.Is(err, media.ErrUnsupported):
+		return nil, errs.New(errs.CodeUnsupported, "Images must be JPEG, PNG or GIF")
+	case errors.Is(err, media.ErrTooLarge):
+		return nil, errs.New(errs.CodeTooLarge, fmt.Sprintf("Images must have at most %d megapixels", media.MaxPixels/1_000_000))
+	case err != nil:
+		return nil, err
+	}
+
+	sum := sha256.Sum256(data)
+	dir := prefix + "/" + hex.EncodeToString(sum[:8])
+	ctx := c.Request.Context()
+
+	key := dir + "/original" + img.Ext
+	if err := h.storage.Put(ctx, key, data, img.ContentType); err != nil {
+		return nil, errs.Internal("Failed to store the image", err)
+	}
+	upload := &ImageUpload{URL: h.mediaURL + key, Thumbnails: make(map[string]string, len(widths))}
+
+	for _, width := range widths {
+		thumbnail, err := img.Thumbnail(width)
+		if err != nil {
+			return nil, errs.Internal("Failed to render thumbnails", err)
+		}
+		name := "w" + strconv.Itoa(width)
+		key := dir + "/" + name + ".jpg"
+		if err := h.storage.Put(ctx, key, thumbnail, "image/jpeg"); err != nil {
+			return nil, errs.Internal("Failed to store the image", err)
+		}
+		upload.Thumbnails[name] = h.mediaURL + key
+	}
+	return upload, nil
+}
+
+// readImage reads the image field of a multipart form, no larger than the
+// configured upload size.
+func (h *UploadHandler) readImage(c *gin.Context) ([]byte, error) {
+	tooLarge := errs.New(errs.CodeTooLarge, fmt.Sprintf("Images must be at most %d KiB", h.maxSize>>10))
+
+	// leave room for the multipart framing around the file
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxSize+64<<10)
+	file, header, err := c.Request.FormFile("image")
+	if err != nil {
+		var maxBytes *http.MaxBytesError
+		if errors.As(err, &maxBytes) {
+			return nil, tooLarge
+		}
+		return nil, errs.Validation("image", "is required, send it as multipart/form-data")
+	}
+	defer file.Close()
+	if header.Size > h.maxSize {
+		return nil, tooLarge
+	}
+	return io.ReadAll(file)
+}
+
+// uploadedKeys returns the storage keys of a previous upload, none when the
+// image is hosted elsewhere.
+func (h *UploadHandler) uploadedKeys(url string, thumbnails []byte) []string {
+	var urls map[string]string
+	json.Unmarshal(thumbnails, &urls)
+
+	var keys []string
+	for _, u := range append([]string{url}, slices.Collect(maps.Values(urls))...) {
+		if key, ok := strings.CutPrefix(u, h.mediaURL); ok && storage.ValidKey(key) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// discard deletes the files of a replaced upload, unless the new upload is
+// the same image and reuses them. Failures only leave orphaned files.
+func (h *UploadHandler) discard(ctx context.Context, keys []string, current *ImageUpload) {
+	kept := map[string]bool{strings.TrimPrefix(current.URL, h.mediaURL): true}
+	for _, u := range current.Thumbnails {
+		kept[strings.TrimPrefix(u, h.mediaURL)] = true
+	}
+	for _, key := range keys {
+		if kept[key] {
+			continue
+		}
+		if err := h.storage.Delete(ctx, key); err != nil {
+			log.Println("Failed to delete replaced media:", err)
+		}
+	}
+}
@@ -2,49 +2,147 @@ package controllers
 
 import (
 	"net/http"
-	"os"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
+	"backend/config"
+	"backend/errs"
 	"backend/models"
+	"backend/oidc"
+	"backend/repository"
+	"backend/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// UserHandler serves registration, login, profile and account management.
+type UserHandler struct {
+	cfg      *config.Config
+	tx       repository.Transactor
+	users    repository.UserRepository
+	sessions repository.SessionRepository
+	security repository.SecurityRepository
+	bookings repository.BookingRepository
+	payments repository.PaymentRepository
 
-func generateToken(userID int) (string, error) {
+	// the discovered OIDC provider, see getOIDCProvider
+	oidcMu       sync.Mutex
+	oidcProvider *oidc.Provider
+}
+
+type RegisterInput struct {
+	Name     string `json:"name" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+type LoginInput struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required"`
+}
+
+// AuthResponse is returned by register and login. The token is also set as
+// the token cookie.
+type AuthResponse struct {
+	Message string `json:"message"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+	Token   string `json:"token"`
+}
+
+func NewUserHandler(cfg *config.Config, repos repository.Repositories) *UserHandler {
+	return &UserHandler{
+		cfg:      cfg,
+		tx:       repos.Transactor,
+		users:    repos.Users,
+		sessions: repos.Sessions,
+		security: repos.Security,
+		bookings: repos.Bookings,
+		payments: repos.Payments,
+	}
+}
+
+func generateToken(secret string, userID int, sessionID string, expiresAt time.Time) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(time.Hour * 72).Unix(),
+		"sid":     sessionID,
+		"exp":     expiresAt.Unix(),
 	}
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return token.SignedString([]byte(secret))
+}
+
+// startSession records a new login session for user, sets the auth cookie
+// and returns the signed token referencing it.
+func (h *UserHandler) startSession(c *gin.Context, user *models.User) (string, error) {
+	cfg := h.cfg
+
+	sessionID, err := utils.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	session := models.Session{
+		SessionID:  sessionID,
+		UserID:     user.UserID,
+		UserAgent:  truncate(c.Request.UserAgent(), 512),
+		IPAddress:  c.ClientIP(),
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(cfg.JWT.TTL),
+	}
+	if err := h.sessions.Create(c.Request.Context(), &session); err != nil {
+		return "", err
+	}
+
+	token, err := generateToken(cfg.JWT.Secret, user.UserID, session.SessionID, session.ExpiresAt)
+	if err != nil {
+		return "", err
+	}
+
+	c.SetCookie("token", token, int(cfg.JWT.TTL.Seconds()), "/", "", false, true)
+	return token, nil
 }
 
-func Register(c *gin.Context) {
-	var input struct {
-		Name     string `json:"name" binding:"required"`
-		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required,min=6"`
+// truncate cuts s to at most max bytes without splitting a character and
+// drops invalid UTF-8, which the database would reject.
+func truncate(s string, max int) string {
+	s = strings.ToValidUTF8(s, "")
+	if len(s) <= max {
+		return s
+	}
+	for max > 0 && !utf8.RuneStart(s[max]) {
+		max--
 	}
+	return s[:max]
+}
+
+func (h *UserHandler) Register(c *gin.Context) {
+	var input RegisterInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		errs.Abort(c, errs.Binding(err))
 		return
 	}
 
-	var count int64
-	models.DB.Model(&models.User{}).Where("email = ? OR name = ?", input.Email, input.Name).Count(&count)
-	if count > 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Email or name already in use"})
+	taken, err := h.users.EmailOrNameTaken(c.Request.Context(), input.Email, input.Name, 0)
+	if err != nil {
+		errs.Abort(c, err)
+		return
+	}
+	if taken {
+		errs.Abort(c, errs.BadRequest("Email or name already in use"))
 		return
 	}
 
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		errs.Abort(c, errs.Internal("Failed to hash password", err))
 		return
 	}
 
@@ -54,70 +152,105 @@ func Register(c *gin.Context) {
 		PasswordHash: string(hashedPassword),
 	}
 
-	if err := models.DB.Create(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+	if err := h.users.Create(c.Request.Context(), &user); err != nil {
+		errs.Abort(c, errs.Internal("Failed to create user", err))
 		return
 	}
 
-	token, err := generateToken(user.UserID)
+	token, err := h.startSession(c, &user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		errs.Abort(c, errs.Internal("Failed to generate token", err))
 		return
 	}
 
-	c.SetCookie("token", token, 3600*24*3, "/", "", false, true)
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Registration successful",
-		"name":    user.Name,
-		"email":   user.Email,
-		"token":   token,
+	c.JSON(http.StatusOK, AuthResponse{
+		Message: "Registration successful",
+		Name:    user.Name,
+		Email:   user.Email,
+		Token:   token,
 	})
 }
 
-func Login(c *gin.Context) {
-	var input struct {
-		Email    string `json:"email" binding:"required,email"`
-		Password string `json:"password" binding:"required"`
-	}
+func (h *UserHandler) Login(c *gin.Context) {
+	var input LoginInput
 
 	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		errs.Abort(c, errs.Binding(err))
+		return
+	}
+
+	ip := c.ClientIP()
+	ipFailures, err := h.failedLoginsFromIP(c, ip)
+	if err != nil {
+		errs.Abort(c, errs.Internal("Failed to check login attempts", err))
+		return
+	}
+	// throttled requests are not recorded, the ip_throttled event recorded by
+	// the failure that reached the limit is enough
+	if ipFailures >= maxFailedLoginsPerIP {
+		abortWithRetryAfter(c, errs.CodeRateLimited, "Too many failed login attempts", loginAttemptWindow)
+		return
+	}
+
+	user, err := h.users.GetByEmail(c.Request.Context(), input.Email)
+	if err != nil {
+		h.recordFailedLogin(c, nil, input.Email, ip, ipFailures)
+		errs.Abort(c, errs.Unauthorized("Invalid credentials"))
+		return
+	}
+
+	// a locked account answers like an unknown email, the lockout only shows
+	// in the audit log
+	now := time.Now()
+	if user.LockedUntil != nil && user.LockedUntil.After(now) {
+		h.recordFailedLogin(c, user, input.Email, ip, ipFailures)
+		h.recordSecurityEvent(c, &user.UserID, user.Email, ip, securityEventLockedLogin, "login attempted while account is locked")
+		errs.Abort(c, errs.Unauthorized("Invalid credentials"))
 		return
 	}
 
-	var user models.User
-	if err := models.DB.Where("email = ?", input.Email).First(&user).Error; err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+	// so does an attempt made before the progressive delay is over, telling
+	// it apart would confirm the account exists
+	if user.LastFailedLoginAt != nil && user.LastFailedLoginAt.Add(loginDelay(user.FailedLoginAttempts)).After(now) {
+		h.recordFailedLogin(c, user, input.Email, ip, ipFailures)
+		h.recordSecurityEvent(c, &user.UserID, user.Email, ip, securityEventSlowedLogin, "login attempted before the login delay was over")
+		errs.Abort(c, errs.Unauthorized("Invalid credentials"))
 		return
 	}
 
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		h.recordFailedLogin(c, user, input.Email, ip, ipFailures)
+		h.registerFailedLogin(c, user, ip)
+		errs.Abort(c, errs.Unauthorized("Invalid credentials"))
 		return
 	}
 
-	token, err := generateToken(user.UserID)
+	h.recordLoginAttempt(c, user, input.Email, ip, true)
+	h.resetFailedLogins(c, user)
+
+	token, err := h.startSession(c, user)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		errs.Abort(c, errs.Internal("Failed to generate token", err))
 		return
 	}
 
-	c.SetCookie("token", token, 3600*24*3, "/", "", false, true)
-
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Login successful",
-		"name":    user.Name,
-		"email":   user.Email,
-		"token":   token,
+	c.JSON(http.StatusOK, AuthResponse{
+		Message: "Login successful",
+		Name:    user.Name,
+		Email:   user.Email,
+		Token:   token,
 	})
 }
 
-func Me(c *gin.Context) {
+func (h *UserHandler) Me(c *gin.Context) {
 	user, exists := c.Get("user")
 	if !exists {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		errs.Abort(c, errs.Unauthorized("Unauthorized"))
 		return
 	}
 	c.JSON(http.StatusOK, user)
 }
+
+func GetCSRFToken(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"csrf_token": c.GetString("csrf_token")})
+}
@@ -0,0 +1,115 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"backend/models"
+	"backend/repository"
+	"backend/repository/memory"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestTruncateKeepsCharactersWhole(t *testing.T) {
+	cases := []struct {
+		in   string
+		max  int
+		want string
+	}{
+		{"curl/8.0", 512, "curl/8.0"},
+		{"abcdef", 3, "abc"},
+		{"añb", 2, "a"},
+		{"añb", 3, "añ"},
+		{"日本語", 4, "日"},
+		{"a\xffb", 512, "ab"},
+	}
+	for _, tc := range cases {
+		got := truncate(tc.in, tc.max)
+		if got != tc.want || !utf8.ValidString(got) {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tc.in, tc.max, got, tc.want)
+		}
+	}
+}
+
+func TestLoginHidesLockoutAndThrottling(t *testing.T) {
+	repos := memory.New()
+	ctx := context.Background()
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret123"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &models.User{Name: "locked", Email: "locked@example.com", PasswordHash: string(hash)}
+	if err := repos.Users.Create(ctx, user); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	until := now.Add(accountLockoutDuration)
+	if err := repos.Users.UpdateLoginState(ctx, user.UserID, maxFailedLoginsPerUser, &now, &until); err != nil {
+		t.Fatal(err)
+	}
+	slowed := &models.User{Name: "slowed", Email: "slowed@example.com", PasswordHash: string(hash)}
+	if err := repos.Users.Create(ctx, slowed); err != nil {
+		t.Fatal(err)
+	}
+	if err := repos.Users.UpdateLoginState(ctx, slowed.UserID, loginDelayAfterFailures, &now, nil); err != nil {
+		t.Fatal(err)
+	}
+	h := NewUserHandler(testConfig(), repos)
+	router := testRouter(func(router *gin.Engine) { router.POST("/login", h.Login) })
+	login := func(email, password string) *httptest.ResponseRecorder {
+		return serve(t, router, http.MethodPost, "/login", map[string]string{"email": email, "password": password})
+	}
+	events := func(eventType string) int {
+		list, err := repos.Security.ListEvents(ctx, repository.SecurityEventFilter{EventType: eventType})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(list)
+	}
+
+	unknown := login("nobody@example.com", "secret123")
+	locked := login("locked@example.com", "secret123")
+	if unknown.Code != http.StatusUnauthorized || locked.Code != unknown.Code || locked.Body.String() != unknown.Body.String() {
+		t.Errorf("locked account answered %d %s, unknown email %d %s", locked.Code, locked.Body, unknown.Code, unknown.Body)
+	}
+	if locked.Header().Get("Retry-After") != "" {
+		t.Error("locked account sent Retry-After")
+	}
+	if events(securityEventLockedLogin) != 1 {
+		t.Error("login to the locked account was not audited")
+	}
+
+	// the right password is refused too until the delay is over
+	if w := login("slowed@example.com", "secret123"); w.Code != unknown.Code || w.Body.String() != unknown.Body.String() ||
+		w.Header().Get("Retry-After") != "" {
+		t.Errorf("slowed down account answered %d %s, unknown email %d %s", w.Code, w.Body, unknown.Code, unknown.Body)
+	}
+	if events(securityEventSlowedLogin) != 1 {
+		t.Error("login before the delay was over was not audited")
+	}
+
+	for range maxFailedLoginsPerIP - 3 {
+		login("nobody@example.com", "secret123")
+	}
+	if events(securityEventIPThrottled) != 1 {
+		t.Fatalf("%d ip_throttled events after reaching the limit, want 1", events(securityEventIPThrottled))
+	}
+	for range 3 {
+		if w := login("nobody@example.com", "secret123"); w.Code != http.StatusTooManyRequests {
+			t.Fatalf("throttled login: expected 429, got %d", w.Code)
+		}
+	}
+	failures, err := repos.Security.CountFailedLoginsFromIP(ctx, "192.0.2.1", now.Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if failures != maxFailedLoginsPerIP || events(securityEventIPThrottled) != 1 {
+		t.Errorf("throttled logins recorded: %d attempts, %d events", failures, events(securityEventIPThrottled))
+	}
+}
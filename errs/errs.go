@@ -0,0 +1,195 @@
+// Package errs defines the domain errors handlers return and how each maps
+// to an HTTP status. The error middleware renders them as
+//
+//	{"error": {"code": "...", "message": "...", "fields": {...}, "request_id": "..."}}
+package errs
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+type Code string
+
+const (
+	CodeBadRequest     Code = "bad_request"
+	CodeValidation     Code = "validation_failed"
+	CodeUnauthorized   Code = "unauthorized"
+	CodeForbidden      Code = "forbidden"
+	CodeNotFound       Code = "not_found"
+	CodeConflict       Code = "conflict"
+	CodeLocked         Code = "locked"
+	CodeRateLimited    Code = "rate_limited"
+	CodeInternal       Code = "internal"
+	CodeNotImplemented Code = "not_implemented"
+	CodeUpstream       Code = "upstream_unavailable"
+	CodeTooLarge       Code = "payload_too_large"
+	CodeUnsupported    Code = "unsupported_media_type"
+)
+
+var statuses = map[Code]int{
+	CodeBadRequest:     http.StatusBadRequest,
+	CodeValidation:     http.StatusBadRequest,
+	CodeUnauthorized:   http.StatusUnauthorized,
+	CodeForbidden:      http.StatusForbidden,
+	CodeNotFound:       http.StatusNotFound,
+	CodeConflict:       http.StatusConflict,
+	CodeLocked:         http.StatusLocked,
+	CodeRateLimited:    http.StatusTooManyRequests,
+	CodeInternal:       http.StatusInternalServerError,
+	CodeNotImplemented: http.StatusNotImplemented,
+	CodeUpstream:       http.StatusBadGateway,
+	CodeTooLarge:       http.StatusRequestEntityTooLarge,
+	CodeUnsupported:    http.StatusUnsupportedMediaType,
+}
+
+// Error is a failure that is safe to show to API clients. Cause is logged but
+// never rendered.
+type Error struct {
+	Code       Code
+	Message    string
+	Fields     map[string]string
+	RetryAfter time.Duration
+	Cause      error
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return e.Message + ": " + e.Cause.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Status returns the HTTP status for the error code.
+func (e *Error) Status() int {
+	if status, ok := statuses[e.Code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+func BadRequest(message string) *Error   { return New(CodeBadRequest, message) }
+func Unauthorized(message string) *Error { return New(CodeUnauthorized, message) }
+func Forbidden(message string) *Error    { return New(CodeForbidden, message) }
+func NotFound(message string) *Error     { return New(CodeNotFound, message) }
+func Conflict(message string) *Error     { return New(CodeConflict, message) }
+
+// Validation reports invalid input for a single field.
+func Validation(field, message string) *Error {
+	return &Error{Code: CodeValidation, Message: "Validation failed", Fields: map[string]string{field: message}}
+}
+
+// Invalid reports invalid input for several fields at once.
+func Invalid(fields map[string]string) *Error {
+	return &Error{Code: CodeValidation, Message: "Validation failed", Fields: fields}
+}
+
+// Internal hides cause behind message, use it for failures the client cannot
+// act on.
+func Internal(message string, cause error) *Error {
+	return &Error{Code: CodeInternal, Message: message, Cause: cause}
+}
+
+// Throttled asks the client to retry after the given delay.
+func Throttled(code Code, message string, retryAfter time.Duration) *Error {
+	return &Error{Code: code, Message: message, RetryAfter: retryAfter}
+}
+
+// Binding converts a request binding failure into a bad request, with one
+// entry per invalid field for validation failures.
+func Binding(err error) *Error {
+	var validationErrors validator.ValidationErrors
+	if errors.As(err, &validationErrors) {
+		fields := make(map[string]string, len(validationErrors))
+		for _, fe := range validationErrors {
+			fields[fe.Field()] = fieldMessage(fe)
+		}
+		return &Error{Code: CodeValidation, Message: "Validation failed", Fields: fields, Cause: err}
+	}
+
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &typeErr) && typeErr.Field != "":
+		return &Error{
+			Code:    CodeValidation,
+			Message: "Validation failed",
+			Fields:  map[string]string{typeErr.Field: "must be " + expected(typeErr.Type)},
+			Cause:   err,
+		}
+	case errors.Is(err, io.EOF):
+		return &Error{Code: CodeBadRequest, Message: "Request body is empty", Cause: err}
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return &Error{Code: CodeBadRequest, Message: "Request body is not valid JSON", Cause: err}
+	}
+	return &Error{Code: CodeBadRequest, Message: "Invalid request body", Cause: err}
+}
+
+// Expecter is implemented by request types with their own JSON decoding to
+// describe what they accept, e.g. "a date as YYYY-MM-DD".
+type Expecter interface {
+	Expected() string
+}
+
+func expected(t reflect.Type) string {
+	if e, ok := reflect.Zero(t).Interface().(Expecter); ok {
+		return e.Expected()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	case reflect.Map, reflect.Struct:
+		return "an object"
+	}
+	return "of type " + t.String()
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		return "must be at least " + fe.Param()
+	case "max":
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of " + fe.Param()
+	case "url":
+		return "must be a URL"
+	case "timezone":
+		return "must be an IANA time zone, e.g. Asia/Kolkata"
+	}
+	return "is invalid"
+}
+
+// Abort records err for the error middleware and stops the handler chain.
+func Abort(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"backend/config"
+	"backend/migrations"
+	"backend/models"
+	"backend/repository/postgres"
+	"backend/tmdb"
+)
+
+func runImport(args []string) {
+	defaults := tmdb.DefaultOptions()
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	file := flags.String("file", "", "TMDB export to import, - reads standard input")
+	dryRun := flags.Bool("dry-run", false, "report what would change without writing anything")
+	country := flags.String("country", defaults.Country, "country whose certification is used")
+	runDays := flags.Int("run-days", defaults.RunDays, "days new movies run from their release date")
+	maxCast := flags.Int("max-cast", defaults.MaxCast, "actors imported per movie")
+	showPadding := flags.Duration("show-padding", defaults.ShowPadding, "added to new runtimes when computed show end times move")
+	cleaningBuffer := flags.Duration("cleaning-buffer", defaults.CleaningBuffer, "least time between two shows on a screen")
+	flags.Parse(args)
+	if *file == "" {
+		log.Fatal("usage: import -file export.json [-dry-run]")
+	}
+
+	var input io.Reader = os.Stdin
+	if *file != "-" {
+		f, err := os.Open(*file)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		input = f
+	}
+	movies, err := tmdb.Decode(input)
+	if err != nil {
+		log.Fatal("Failed to read the export: ", err)
+	}
+
+	dbConfig, err := config.LoadDatabase()
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := models.OpenDatabase(dbConfig)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := migrations.EnsureCurrent(db); err != nil {
+		log.Fatal(err)
+	}
+
+	opts := defaults
+	opts.DryRun, opts.Country, opts.RunDays, opts.MaxCast = *dryRun, *country, *runDays, *maxCast
+	opts.ShowPadding, opts.CleaningBuffer = *showPadding, *cleaningBuffer
+	report, err := tmdb.Import(context.Background(), postgres.New(db), movies, opts)
+	if err != nil {
+		log.Fatal("Import failed, no changes were written: ", err)
+	}
+	fmt.Fprint(os.Stdout, report)
+}
@@ -0,0 +1,148 @@
+package integration
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/repository"
+	"backend/repository/postgres"
+	"backend/testharness"
+)
+
+func TestRegisterLoginAndProfile(t *testing.T) {
+	h := testharness.New(t)
+
+	_, token := h.Register("alice", "alice@example.com", "secret123")
+
+	w := h.Do(testharness.Request{Method: http.MethodGet, Path: "/api/v1/profile", Token: token})
+	h.Expect(w, http.StatusOK)
+	var profile struct {
+		User struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"user"`
+	}
+	h.Decode(w, &profile)
+	if profile.User.Name != "alice" || profile.User.Email != "alice@example.com" {
+		t.Fatalf("unexpected profile %+v", profile.User)
+	}
+
+	w = h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/login", JSON: map[string]string{
+		"email":    "alice@example.com",
+		"password": "secret123",
+	}})
+	h.Expect(w, http.StatusOK)
+
+	w = h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/login", JSON: map[string]string{
+		"email":    "alice@example.com",
+		"password": "wrong-password",
+	}})
+	h.Expect(w, http.StatusUnauthorized)
+}
+
+func TestRegisterRejectsDuplicates(t *testing.T) {
+	h := testharness.New(t)
+
+	h.Register("bob", "bob@example.com", "secret123")
+
+	for _, body := range []map[string]string{
+		{"name": "bob", "email": "other@example.com", "password": "secret123"},
+		{"name": "other", "email": "bob@example.com", "password": "secret123"},
+	} {
+		w := h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/register", JSON: body})
+		h.Expect(w, http.StatusBadRequest)
+	}
+}
+
+func TestLogoutRevokesToken(t *testing.T) {
+	h := testharness.New(t)
+
+	_, token := h.Register("carol", "carol@example.com", "secret123")
+
+	h.Expect(h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/logout", Token: token}), http.StatusOK)
+	h.Expect(h.Do(testharness.Request{Method: http.MethodGet, Path: "/api/v1/profile", Token: token}), http.StatusUnauthorized)
+}
+
+func TestConcurrentFailedLoginsLockAccount(t *testing.T) {
+	h := testharness.New(t)
+
+	userID, _ := h.Register("erin", "erin@example.com", "secret123")
+
+	const attempts = 8
+	codes := make(chan int, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/login", JSON: map[string]string{
+				"email":    "erin@example.com",
+				"password": "wrong-password",
+			}})
+			codes <- w.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	// attempts that started late are slowed down or find the account locked,
+	// both answer like a wrong password
+	rejected := 0
+	for code := range codes {
+		if code != http.StatusUnauthorized {
+			t.Errorf("unexpected status %d", code)
+		}
+		rejected++
+	}
+
+	var user models.User
+	if err := h.DB.First(&user, userID).Error; err != nil {
+		t.Fatal(err)
+	}
+	var lockedLogins int64
+	if err := h.DB.Model(&models.SecurityEvent{}).Where("user_id = ? AND event_type = ?", userID, "locked_account_login").
+		Count(&lockedLogins).Error; err != nil {
+		t.Fatal(err)
+	}
+	var slowedLogins int64
+	if err := h.DB.Model(&models.SecurityEvent{}).Where("user_id = ? AND event_type = ?", userID, "slowed_down_login").
+		Count(&slowedLogins).Error; err != nil {
+		t.Fatal(err)
+	}
+	if failed := rejected - int(lockedLogins+slowedLogins); user.FailedLoginAttempts != failed {
+		t.Fatalf("%d failed logins were counted as %d", failed, user.FailedLoginAttempts)
+	}
+	if locked := user.LockedUntil != nil; locked != (user.FailedLoginAttempts >= 5) {
+		t.Fatalf("%d failed logins, locked %v", user.FailedLoginAttempts, locked)
+	}
+}
+
+func TestProfileUpdateLeavesOtherColumns(t *testing.T) {
+	h := testharness.New(t)
+
+	userID, token := h.Register("frank", "frank@example.com", "secret123")
+
+	// columns written elsewhere survive a profile update
+	users := postgres.New(h.DB).Users
+	if _, err := users.RegisterFailedLogin(context.Background(), userID, time.Now(), time.Hour, 5, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	phone := "+911234567890"
+	if err := users.UpdateProfile(context.Background(), userID, repository.ProfileChanges{Phone: &phone}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	h.Expect(h.Do(testharness.Request{Method: http.MethodPut, Path: "/api/v1/profile", Token: token,
+		JSON: map[string]string{"name": "franklin"}}), http.StatusOK)
+
+	var user models.User
+	if err := h.DB.First(&user, userID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if user.Name != "franklin" || user.Phone != phone || user.FailedLoginAttempts != 1 {
+		t.Fatalf("profile update clobbered the row: %+v", user)
+	}
+}
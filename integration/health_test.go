@@ -0,0 +1,27 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"backend/testharness"
+)
+
+func TestReadinessChecksDatabaseAndSchema(t *testing.T) {
+	h := testharness.New(t)
+
+	h.Expect(h.Do(testharness.Request{Method: http.MethodGet, Path: "/healthz"}), http.StatusOK)
+
+	w := h.Do(testharness.Request{Method: http.MethodGet, Path: "/readyz"})
+	h.Expect(w, http.StatusOK)
+	var ready struct {
+		Status                string `json:"status"`
+		Database              string `json:"database"`
+		SchemaVersion         int    `json:"schema_version"`
+		ExpectedSchemaVersion int    `json:"expected_schema_version"`
+	}
+	h.Decode(w, &ready)
+	if ready.Status != "ready" || ready.Database != "ok" || ready.SchemaVersion != ready.ExpectedSchemaVersion {
+		t.Fatalf("unexpected readiness %+v", ready)
+	}
+}
@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+
+	"backend/models"
+	"backend/testharness"
+	"backend/tmdb"
+)
+
+func TestImportTMDB(t *testing.T) {
+	h := testharness.New(t)
+
+	userID, token := h.Register("importer", "importer@example.com", "Password123!")
+	if err := h.DB.Model(&models.User{}).Where("user_id = ?", userID).Update("is_admin", true).Error; err != nil {
+		t.Fatalf("promoting user: %v", err)
+	}
+
+	export := []map[string]interface{}{
+		{
+			"id": 550, "title": "Imported Movie", "overview": "An imported movie", "runtime": 139,
+			"release_date": "2030-10-15", "original_language": "en", "poster_path": "/poster.jpg", "vote_average": 8.44,
+			"genres":           []map[string]interface{}{{"id": 18, "name": "Drama"}, {"id": 53, "name": "Thriller"}},
+			"spoken_languages": []map[string]string{{"iso_639_1": "en", "english_name": "English"}},
+			"videos":           map[string]interface{}{"results": []map[string]string{{"site": "YouTube", "type": "Trailer", "key": "abc"}}},
+			"release_dates": map[string]interface{}{"results": []map[string]interface{}{
+				{"iso_3166_1": "IN", "release_dates": []map[string]string{{"certification": "UA 16+"}}},
+			}},
+			"credits": map[string]interface{}{
+				"cast": []map[string]interface{}{{"id": 819, "name": "Lead Actor", "character": "Narrator", "order": 0}},
+				"crew": []map[string]interface{}{
+					{"id": 7467, "name": "The Director", "job": "Director"},
+					{"id": 7468, "name": "Grip", "job": "Key Grip"},
+				},
+			},
+		},
+		{"id": 551, "title": "No Runtime", "genres": []map[string]interface{}{{"id": 18, "name": "Drama"}}, "original_language": "en"},
+	}
+
+	post := func(path string) tmdb.Report {
+		t.Helper()
+		w := h.Do(testharness.Request{Method: http.MethodPost, Path: path, Token: token, JSON: export})
+		h.Expect(w, http.StatusOK)
+		var report tmdb.Report
+		h.Decode(w, &report)
+		return report
+	}
+
+	report := post("/api/v1/admin/imports/tmdb?dry_run=true")
+	if report.Movies.Created != 1 {
+		t.Fatalf("dry run report %+v", report)
+	}
+	var count int64
+	h.DB.Model(&models.Movie{}).Where("external_id = ?", "tmdb:550").Count(&count)
+	if count != 0 {
+		t.Fatal("dry run wrote the movie")
+	}
+
+	report = post("/api/v1/admin/imports/tmdb")
+	if report.Movies != (tmdb.Counts{Created: 1}) || report.People != (tmdb.Counts{Created: 2}) || report.Credits != (tmdb.Counts{Created: 2}) {
+		t.Errorf("first import %+v", report)
+	}
+	if len(report.Rejected) != 1 || report.Rejected[0].ExternalID != "tmdb:551" {
+		t.Errorf("rejected %+v", report.Rejected)
+	}
+
+	var movie models.Movie
+	if err := h.DB.Where("external_id = ?", "tmdb:550").First(&movie).Error; err != nil {
+		t.Fatalf("loading imported movie: %v", err)
+	}
+	if movie.Certification != models.CertificationUA || movie.TrailerURL != "https://www.youtube.com/watch?v=abc" ||
+		movie.OriginalLanguage != "English" || movie.Genre != "Drama" || movie.Rating != 8.4 {
+		t.Errorf("imported movie %+v", movie)
+	}
+
+	report = post("/api/v1/admin/imports/tmdb")
+	if report.Movies != (tmdb.Counts{Skipped: 1}) || report.People != (tmdb.Counts{Skipped: 2}) || report.Credits != (tmdb.Counts{Skipped: 2}) {
+		t.Errorf("second import %+v", report)
+	}
+
+	w := h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/admin/imports/tmdb", JSON: export})
+	h.Expect(w, http.StatusUnauthorized)
+}
@@ -0,0 +1,13 @@
+// Package integration drives the HTTP API against a real Postgres database,
+// see the testharness package for how the database is provided.
+package integration
+
+import (
+	"testing"
+
+	"backend/testharness"
+)
+
+func TestMain(m *testing.M) {
+	testharness.Main(m)
+}
@@ -0,0 +1,114 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"backend/controllers"
+	"backend/models"
+	"backend/testharness"
+
+	"gorm.io/datatypes"
+)
+
+func TestSearchMovies(t *testing.T) {
+	h := testharness.New(t)
+
+	day := func(month time.Month) time.Time { return time.Date(2030, month, 1, 0, 0, 0, 0, time.UTC) }
+	for _, movie := range []models.Movie{
+		{MovieName: "Search Alpha", MovieDescription: "A space drama", Languages: datatypes.JSON(`["English","Hindi"]`),
+			Genre: "Drama", Genres: datatypes.JSON(`["Drama","Sci-Fi"]`), MovieStatus: "Now Showing", Rating: 4.5, StartDate: day(1), EndDate: day(2)},
+		{MovieName: "Search Beta 100%", MovieDescription: "A comedy", Languages: datatypes.JSON(`["Tamil"]`),
+			Genre: "Comedy", Genres: datatypes.JSON(`["Comedy"]`), MovieStatus: "Upcoming", Rating: 3.0, StartDate: day(3), EndDate: day(4)},
+		{MovieName: "Search Gamma", MovieDescription: "Another drama", Languages: datatypes.JSON(`["english"]`),
+			Genre: "drama", Genres: datatypes.JSON(`["drama"]`), MovieStatus: "Upcoming", Rating: 4.0, StartDate: day(5), EndDate: day(6)},
+	} {
+		if err := h.DB.Create(&movie).Error; err != nil {
+			t.Fatalf("creating movie: %v", err)
+		}
+	}
+
+	names := func(path string) ([]string, int64) {
+		t.Helper()
+		w := h.Do(testharness.Request{Method: http.MethodGet, Path: path})
+		h.Expect(w, http.StatusOK)
+		var page controllers.MoviePage
+		h.Decode(w, &page)
+		if header := w.Header().Get(controllers.TotalCountHeader); header != strconv.FormatInt(page.Total, 10) {
+			t.Fatalf("%s: X-Total-Count %q, total %d", path, header, page.Total)
+		}
+		var result []string
+		for _, movie := range page.Items {
+			result = append(result, movie.MovieName)
+		}
+		return result, page.Total
+	}
+
+	for path, want := range map[string][]string{
+		"/api/v1/movies?q=search+drama&sort=-rating":                  {"Search Alpha", "Search Gamma"},
+		"/api/v1/movies?q=100%25":                                     {"Search Beta 100%"},
+		"/api/v1/movies?q=search&language=ENGLISH":                    {"Search Alpha", "Search Gamma"},
+		"/api/v1/movies?q=search&genre=DRAMA&sort=name":               {"Search Alpha", "Search Gamma"},
+		"/api/v1/movies?q=search&genre=sci-fi":                        {"Search Alpha"},
+		"/api/v1/movies?q=search&status=now+showing":                  nil,
+		"/api/v1/movies?q=search&from=2030-02-15&to=2030-03-10":       {"Search Beta 100%"},
+		"/api/v1/movies?q=search&sort=-release_date&limit=1&offset=1": {"Search Beta 100%"},
+	} {
+		got, _ := names(path)
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: got %v, want %v", path, got, want)
+		}
+	}
+
+	if _, total := names("/api/v1/movies?q=search&limit=1"); total != 3 {
+		t.Errorf("total %d, want 3", total)
+	}
+
+	w := h.Do(testharness.Request{Method: http.MethodGet, Path: "/movies?q=search"})
+	h.Expect(w, http.StatusOK)
+	var legacy []controllers.MovieResponse
+	h.Decode(w, &legacy)
+	if len(legacy) != 3 {
+		t.Errorf("legacy listing returned %d movies, want 3", len(legacy))
+	}
+
+	h.Expect(h.Do(testharness.Request{Method: http.MethodGet, Path: "/api/v1/movies?sort=popularity"}), http.StatusBadRequest)
+}
+
+func TestNowShowingAndComingSoon(t *testing.T) {
+	h := testharness.New(t)
+
+	var puneID int
+	if err := h.DB.Model(&models.City{}).Where("city_name = ?", "Pune").Select("city_id").Scan(&puneID).Error; err != nil {
+		t.Fatalf("loading city: %v", err)
+	}
+
+	list := func(path string) []string {
+		t.Helper()
+		w := h.Do(testharness.Request{Method: http.MethodGet, Path: path})
+		h.Expect(w, http.StatusOK)
+		var page controllers.MoviePage
+		h.Decode(w, &page)
+		var result []string
+		for _, movie := range page.Items {
+			result = append(result, movie.MovieName+": "+movie.MovieStatus)
+		}
+		return result
+	}
+
+	for path, want := range map[string][]string{
+		"/api/v1/movies/now-showing":                                 {"Test Movie: Now Showing"},
+		"/api/v1/movies/now-showing?city_id=" + strconv.Itoa(puneID): {"Test Movie: Now Showing"},
+		"/api/v1/movies/now-showing?city_id=999999":                  nil,
+		"/api/v1/movies/coming-soon":                                 {"Future Movie: Upcoming"},
+		"/api/v1/movies/coming-soon?city_id=999999":                  {"Future Movie: Upcoming"},
+		"/api/v1/movies?status=not+scheduled&city_id=999999":         {"Test Movie: Not Scheduled"},
+	} {
+		if got := list(path); fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%s: got %v, want %v", path, got, want)
+		}
+	}
+}
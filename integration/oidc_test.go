@@ -0,0 +1,108 @@
+package integration
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/testharness"
+)
+
+func TestOIDCLogin(t *testing.T) {
+	idp := testharness.NewOIDCProvider(t)
+	h := testharness.New(t, idp.Configure)
+
+	aliceID, aliceToken := h.Register("alice", "alice@example.com", "secret123")
+
+	// login starts the flow and returns the provider URL and the state cookie
+	login := func() (string, *http.Cookie) {
+		w := h.Do(testharness.Request{Method: http.MethodGet, Path: "/api/v1/auth/oidc/login"})
+		h.Expect(w, http.StatusFound)
+		for _, cookie := range w.Result().Cookies() {
+			if cookie.Name == "oidc_state" {
+				return w.Header().Get("Location"), cookie
+			}
+		}
+		t.Fatal("login set no state cookie")
+		return "", nil
+	}
+	callback := func(state, code string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		query := url.Values{"state": {state}, "code": {code}}
+		return h.Do(testharness.Request{Method: http.MethodGet, Path: "/api/v1/auth/oidc/callback?" + query.Encode(),
+			Header: http.Header{"Cookie": {cookie.String()}}})
+	}
+	alice := testharness.OIDCUser{Subject: "alice-sub", Email: "alice@example.com", EmailVerified: true, Name: "Alice"}
+
+	// a code only redeems with the verifier of the login it was issued to
+	first, _ := login()
+	firstCode, firstState := idp.Authorize(t, first, alice)
+	second, secondCookie := login()
+	_, secondState := idp.Authorize(t, second, alice)
+	h.Expect(callback(firstState, firstCode, secondCookie), http.StatusBadRequest)
+	h.Expect(callback(secondState, firstCode, secondCookie), http.StatusUnauthorized)
+
+	authURL, cookie := login()
+	code, state := idp.Authorize(t, authURL, testharness.OIDCUser{Subject: "alice-sub", Email: "alice@example.com", EmailVerified: true, Nonce: "replayed"})
+	h.Expect(callback(state, code, cookie), http.StatusUnauthorized)
+
+	// the verified email links the identity to the existing account
+	authURL, cookie = login()
+	code, state = idp.Authorize(t, authURL, alice)
+	w := callback(state, code, cookie)
+	h.Expect(w, http.StatusFound)
+	if w.Header().Get("Location") != h.Config.URLs.FrontendBaseURL+"/" {
+		t.Errorf("redirected to %q", w.Header().Get("Location"))
+	}
+	var identity models.UserIdentity
+	if err := h.DB.Where("issuer = ? AND subject = ?", idp.URL, "alice-sub").First(&identity).Error; err != nil {
+		t.Fatalf("loading the identity: %v", err)
+	}
+	if identity.UserID != aliceID {
+		t.Errorf("identity linked to user %d, want %d", identity.UserID, aliceID)
+	}
+
+	// whoever registered the address loses the password and the sessions
+	h.Expect(h.Do(testharness.Request{Method: http.MethodGet, Path: "/api/v1/profile", Token: aliceToken}), http.StatusUnauthorized)
+	h.Expect(h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/login", JSON: map[string]string{
+		"email": "alice@example.com", "password": "secret123",
+	}}), http.StatusUnauthorized)
+
+	// signing in again finds the linked identity instead of adding another
+	authURL, cookie = login()
+	code, state = idp.Authorize(t, authURL, alice)
+	h.Expect(callback(state, code, cookie), http.StatusFound)
+	var identities int64
+	h.DB.Model(&models.UserIdentity{}).Where("user_id = ?", aliceID).Count(&identities)
+	if identities != 1 {
+		t.Errorf("alice has %d identities, want 1", identities)
+	}
+
+	// a locked account stays locked for the identity provider too
+	if err := h.DB.Model(&models.User{}).Where("user_id = ?", aliceID).
+		Update("locked_until", time.Now().Add(time.Hour)).Error; err != nil {
+		t.Fatal(err)
+	}
+	authURL, cookie = login()
+	code, state = idp.Authorize(t, authURL, alice)
+	h.Expect(callback(state, code, cookie), http.StatusUnauthorized)
+
+	// unverified emails are not linked to anyone
+	authURL, cookie = login()
+	code, state = idp.Authorize(t, authURL, testharness.OIDCUser{Subject: "mallory-sub", Email: "alice@example.com"})
+	h.Expect(callback(state, code, cookie), http.StatusConflict)
+
+	// a new verified identity gets an account of its own
+	authURL, cookie = login()
+	code, state = idp.Authorize(t, authURL, testharness.OIDCUser{Subject: "bob-sub", Email: "bob@example.com", EmailVerified: true, Name: "alice"})
+	h.Expect(callback(state, code, cookie), http.StatusFound)
+	var bob models.User
+	if err := h.DB.Where("email = ?", "bob@example.com").First(&bob).Error; err != nil {
+		t.Fatalf("loading the new account: %v", err)
+	}
+	if bob.Name != "alice 2" {
+		t.Errorf("new account named %q", bob.Name)
+	}
+}
@@ -0,0 +1,148 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/controllers"
+	"backend/models"
+	"backend/testharness"
+)
+
+var txnIDPattern = regexp.MustCompile(`name="txnid" value="([^"]+)"`)
+
+// initiatePayment starts a payment for two seats and returns the transaction id.
+func initiatePayment(h *testharness.Harness, token string, showID uint) string {
+	h.T.Helper()
+
+	w := h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/payment/initiate", Token: token, JSON: map[string]interface{}{
+		"amount":  250,
+		"show_id": showID,
+		"seats":   "A1,A2",
+	}})
+	h.Expect(w, http.StatusOK)
+
+	match := txnIDPattern.FindStringSubmatch(w.Body.String())
+	if match == nil {
+		h.T.Fatalf("no transaction id in payment form: %s", w.Body.String())
+	}
+	return match[1]
+}
+
+// callbackForm builds the form PayU posts back, signed with the merchant salt.
+func callbackForm(h *testharness.Harness, txnID, status string) url.Values {
+	form := url.Values{
+		"txnid":       {txnID},
+		"status":      {status},
+		"email":       {"erin@example.com"},
+		"firstname":   {"erin"},
+		"productinfo": {"MovieTickets"},
+		"amount":      {"250.00"},
+	}
+	hashParts := []string{h.Config.PayU.MerchantSalt, status, "", "", "", "", "", "", "", "", "", "",
+		form.Get("email"), form.Get("firstname"), form.Get("productinfo"), form.Get("amount"), txnID, h.Config.PayU.MerchantKey}
+	form.Set("hash", controllers.GenerateHash(strings.Join(hashParts, "|")))
+	return form
+}
+
+func bookingStatus(h *testharness.Harness, txnID string) string {
+	h.T.Helper()
+	var booking models.Booking
+	if err := h.DB.Where("txn_id = ?", txnID).First(&booking).Error; err != nil {
+		h.T.Fatalf("loading booking %s: %v", txnID, err)
+	}
+	return booking.Status
+}
+
+func TestPaymentSuccessFlow(t *testing.T) {
+	h := testharness.New(t)
+
+	_, token := h.Register("erin", "erin@example.com", "secret123")
+	show := h.Shows()[0]
+
+	txnID := initiatePayment(h, token, show.ShowID)
+	if status := bookingStatus(h, txnID); status != "pending" {
+		t.Fatalf("expected pending booking, got %q", status)
+	}
+
+	w := h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/payment/success", Form: callbackForm(h, txnID, "success")})
+	h.Expect(w, http.StatusFound)
+	if want := fmt.Sprintf("%s/payment-success?txnid=%s", h.Config.URLs.FrontendBaseURL, txnID); w.Header().Get("Location") != want {
+		t.Fatalf("expected redirect to %s, got %s", want, w.Header().Get("Location"))
+	}
+	if status := bookingStatus(h, txnID); status != "success" {
+		t.Fatalf("expected successful booking, got %q", status)
+	}
+
+	w = h.Do(testharness.Request{Method: http.MethodGet, Path: "/api/v1/booking/" + txnID})
+	h.Expect(w, http.StatusOK)
+	var details models.BookingDetailsResponse
+	h.Decode(w, &details)
+	if details.Movie != "Test Movie" || details.Theatre != "Test Cinema" || len(details.Seats) != 2 {
+		t.Fatalf("unexpected booking details %+v", details)
+	}
+}
+
+func TestPaymentCallbackRejectsForgedHash(t *testing.T) {
+	h := testharness.New(t)
+
+	_, token := h.Register("erin", "erin@example.com", "secret123")
+	txnID := initiatePayment(h, token, h.Shows()[0].ShowID)
+
+	form := callbackForm(h, txnID, "success")
+	form.Set("hash", "forged")
+	h.Expect(h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/payment/success", Form: form}), http.StatusBadRequest)
+
+	if status := bookingStatus(h, txnID); status != "pending" {
+		t.Fatalf("forged callback changed booking to %q", status)
+	}
+}
+
+func TestPaymentFailureFlow(t *testing.T) {
+	h := testharness.New(t)
+
+	_, token := h.Register("erin", "erin@example.com", "secret123")
+	txnID := initiatePayment(h, token, h.Shows()[0].ShowID)
+
+	w := h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/payment/failure", Form: callbackForm(h, txnID, "failure")})
+	h.Expect(w, http.StatusFound)
+	if status := bookingStatus(h, txnID); status != "failed" {
+		t.Fatalf("expected failed booking, got %q", status)
+	}
+}
+
+func TestAdultOnlyShows(t *testing.T) {
+	h := testharness.New(t)
+
+	show := h.Shows()[0]
+	if err := h.DB.Model(&models.Movie{}).Where("movie_id = ?", show.MovieID).Update("certification", models.CertificationA).Error; err != nil {
+		t.Fatalf("rating the movie: %v", err)
+	}
+	_, token := h.Register("erin", "erin@example.com", "secret123")
+	request := testharness.Request{Method: http.MethodPost, Path: "/api/v1/payment/initiate", Token: token, JSON: map[string]interface{}{
+		"amount": 250, "show_id": show.ShowID, "seats": "A1,A2",
+	}}
+
+	h.Expect(h.Do(request), http.StatusForbidden)
+
+	underage := show.Date.AddDate(-models.AdultAge, 0, 1).Format(time.DateOnly)
+	h.Expect(h.Do(testharness.Request{Method: http.MethodPut, Path: "/api/v1/profile", Token: token, JSON: map[string]string{"date_of_birth": underage}}), http.StatusOK)
+	h.Expect(h.Do(request), http.StatusForbidden)
+
+	adult := show.Date.AddDate(-models.AdultAge, 0, 0).Format(time.DateOnly)
+	h.Expect(h.Do(testharness.Request{Method: http.MethodPut, Path: "/api/v1/profile", Token: token, JSON: map[string]string{"date_of_birth": adult}}), http.StatusOK)
+	txnID := initiatePayment(h, token, show.ShowID)
+
+	w := h.Do(testharness.Request{Method: http.MethodGet, Path: "/api/v1/booking/" + txnID})
+	h.Expect(w, http.StatusOK)
+	var ticket models.BookingDetailsResponse
+	h.Decode(w, &ticket)
+	if !ticket.IDCheckRequired || ticket.Certification != models.CertificationA {
+		t.Errorf("ticket %+v", ticket)
+	}
+}
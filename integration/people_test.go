@@ -0,0 +1,74 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"backend/controllers"
+	"backend/models"
+	"backend/testharness"
+)
+
+func TestCreditsAndFilmography(t *testing.T) {
+	h := testharness.New(t)
+
+	movie := h.Movie("Test Movie")
+	_, token := h.Admin("curator", "curator@example.com", "Password123!")
+
+	anonymous := h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/people", JSON: map[string]interface{}{
+		"name": "Credit Person",
+	}})
+	h.Expect(anonymous, http.StatusUnauthorized)
+
+	w := h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/people", Token: token, JSON: map[string]interface{}{
+		"name": "Credit Person",
+	}})
+	h.Expect(w, http.StatusCreated)
+	var person models.Person
+	h.Decode(w, &person)
+
+	credits := fmt.Sprintf("/api/v1/movies/%d/credits", movie.MovieID)
+	for _, body := range []map[string]interface{}{
+		{"person_id": person.PersonID, "role": "actor", "character": "Lead", "billing_order": 1},
+		{"person_id": person.PersonID, "role": "director"},
+	} {
+		h.Expect(h.Do(testharness.Request{Method: http.MethodPost, Path: credits, Token: token, JSON: body}), http.StatusCreated)
+	}
+
+	w = h.Do(testharness.Request{Method: http.MethodPost, Path: credits, Token: token, JSON: map[string]interface{}{
+		"person_id": person.PersonID, "role": "director",
+	}})
+	h.Expect(w, http.StatusConflict)
+	w = h.Do(testharness.Request{Method: http.MethodPost, Path: credits, Token: token, JSON: map[string]interface{}{
+		"person_id": person.PersonID, "role": "crew",
+	}})
+	h.Expect(w, http.StatusBadRequest)
+
+	w = h.Do(testharness.Request{Method: http.MethodGet, Path: credits})
+	h.Expect(w, http.StatusOK)
+	var movieCredits controllers.MovieCredits
+	h.Decode(w, &movieCredits)
+	if len(movieCredits.Cast) != 1 || movieCredits.Cast[0].Character != "Lead" || movieCredits.Cast[0].Name != "Credit Person" {
+		t.Errorf("cast %+v", movieCredits.Cast)
+	}
+	if len(movieCredits.Crew) != 1 || movieCredits.Crew[0].Role != models.CreditRoleDirector {
+		t.Errorf("crew %+v", movieCredits.Crew)
+	}
+
+	w = h.Do(testharness.Request{Method: http.MethodGet, Path: fmt.Sprintf("/api/v1/people/%d/filmography", person.PersonID)})
+	h.Expect(w, http.StatusOK)
+	var filmography controllers.Filmography
+	h.Decode(w, &filmography)
+	if len(filmography.Credits) != 2 || filmography.Credits[0].Movie.MovieName != "Test Movie" {
+		t.Errorf("filmography %+v", filmography.Credits)
+	}
+
+	h.Expect(h.Do(testharness.Request{Method: http.MethodDelete, Path: fmt.Sprintf("/api/v1/people/%d", person.PersonID), Token: token}), http.StatusOK)
+	w = h.Do(testharness.Request{Method: http.MethodGet, Path: credits})
+	h.Expect(w, http.StatusOK)
+	h.Decode(w, &movieCredits)
+	if len(movieCredits.Cast)+len(movieCredits.Crew) != 0 {
+		t.Errorf("credits left after deleting the person: %+v", movieCredits)
+	}
+}
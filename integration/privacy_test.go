@@ -0,0 +1,71 @@
+package integration
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/testharness"
+
+	"gorm.io/datatypes"
+)
+
+func TestDeleteAccountScrubsPaymentsAndReviews(t *testing.T) {
+	h := testharness.New(t)
+
+	userID, token := h.Register("grace", "grace@example.com", "secret123")
+	show := h.Shows()[0]
+	booking := models.Booking{UserID: userID, ShowID: show.ShowID, TxnID: "txn-grace", Amount: 200, Status: "confirmed"}
+	if err := h.DB.Create(&booking).Error; err != nil {
+		t.Fatal(err)
+	}
+	payment := models.Payment{BookingID: booking.BookingID, Amount: 200, Status: "success",
+		PaymentResponse: datatypes.JSON(`{"email":"grace@example.com","firstname":"Grace","phone":"9999999999"}`)}
+	if err := h.DB.Create(&payment).Error; err != nil {
+		t.Fatal(err)
+	}
+	review := models.Review{UserID: uint(userID), MovieID: uint(show.MovieID), Rating: 4, Comments: "Watched it with my sister Ada"}
+	if err := h.DB.Create(&review).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	h.Expect(h.Do(testharness.Request{Method: http.MethodDelete, Path: "/api/v1/profile", Token: token,
+		JSON: map[string]interface{}{"confirm": true, "password": "secret123"}}), http.StatusOK)
+
+	if err := h.DB.First(&payment, payment.PaymentID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if len(payment.PaymentResponse) != 0 {
+		t.Errorf("payment response kept: %s", payment.PaymentResponse)
+	}
+	if err := h.DB.First(&review, review.ReviewID).Error; err != nil {
+		t.Fatal(err)
+	}
+	if review.Comments != "" || review.Rating != 4 {
+		t.Errorf("review not scrubbed: %+v", review)
+	}
+}
+
+func TestDeleteLinkedAccountNeedsFreshSession(t *testing.T) {
+	h := testharness.New(t)
+
+	userID, token := h.Register("heidi", "heidi@example.com", "secret123")
+	identity := models.UserIdentity{UserID: userID, Issuer: "https://idp.example.com", Subject: "heidi-sub", Email: "heidi@example.com"}
+	if err := h.DB.Create(&identity).Error; err != nil {
+		t.Fatal(err)
+	}
+	deleteAccount := testharness.Request{Method: http.MethodDelete, Path: "/api/v1/profile", Token: token,
+		JSON: map[string]interface{}{"confirm": true}}
+
+	stale := time.Now().Add(-time.Hour)
+	if err := h.DB.Model(&models.Session{}).Where("user_id = ?", userID).Update("created_at", stale).Error; err != nil {
+		t.Fatal(err)
+	}
+	h.Expect(h.Do(deleteAccount), http.StatusUnauthorized)
+
+	if err := h.DB.Model(&models.Session{}).Where("user_id = ?", userID).Update("created_at", time.Now()).Error; err != nil {
+		t.Fatal(err)
+	}
+	h.Expect(h.Do(deleteAccount), http.StatusOK)
+}
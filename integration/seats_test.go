@@ -0,0 +1,67 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+
+	"backend/models"
+	"backend/testharness"
+)
+
+func TestConcurrentSeatBookingHasOneWinner(t *testing.T) {
+	h := testharness.New(t)
+
+	userID, _ := h.Register("dave", "dave@example.com", "secret123")
+	show := h.Shows()[0]
+
+	const attempts = 20
+	codes := make(chan int, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/seats/book", JSON: map[string]interface{}{
+				"show_id": show.ShowID,
+				"seat":    "A1",
+				"user_id": userID,
+			}})
+			codes <- w.Code
+		}()
+	}
+	wg.Wait()
+	close(codes)
+
+	created, conflicts := 0, 0
+	for code := range codes {
+		switch code {
+		case http.StatusCreated:
+			created++
+		case http.StatusConflict:
+			conflicts++
+		default:
+			t.Errorf("unexpected status %d", code)
+		}
+	}
+	if created != 1 || conflicts != attempts-1 {
+		t.Fatalf("expected 1 booking and %d conflicts, got %d and %d", attempts-1, created, conflicts)
+	}
+
+	var count int64
+	h.DB.Model(&models.SeatBooking{}).Where("show_id = ? AND seat = ?", show.ShowID, "A1").Count(&count)
+	if count != 1 {
+		t.Fatalf("expected one stored booking, got %d", count)
+	}
+
+	w := h.Do(testharness.Request{Method: http.MethodGet, Path: fmt.Sprintf("/api/v1/seats/show/%d", show.ShowID)})
+	h.Expect(w, http.StatusOK)
+	var booked struct {
+		BookedSeats []string `json:"bookedSeats"`
+	}
+	h.Decode(w, &booked)
+	if len(booked.BookedSeats) != 1 || booked.BookedSeats[0] != "A1" {
+		t.Fatalf("unexpected booked seats %v", booked.BookedSeats)
+	}
+}
@@ -0,0 +1,240 @@
+package integration
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"backend/controllers"
+	"backend/models"
+	"backend/repository"
+	"backend/testharness"
+)
+
+func TestCreateShow(t *testing.T) {
+	h := testharness.New(t)
+
+	movie := h.Movie("Test Movie")
+	theatre := h.Theatre("Test Cinema")
+	date := time.Now().AddDate(0, 0, 2).Format("2006-01-02")
+
+	w := h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/shows", JSON: map[string]interface{}{
+		"movie_id":   movie.MovieID,
+		"theatre_id": theatre.TheatreID,
+		"date":       date,
+		"languages":  []string{"Hindi"},
+		"times": []map[string]string{
+			{"start_time": "10:00", "end_time": "12:15"},
+			{"start_time": "13:00", "end_time": "15:15"},
+		},
+	}})
+	h.Expect(w, http.StatusCreated)
+
+	var created []models.Show
+	h.Decode(w, &created)
+	if len(created) != 2 {
+		t.Fatalf("expected 2 shows, got %d", len(created))
+	}
+
+	w = h.Do(testharness.Request{Method: http.MethodGet, Path: fmt.Sprintf("/api/v1/shows/%d", created[0].ShowID)})
+	h.Expect(w, http.StatusOK)
+	var show models.Show
+	h.Decode(w, &show)
+	if show.Movie.MovieName != "Test Movie" || show.Theatre.TheatreName != "Test Cinema" {
+		t.Fatalf("show not loaded with its movie and theatre: %+v", show)
+	}
+}
+
+func TestCreateShowValidatesReferences(t *testing.T) {
+	h := testharness.New(t)
+
+	movie := h.Movie("Test Movie")
+	theatre := h.Theatre("Test Cinema")
+
+	cases := map[string]map[string]interface{}{
+		"unknown movie":   {"movie_id": 999999, "theatre_id": theatre.TheatreID},
+		"unknown theatre": {"movie_id": movie.MovieID, "theatre_id": 999999},
+		"foreign screen":  {"movie_id": movie.MovieID, "theatre_id": theatre.TheatreID, "screen_id": 999999},
+	}
+	for name, body := range cases {
+		body["date"] = "2030-01-01"
+		body["languages"] = []string{"Hindi"}
+		body["times"] = []map[string]string{{"start_time": "10:00", "end_time": "12:00"}}
+
+		w := h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/shows", JSON: body})
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d: %s", name, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestCityShowtimes(t *testing.T) {
+	h := testharness.New(t)
+
+	theatre := h.Theatre("Test Cinema")
+	shows := h.Shows()
+	if err := h.DB.Create(&models.SeatBooking{ShowID: shows[0].ShowID, Seat: "A1"}).Error; err != nil {
+		t.Fatalf("booking seat: %v", err)
+	}
+
+	tomorrow := repository.Day(time.Now()).AddDate(0, 0, 1).Format(time.DateOnly)
+	path := fmt.Sprintf("/api/v1/cities/%d/showtimes?date=%s", theatre.CityID, tomorrow)
+	w := h.Do(testharness.Request{Method: http.MethodGet, Path: path})
+	h.Expect(w, http.StatusOK)
+	var listing controllers.CityShowtimes
+	h.Decode(w, &listing)
+
+	if len(listing.Movies) != 1 || len(listing.Movies[0].Theatres) != 1 {
+		t.Fatalf("expected one movie in one theatre, got %+v", listing.Movies)
+	}
+	showtimes := listing.Movies[0].Theatres[0].Showtimes
+	if len(showtimes) != 2 {
+		t.Fatalf("expected two showtimes, got %+v", showtimes)
+	}
+	first := showtimes[0]
+	if first.StartTime != "18:00" || first.ScreenName != "Screen 1" || first.TotalSeats != 10 || first.RemainingSeats != 9 {
+		t.Errorf("unexpected first showtime %+v", first)
+	}
+	if showtimes[1].StartTime != "21:00" || showtimes[1].RemainingSeats != 10 {
+		t.Errorf("unexpected second showtime %+v", showtimes[1])
+	}
+
+	w = h.Do(testharness.Request{Method: http.MethodGet, Path: path + "&language=english"})
+	h.Expect(w, http.StatusOK)
+	h.Decode(w, &listing)
+	if len(listing.Movies) != 1 || len(listing.Movies[0].Theatres[0].Showtimes) != 1 {
+		t.Errorf("language filter: got %+v", listing.Movies)
+	}
+
+	w = h.Do(testharness.Request{Method: http.MethodGet, Path: fmt.Sprintf("/api/v1/cities/%d/showtimes", theatre.CityID)})
+	h.Expect(w, http.StatusOK)
+	h.Decode(w, &listing)
+	if len(listing.Movies) != 0 {
+		t.Errorf("expected nothing today, got %+v", listing.Movies)
+	}
+}
+
+func TestShowScheduleConflicts(t *testing.T) {
+	h := testharness.New(t)
+
+	movie := h.Movie("Test Movie")
+	theatre := h.Theatre("Test Cinema")
+	seeded := h.Shows()[0]
+	date := seeded.Date.Format(time.DateOnly)
+
+	schedule := func(query, date string, times ...string) *httptest.ResponseRecorder {
+		body := map[string]interface{}{
+			"movie_id": movie.MovieID, "theatre_id": theatre.TheatreID, "screen_id": seeded.ScreenID,
+			"date": date, "languages": []string{"Hindi"},
+		}
+		var entries []map[string]string
+		for i := 0; i < len(times); i += 2 {
+			entries = append(entries, map[string]string{"start_time": times[i], "end_time": times[i+1]})
+		}
+		body["times"] = entries
+		return h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/shows" + query, JSON: body})
+	}
+
+	// the seeded show runs 18:00 to 20:15, the cleaning buffer is 15 minutes
+	w := schedule("?dry_run=true", date, "20:20", "22:30", "10:00", "11:00")
+	h.Expect(w, http.StatusOK)
+	var check controllers.ScheduleCheck
+	h.Decode(w, &check)
+	if check.OK || len(check.Conflicts) != 2 {
+		t.Fatalf("dry run %+v", check.Conflicts)
+	}
+	if overlap := check.Conflicts[1]; overlap.Reason != controllers.ConflictOverlap || overlap.ShowID == nil || *overlap.ShowID != seeded.ShowID {
+		t.Errorf("overlap %+v", overlap)
+	}
+	if short := check.Conflicts[0]; short.Reason != controllers.ConflictTooShort || short.Field != "times[1]" {
+		t.Errorf("too short %+v", short)
+	}
+
+	h.Expect(schedule("", date, "20:20", "22:30"), http.StatusConflict)
+	h.Expect(schedule("", "2001-01-01", "10:00", "12:00"), http.StatusBadRequest)
+	h.Expect(schedule("", date, "20:30", "22:30", "22:45", "00:45"), http.StatusCreated)
+	if len(h.Shows()) != 4 {
+		t.Errorf("expected the two new shows only, got %d shows", len(h.Shows()))
+	}
+}
+
+func TestComputedShowEndTimes(t *testing.T) {
+	h := testharness.New(t)
+
+	movie := h.Movie("Test Movie")
+	theatre := h.Theatre("Test Cinema")
+	seeded := h.Shows()[0]
+
+	w := h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/shows", JSON: map[string]interface{}{
+		"movie_id": movie.MovieID, "theatre_id": theatre.TheatreID, "screen_id": seeded.ScreenID,
+		"date": seeded.Date.Format(time.DateOnly), "languages": []string{"Hindi"},
+		"times": []map[string]string{{"start_time": "09:00"}, {"start_time": "23:00"}},
+	}})
+	h.Expect(w, http.StatusCreated)
+	var created []models.Show
+	h.Decode(w, &created)
+	// 120 minutes plus 20 of padding, the late show ends the next day
+	for i, want := range []string{"11:20", "01:20"} {
+		if got := created[i].EndTime.Format("15:04"); got != want || !created[i].EndTimeComputed {
+			t.Errorf("show %d ends at %s, computed %v, want %s", i, got, created[i].EndTimeComputed, want)
+		}
+	}
+
+	w = h.Do(testharness.Request{Method: http.MethodPatch, Path: fmt.Sprintf("/api/v1/movies/%d", movie.MovieID), JSON: map[string]int{"duration": 150}})
+	h.Expect(w, http.StatusOK)
+
+	ends := map[uint]string{}
+	for _, show := range h.Shows() {
+		ends[show.ShowID] = show.EndTime.Format("15:04")
+	}
+	if ends[created[0].ShowID] != "11:50" || ends[created[1].ShowID] != "01:50" {
+		t.Errorf("computed end times did not follow the runtime: %v", ends)
+	}
+	if ends[seeded.ShowID] != seeded.EndTime.Format("15:04") {
+		t.Errorf("typed end time changed to %s", ends[seeded.ShowID])
+	}
+}
+
+func TestShowInstants(t *testing.T) {
+	h := testharness.New(t)
+
+	movie := h.Movie("Test Movie")
+	theatre := h.Theatre("Test Cinema")
+	seeded := h.Shows()[0]
+
+	// the seeded city takes the default time zone
+	kolkata, err := time.LoadLocation(models.DefaultTimezone)
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := seeded.Date.UTC()
+	if want := time.Date(day.Year(), day.Month(), day.Day(), 18, 0, 0, 0, kolkata); !seeded.StartsAt.Equal(want) || seeded.TimeZone != models.DefaultTimezone {
+		t.Errorf("seeded show starts at %s in %q, want %s", seeded.StartsAt, seeded.TimeZone, want)
+	}
+
+	w := h.Do(testharness.Request{Method: http.MethodPost, Path: "/api/v1/shows", JSON: map[string]interface{}{
+		"movie_id": movie.MovieID, "theatre_id": theatre.TheatreID, "screen_id": seeded.ScreenID,
+		"date": day.Format(time.DateOnly), "languages": []string{"Hindi"},
+		"times": []map[string]string{{"start_time": "23:00", "end_time": "01:30"}},
+	}})
+	h.Expect(w, http.StatusCreated)
+	var created []models.Show
+	h.Decode(w, &created)
+	late := created[0]
+	if late.EndsAt.Sub(late.StartsAt) != 150*time.Minute {
+		t.Errorf("overnight show runs from %s to %s", late.StartsAt, late.EndsAt)
+	}
+	if late.StartTime.Format("15:04") != "23:00" || late.EndTime.Format("15:04") != "01:30" || !late.Date.Equal(seeded.Date) {
+		t.Errorf("legacy fields %s %s %s", late.Date, late.StartTime, late.EndTime)
+	}
+
+	var shows []models.Show
+	if err := h.DB.Order("starts_at").Find(&shows).Error; err != nil {
+		t.Fatal(err)
+	}
+	if last := shows[len(shows)-1]; last.ShowID != late.ShowID {
+		t.Errorf("expected the overnight show last, got show %d", last.ShowID)
+	}
+}
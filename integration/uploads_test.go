@@ -0,0 +1,77 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"strings"
+	"testing"
+
+	"backend/controllers"
+	"backend/models"
+	"backend/testharness"
+)
+
+func TestImageUploads(t *testing.T) {
+	h := testharness.New(t)
+
+	userID, token := h.Register("uploader", "uploader@example.com", "Password123!")
+	if err := h.DB.Model(&models.User{}).Where("user_id = ?", userID).Update("is_admin", true).Error; err != nil {
+		t.Fatalf("promoting user: %v", err)
+	}
+
+	var poster bytes.Buffer
+	if err := png.Encode(&poster, image.NewGray(image.Rect(0, 0, 500, 750))); err != nil {
+		t.Fatal(err)
+	}
+
+	movie := h.Movie("Test Movie")
+	posterPath := fmt.Sprintf("/api/v1/movies/%d/poster", movie.MovieID)
+	upload := func(path string, data []byte) testharness.Request {
+		return testharness.Request{Method: http.MethodPost, Path: path, Token: token, Files: map[string][]byte{"image": data}}
+	}
+
+	h.Expect(h.Do(testharness.Request{Method: http.MethodPost, Path: posterPath, Files: map[string][]byte{"image": poster.Bytes()}}), http.StatusUnauthorized)
+	h.Expect(h.Do(upload(posterPath, []byte("not an image"))), http.StatusUnsupportedMediaType)
+	h.Expect(h.Do(upload(posterPath, make([]byte, h.Config.Storage.MaxUploadSize+1))), http.StatusRequestEntityTooLarge)
+
+	w := h.Do(upload(posterPath, poster.Bytes()))
+	h.Expect(w, http.StatusOK)
+	var result controllers.ImageUpload
+	h.Decode(w, &result)
+	if len(result.Thumbnails) != 3 || result.Thumbnails["w185"] == "" {
+		t.Fatalf("upload %+v", result)
+	}
+
+	movie = h.Movie("Test Movie")
+	var thumbnails map[string]string
+	json.Unmarshal(movie.PosterThumbnails, &thumbnails)
+	if movie.PosterURL != result.URL || thumbnails["w342"] != result.Thumbnails["w342"] {
+		t.Errorf("stored poster %q %s", movie.PosterURL, movie.PosterThumbnails)
+	}
+
+	mediaPath := strings.TrimPrefix(result.Thumbnails["w185"], h.Config.URLs.BaseURL)
+	w = h.Do(testharness.Request{Method: http.MethodGet, Path: mediaPath})
+	h.Expect(w, http.StatusOK)
+	if !strings.Contains(w.Header().Get("Cache-Control"), "immutable") || w.Header().Get("Content-Type") != "image/jpeg" {
+		t.Errorf("media headers %v", w.Header())
+	}
+	thumbnail, _, err := image.DecodeConfig(w.Body)
+	if err != nil || thumbnail.Width != 185 || thumbnail.Height != 278 {
+		t.Errorf("thumbnail %+v: %v", thumbnail, err)
+	}
+	w = h.Do(testharness.Request{Method: http.MethodGet, Path: mediaPath, Header: http.Header{"If-None-Match": {w.Header().Get("ETag")}}})
+	h.Expect(w, http.StatusNotModified)
+	h.Expect(h.Do(testharness.Request{Method: http.MethodGet, Path: "/media/movies/0/missing.jpg"}), http.StatusNotFound)
+
+	theatre := h.Theatre("Test Cinema")
+	w = h.Do(upload(fmt.Sprintf("/api/v1/theatres/%d/image", theatre.TheatreID), poster.Bytes()))
+	h.Expect(w, http.StatusOK)
+	h.Decode(w, &result)
+	if h.Theatre("Test Cinema").TheatreImage != result.URL || result.Thumbnails["w1280"] == "" {
+		t.Errorf("theatre image %+v", result)
+	}
+}
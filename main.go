@@ -1,122 +1,81 @@
 package main
 
 import (
+	"context"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 
-	"github.com/gin-contrib/cors"
-	"github.com/gin-gonic/gin"
-
-	"backend/controllers"
-	"backend/middlewares"
+	"backend/config"
 	"backend/models"
+	"backend/repository/postgres"
+	"backend/routes"
 )
 
 func main() {
-	models.ConnectDatabase()
-
-	router := gin.Default()
-
-	router.POST("/api/payment/success", controllers.PaymentSuccessHandler)
-	router.POST("/api/payment/failure", controllers.PaymentFailureHandler)
-
-	router.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
-		AllowCredentials: true,
-	}))
-
-	router.Use(func(c *gin.Context) {
-		c.Set("db", models.DB)
-		c.Next()
-	})
-
-	router.POST("/register", controllers.Register)
-	router.POST("/login", controllers.Login)
-	router.GET("/me", middlewares.AuthMiddleware(), controllers.Me)
-	router.GET("/api/booking/:txnid", controllers.GetBookingDetails)
-
-	protected := router.Group("/api")
-	protected.Use(middlewares.AuthMiddleware())
-	{
-		protected.GET("/profile", func(c *gin.Context) {
-			user, _ := c.Get("user")
-			c.JSON(200, gin.H{"user": user})
-		})
-
-		// Payment initiation (requires authentication)
-		protected.POST("/payment/initiate", controllers.InitiatePayment)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			runMigrate(os.Args[2:])
+			return
+		case "seed":
+			runSeed(os.Args[2:])
+			return
+		case "import":
+			runImport(os.Args[2:])
+			return
+		case "serve":
+		default:
+			log.Fatalf("unknown command %q, expected serve, migrate, seed or import", os.Args[1])
+		}
 	}
 
-	movieRoutes := router.Group("/movies")
-	{
-		movieRoutes.POST("", controllers.CreateMovie)
-		movieRoutes.GET("", controllers.GetMovies)
-		movieRoutes.GET("/:id", controllers.GetMovieByID)
-		movieRoutes.PUT("/:id", controllers.UpdateMovie)
-		movieRoutes.DELETE("/:id", controllers.DeleteMovie)
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	theatreRoutes := router.Group("/theatres")
-	{
-		theatreRoutes.POST("", controllers.CreateTheatre)
-		theatreRoutes.GET("", controllers.GetTheatres)
-		theatreRoutes.GET("/:id", controllers.GetTheatreByID)
-		theatreRoutes.PUT("/:id", controllers.UpdateTheatre)
-		theatreRoutes.DELETE("/:id", controllers.DeleteTheatre)
-	}
+	db := models.ConnectDatabase(cfg.Database)
 
-	showRoutes := router.Group("/shows")
-	{
-		showRoutes.POST("", controllers.CreateShow)
-		showRoutes.GET("", controllers.GetShows)
-		showRoutes.GET("/:id", controllers.GetShowByID)
-		showRoutes.PUT("/:id", controllers.UpdateShow)
-		showRoutes.DELETE("/:id", controllers.DeleteShow)
-	}
+	router := routes.New(cfg, db, postgres.New(db))
 
-	reviewRoutes := router.Group("/reviews")
-	{
-		reviewRoutes.POST("", controllers.CreateReview)
-		reviewRoutes.GET("", controllers.GetReviews)
-		reviewRoutes.GET("/:id", controllers.GetReviewByID)
-		reviewRoutes.PUT("/:id", controllers.UpdateReview)
-		reviewRoutes.DELETE("/:id", controllers.DeleteReview)
+	server := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           router,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
 	}
 
-	stateRoutes := router.Group("/states")
-	{
-		stateRoutes.POST("", controllers.CreateState)
-		stateRoutes.GET("", controllers.GetStates)
-		stateRoutes.GET("/:id", controllers.GetStateByID)
-		stateRoutes.PUT("/:id", controllers.UpdateState)
-		stateRoutes.DELETE("/:id", controllers.DeleteState)
-	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
-	cityRoutes := router.Group("/cities")
-	{
-		cityRoutes.POST("", controllers.CreateCity)
-		cityRoutes.GET("", controllers.GetCities)
-		cityRoutes.GET("/:id", controllers.GetCityByID)
-		cityRoutes.PUT("/:id", controllers.UpdateCity)
-		cityRoutes.DELETE("/:id", controllers.DeleteCity)
-	}
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Server running on port %s", cfg.Server.Port)
+		serveErr <- server.ListenAndServe()
+	}()
 
-	seatRoutes := router.Group("/seats")
-	{
-		seatRoutes.GET("/show/:id", controllers.GetBookedSeats)
-		seatRoutes.POST("/book", controllers.BookSeat)
+	select {
+	case err := <-serveErr:
+		log.Fatalf("Failed to start server: %v", err)
+	case <-ctx.Done():
 	}
-
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	// a second signal kills the process the usual way
+	stop()
+
+	log.Printf("Shutting down, waiting up to %s for in-flight requests", cfg.Server.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Shutdown did not complete: %v", err)
 	}
 
-	log.Printf("Server running on port %s", port)
-	if err := router.Run(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+	if sqlDB, err := db.DB(); err == nil {
+		sqlDB.Close()
 	}
+	log.Println("Server stopped")
 }
@@ -0,0 +1,114 @@
+// Package media checks uploaded images and renders their thumbnails.
+package media
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"net/http"
+)
+
+// MaxPixels bounds the decoded size of an upload, a small file can still
+// decode to a huge bitmap.
+const MaxPixels = 40_000_000
+
+var (
+	ErrUnsupported = errors.New("not a JPEG, PNG or GIF image")
+	ErrTooLarge    = errors.New("image has too many pixels")
+)
+
+var extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+// Image is a decoded upload. Transparent areas are flattened onto white,
+// thumbnails are JPEGs.
+type Image struct {
+	// ContentType is sniffed from the data, whatever the client claimed.
+	ContentType string
+	Ext         string
+	Width       int
+	Height      int
+	pixels      *image.RGBA
+}
+
+// Decode checks that data is a JPEG, PNG or GIF of at most MaxPixels pixels
+// and decodes it. Only the first frame of an animated GIF is kept.
+func Decode(data []byte) (*Image, error) {
+	contentType := http.DetectContentType(data)
+	ext, ok := extensions[contentType]
+	if !ok {
+		return nil, ErrUnsupported
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || "image/"+format != contentType {
+		return nil, ErrUnsupported
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, ErrUnsupported
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	bounds := src.Bounds()
+	pixels := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(pixels, pixels.Rect, image.White, image.Point{}, draw.Src)
+	draw.Draw(pixels, pixels.Rect, src, bounds.Min, draw.Over)
+
+	return &Image{ContentType: contentType, Ext: ext, Width: bounds.Dx(), Height: bounds.Dy(), pixels: pixels}, nil
+}
+
+// Thumbnail scales the image down to width keeping its aspect ratio and
+// encodes it as a JPEG. Narrower images keep their size, they are never
+// scaled up.
+func (im *Image) Thumbnail(width int) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, shrink(im.pixels, width), &jpeg.Options{Quality: 85}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// shrink averages the source pixels that fall in each destination pixel, a
+// box filter, which is good enough for scaling down.
+func shrink(src *image.RGBA, width int) *image.RGBA {
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	if width >= sw {
+		return src
+	}
+	height := max(1, (sh*width+sw/2)/sw)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*sh/height, (y+1)*sh/height
+		for x := 0; x < width; x++ {
+			x0, x1 := x*sw/width, (x+1)*sw/width
+			var r, g, b, n int
+			for sy := y0; sy < y1; sy++ {
+				row := src.Pix[sy*src.Stride+x0*4 : sy*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r += int(row[i])
+					g += int(row[i+1])
+					b += int(row[i+2])
+					n++
+				}
+			}
+			o := dst.PixOffset(x, y)
+			dst.Pix[o], dst.Pix[o+1], dst.Pix[o+2], dst.Pix[o+3] = uint8(r/n), uint8(g/n), uint8(b/n), 0xff
+		}
+	}
+	return dst
+}
@@ -0,0 +1,134 @@
+package media
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encoded(t *testing.T, encode func(*bytes.Buffer, image.Image) error, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecode(t *testing.T) {
+	opaque := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	for i := range opaque.Pix {
+		opaque.Pix[i] = 0x80
+	}
+	// fully transparent, flattened onto white
+	transparent := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	paletted := image.NewPaletted(image.Rect(0, 0, 3, 5), color.Palette{color.Black, color.White})
+
+	pngData := encoded(t, func(w *bytes.Buffer, img image.Image) error { return png.Encode(w, img) }, transparent)
+	gifData := encoded(t, func(w *bytes.Buffer, img image.Image) error { return gif.Encode(w, img, nil) }, paletted)
+	jpegData := encoded(t, func(w *bytes.Buffer, img image.Image) error { return jpeg.Encode(w, img, nil) }, opaque)
+
+	// a GIF whose header claims 8000x8000 pixels, decoding stops at the header
+	hugeGIF := bytes.Clone(gifData)
+	hugeGIF[6], hugeGIF[7], hugeGIF[8], hugeGIF[9] = 0x40, 0x1f, 0x40, 0x1f
+
+	for _, tc := range []struct {
+		name          string
+		data          []byte
+		err           error
+		contentType   string
+		ext           string
+		width, height int
+	}{
+		{name: "png", data: pngData, contentType: "image/png", ext: ".png", width: 4, height: 2},
+		{name: "gif", data: gifData, contentType: "image/gif", ext: ".gif", width: 3, height: 5},
+		{name: "jpeg", data: jpegData, contentType: "image/jpeg", ext: ".jpg", width: 40, height: 30},
+		{name: "html sent as an image", data: []byte("<html><script>alert(1)</script></html>"), err: ErrUnsupported},
+		{name: "png signature on other data", data: append([]byte("\x89PNG\r\n\x1a\n"), gifData...), err: ErrUnsupported},
+		{name: "truncated jpeg", data: jpegData[:len(jpegData)/2], err: ErrUnsupported},
+		{name: "bmp", data: append([]byte("BM"), make([]byte, 64)...), err: ErrUnsupported},
+		{name: "empty", data: nil, err: ErrUnsupported},
+		{name: "over MaxPixels", data: hugeGIF, err: ErrTooLarge},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			im, err := Decode(tc.data)
+			if tc.err != nil {
+				if !errors.Is(err, tc.err) {
+					t.Fatalf("got %v, want %v", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if im.ContentType != tc.contentType || im.Ext != tc.ext || im.Width != tc.width || im.Height != tc.height {
+				t.Errorf("got %s %s %dx%d", im.ContentType, im.Ext, im.Width, im.Height)
+			}
+		})
+	}
+
+	im, err := Decode(pngData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := im.pixels.RGBAAt(0, 0); got != (color.RGBA{0xff, 0xff, 0xff, 0xff}) {
+		t.Errorf("transparent pixel flattened to %v, want white", got)
+	}
+}
+
+func TestShrink(t *testing.T) {
+	for _, tc := range []struct {
+		srcWidth, srcHeight int
+		width               int
+		wantWidth           int
+		wantHeight          int
+	}{
+		{400, 300, 200, 200, 150},
+		{300, 200, 99, 99, 66},
+		{3, 1000, 2, 2, 667},
+		{1000, 1, 10, 10, 1},
+		{100, 100, 100, 100, 100},
+		{100, 100, 320, 100, 100},
+	} {
+		src := image.NewRGBA(image.Rect(0, 0, tc.srcWidth, tc.srcHeight))
+		dst := shrink(src, tc.width)
+		if got := dst.Rect.Size(); got != image.Pt(tc.wantWidth, tc.wantHeight) {
+			t.Errorf("%dx%d to width %d: got %dx%d, want %dx%d", tc.srcWidth, tc.srcHeight, tc.width,
+				got.X, got.Y, tc.wantWidth, tc.wantHeight)
+		}
+		if tc.width >= tc.srcWidth && dst != src {
+			t.Errorf("%dx%d to width %d was copied, narrower images are returned as they are", tc.srcWidth, tc.srcHeight, tc.width)
+		}
+	}
+
+	// each destination pixel averages the source pixels it covers
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	src.SetRGBA(0, 0, color.RGBA{0, 0, 0, 0xff})
+	src.SetRGBA(1, 0, color.RGBA{0xff, 0xff, 0xff, 0xff})
+	src.SetRGBA(0, 1, color.RGBA{0xff, 0, 0, 0xff})
+	src.SetRGBA(1, 1, color.RGBA{0xff, 0, 0, 0xff})
+	if got := shrink(src, 1).RGBAAt(0, 0); got != (color.RGBA{0xbf, 0x3f, 0x3f, 0xff}) {
+		t.Errorf("averaged to %v", got)
+	}
+}
+
+func TestThumbnail(t *testing.T) {
+	im, err := Decode(encoded(t, func(w *bytes.Buffer, img image.Image) error { return png.Encode(w, img) },
+		image.NewRGBA(image.Rect(0, 0, 640, 960))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := im.Thumbnail(160)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || format != "jpeg" || cfg.Width != 160 || cfg.Height != 240 {
+		t.Errorf("thumbnail is %s %dx%d, %v", format, cfg.Width, cfg.Height, err)
+	}
+}
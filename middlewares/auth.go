@@ -1,67 +1,108 @@
 package middlewares
 
 import (
-	"net/http"
-	"os"
 	"strings"
+	"time"
 
-	"backend/models"
+	"backend/errs"
+	"backend/repository"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// sessionTouchInterval limits how often last_seen_at is written for a session.
+const sessionTouchInterval = time.Minute
 
-func AuthMiddleware() gin.HandlerFunc {
+func AuthMiddleware(secret string, users repository.UserRepository, sessions repository.SessionRepository) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		tokenStr, err := c.Cookie("token")
-
-		if err != nil || tokenStr == "" {
-			authHeader := c.GetHeader("Authorization")
-			if authHeader != "" && strings.HasPrefix(authHeader, "Bearer ") {
-				tokenStr = strings.TrimPrefix(authHeader, "Bearer ")
-			}
+		// a Bearer header wins over the cookie, CSRFMiddleware only skips
+		// requests carrying one because they are authenticated by it
+		var tokenStr string
+		if authHeader := c.GetHeader("Authorization"); strings.HasPrefix(authHeader, "Bearer ") {
+			tokenStr = strings.TrimPrefix(authHeader, "Bearer ")
+		} else {
+			tokenStr, _ = c.Cookie("token")
 		}
 
 		if tokenStr == "" {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+			errs.Abort(c, errs.Unauthorized("Unauthorized"))
 			return
 		}
 
 		token, err := jwt.Parse(tokenStr, func(token *jwt.Token) (interface{}, error) {
-			return jwtSecret, nil
+			return []byte(secret), nil
 		})
 		if err != nil || !token.Valid {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+			errs.Abort(c, errs.Unauthorized("Invalid token"))
 			return
 		}
 
 		claims, ok := token.Claims.(jwt.MapClaims)
 		if !ok || claims["user_id"] == nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid claims"})
+			errs.Abort(c, errs.Unauthorized("Invalid claims"))
 			return
 		}
 
 		userIDFloat, ok := claims["user_id"].(float64)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid user ID format"})
+			errs.Abort(c, errs.Unauthorized("Invalid user ID format"))
 			return
 		}
 		userID := int(userIDFloat)
 
-		var user models.User
-		if err := models.DB.First(&user, userID).Error; err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		sessionID, _ := claims["sid"].(string)
+		if sessionID == "" {
+			errs.Abort(c, errs.Unauthorized("Invalid claims"))
+			return
+		}
+
+		session, err := sessions.GetActive(c.Request.Context(), sessionID, userID, time.Now())
+		if err != nil {
+			errs.Abort(c, errs.Unauthorized("Session expired or revoked"))
+			return
+		}
+
+		user, err := users.Get(c.Request.Context(), userID)
+		if err != nil || user.AnonymizedAt != nil {
+			errs.Abort(c, errs.Unauthorized("User not found"))
 			return
 		}
 
+		if time.Since(session.LastSeenAt) > sessionTouchInterval {
+			sessions.Touch(c.Request.Context(), session.SessionID, time.Now())
+		}
+
 		c.Set("user", map[string]interface{}{
 			"user_id":  user.UserID,
 			"name":     user.Name,
 			"email":    user.Email,
 			"is_admin": user.IsAdmin,
 		})
+		c.Set("session_id", session.SessionID)
+
+		c.Next()
+	}
+}
+
+func AdminMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user, exists := c.Get("user")
+		if !exists {
+			errs.Abort(c, errs.Unauthorized("Unauthorized"))
+			return
+		}
+
+		userMap, ok := user.(map[string]interface{})
+		if !ok {
+			errs.Abort(c, errs.Unauthorized("Unauthorized"))
+			return
+		}
+
+		if isAdmin, _ := userMap["is_admin"].(bool); !isAdmin {
+			errs.Abort(c, errs.Forbidden("Admin access required"))
+			return
+		}
 
 		c.Next()
 	}
@@ -0,0 +1,93 @@
+package middlewares
+
+import (
+	"errors"
+	"log"
+	"math"
+	"strconv"
+
+	"backend/errs"
+	"backend/repository"
+	"backend/utils"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const RequestIDHeader = "X-Request-ID"
+
+// RequestID tags every request with an id, reusing the caller's X-Request-ID
+// when it looks sane, and echoes it in the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if id == "" || len(id) > 128 {
+			id, _ = utils.RandomToken(12)
+		}
+		c.Set("request_id", id)
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
+
+// ErrorResponse is the body of every error response.
+type ErrorResponse struct {
+	Error ErrorBody `json:"error"`
+}
+
+type ErrorBody struct {
+	Code       errs.Code         `json:"code"`
+	Message    string            `json:"message"`
+	Fields     map[string]string `json:"fields,omitempty"`
+	RetryAfter int               `json:"retry_after,omitempty"`
+	RequestID  string            `json:"request_id,omitempty"`
+}
+
+// Errors renders the last error a handler recorded with c.Error as
+//
+//	{"error": {"code": ..., "message": ..., "fields": ..., "request_id": ...}}
+//
+// Errors that are not *errs.Error are logged and reported as internal.
+func Errors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+
+		e := domainError(c.Errors.Last().Err)
+		requestID := c.GetString("request_id")
+		if e.Code == errs.CodeInternal {
+			log.Printf("request %s: %s %s: %v", requestID, c.Request.Method, c.Request.URL.Path, e)
+		}
+
+		body := ErrorBody{
+			Code:      e.Code,
+			Message:   e.Message,
+			Fields:    e.Fields,
+			RequestID: requestID,
+		}
+		if e.RetryAfter > 0 {
+			body.RetryAfter = int(math.Ceil(e.RetryAfter.Seconds()))
+			c.Header("Retry-After", strconv.Itoa(body.RetryAfter))
+		}
+		c.JSON(e.Status(), ErrorResponse{Error: body})
+	}
+}
+
+// domainError maps repository sentinels so handlers can pass them through.
+func domainError(err error) *errs.Error {
+	var e *errs.Error
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, repository.ErrNotFound), errors.Is(err, gorm.ErrRecordNotFound):
+		return &errs.Error{Code: errs.CodeNotFound, Message: "Not found", Cause: err}
+	case errors.Is(err, repository.ErrSeatBooked):
+		return &errs.Error{Code: errs.CodeConflict, Message: "Seat already booked", Cause: err}
+	case errors.Is(err, repository.ErrDuplicate):
+		return &errs.Error{Code: errs.CodeConflict, Message: "Already exists", Cause: err}
+	}
+	return errs.Internal("Internal server error", err)
+}
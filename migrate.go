@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"backend/config"
+	"backend/migrations"
+	"backend/models"
+)
+
+const migrateUsage = "usage: migrate up [N] | down [N] | status"
+
+func runMigrate(args []string) {
+	if len(args) == 0 {
+		log.Fatal(migrateUsage)
+	}
+
+	steps := 0
+	if len(args) > 1 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 0 {
+			log.Fatalf("invalid step count %q\n%s", args[1], migrateUsage)
+		}
+		steps = n
+	}
+
+	dbConfig, err := config.LoadDatabase()
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := models.OpenDatabase(dbConfig)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrations.Up(db, steps)
+		for _, m := range applied {
+			log.Printf("applied %06d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(applied) == 0 {
+			log.Println("no pending migrations")
+		}
+	case "down":
+		reverted, err := migrations.Down(db, steps)
+		for _, m := range reverted {
+			log.Printf("reverted %06d_%s", m.Version, m.Name)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(reverted) == 0 {
+			log.Println("no applied migrations")
+		}
+	case "status":
+		statuses, err := migrations.StatusOf(db)
+		if err != nil {
+			log.Fatal(err)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tNAME\tAPPLIED AT")
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05 MST")
+			}
+			fmt.Fprintf(w, "%06d\t%s\t%s\n", s.Version, s.Name, appliedAt)
+		}
+		w.Flush()
+	default:
+		log.Fatal(migrateUsage)
+	}
+}
@@ -1,4 +0,0 @@
-CREATE TABLE state (
-    state_id SERIAL PRIMARY KEY,
-    state_name VARCHAR(100) NOT NULL
-);
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS states (
+    state_id BIGSERIAL PRIMARY KEY,
+    state_name VARCHAR(100) NOT NULL
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS cities (
+    city_id BIGSERIAL PRIMARY KEY,
+    state_id BIGINT NOT NULL,
+    city_name VARCHAR(100) NOT NULL
+);
+
+ALTER TABLE cities DROP CONSTRAINT IF EXISTS fk_states_cities;
+ALTER TABLE cities
+ADD CONSTRAINT fk_states_cities
+FOREIGN KEY (state_id)
+REFERENCES states(state_id)
+ON DELETE CASCADE;
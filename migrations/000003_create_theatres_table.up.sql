@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS theatres (
+    theatre_id BIGSERIAL PRIMARY KEY,
+    theatre_name VARCHAR(255) NOT NULL,
+    theatre_location VARCHAR(255),
+    city_id BIGINT NOT NULL,
+    total_seats BIGINT NOT NULL,
+    theatre_image TEXT,
+    theatre_status VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
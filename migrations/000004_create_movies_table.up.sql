@@ -0,0 +1,15 @@
+CREATE TABLE IF NOT EXISTS movies (
+    movie_id BIGSERIAL PRIMARY KEY,
+    movie_name VARCHAR(255) NOT NULL,
+    movie_description TEXT,
+    duration BIGINT, -- duration in minutes
+    languages JSON,
+    genre VARCHAR(100),
+    poster_url TEXT,
+    rating NUMERIC(2,1), -- e.g., 8.5
+    start_date TIMESTAMP WITH TIME ZONE,
+    end_date TIMESTAMP WITH TIME ZONE,
+    movie_status VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS shows (
+    show_id BIGSERIAL PRIMARY KEY,
+    movie_id BIGINT,
+    theatre_id BIGINT,
+    date TIMESTAMP WITH TIME ZONE,
+    start_time TIMESTAMP WITH TIME ZONE,
+    end_time TIMESTAMP WITH TIME ZONE,
+    languages JSONB,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+
+ALTER TABLE shows DROP CONSTRAINT IF EXISTS fk_theatres_shows;
+ALTER TABLE shows
+ADD CONSTRAINT fk_theatres_shows
+FOREIGN KEY (theatre_id)
+REFERENCES theatres(theatre_id)
+ON DELETE CASCADE;
+
+ALTER TABLE shows DROP CONSTRAINT IF EXISTS fk_movies_shows;
+ALTER TABLE shows
+ADD CONSTRAINT fk_movies_shows
+FOREIGN KEY (movie_id)
+REFERENCES movies(movie_id)
+ON DELETE CASCADE;
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS bookings (
+    booking_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    show_id BIGINT NOT NULL,
+    txn_id VARCHAR(100),
+    amount BIGINT NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    seats VARCHAR(255),
+    booking_time TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
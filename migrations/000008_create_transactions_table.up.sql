@@ -0,0 +1,10 @@
+CREATE TABLE IF NOT EXISTS transactions (
+    transaction_id BIGSERIAL PRIMARY KEY,
+    booking_id BIGINT NOT NULL,
+    total_amount NUMERIC(10,2) NOT NULL,
+    payment_method VARCHAR(50),
+    transaction_time TIMESTAMP WITH TIME ZONE,
+    payment_status VARCHAR(50),
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
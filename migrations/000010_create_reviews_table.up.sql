@@ -1,9 +1,11 @@
-CREATE TABLE reviews (
-    review_id SERIAL PRIMARY KEY,
-    user_id INTEGER NOT NULL REFERENCES user(user_id) ON DELETE CASCADE,
-    show_id INTEGER NOT NULL REFERENCES show(show_id) ON DELETE CASCADE,
-    rating INTEGER CHECK (rating >= 1 AND rating <= 5),
+CREATE TABLE IF NOT EXISTS reviews (
+    review_id BIGSERIAL PRIMARY KEY,
+    user_id BIGINT NOT NULL,
+    movie_id BIGINT NOT NULL,
+    rating BIGINT CHECK (rating >= 1 AND rating <= 5),
     comments TEXT,
-    created_at TIMESTAMP WITH TIME ZONE DEFAULT now(),
-    updated_at TIMESTAMP WITH TIME ZONE DEFAULT now()
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
 );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_movie ON reviews (user_id, movie_id);
@@ -0,0 +1,26 @@
+CREATE TABLE IF NOT EXISTS seat_bookings (
+    id BIGSERIAL PRIMARY KEY,
+    show_id BIGINT,
+    seat TEXT,
+    user_id BIGINT,
+    barcode_id TEXT
+);
+
+ALTER TABLE seat_bookings DROP CONSTRAINT IF EXISTS fk_shows_seat_bookings;
+ALTER TABLE seat_bookings
+ADD CONSTRAINT fk_shows_seat_bookings
+FOREIGN KEY (show_id)
+REFERENCES shows(show_id)
+ON DELETE CASCADE;
+
+ALTER TABLE seat_bookings DROP CONSTRAINT IF EXISTS fk_users_seat_bookings;
+ALTER TABLE seat_bookings
+ADD CONSTRAINT fk_users_seat_bookings
+FOREIGN KEY (user_id)
+REFERENCES users(user_id)
+ON DELETE CASCADE;
+
+ALTER TABLE seat_bookings DROP CONSTRAINT IF EXISTS uq_show_seat;
+ALTER TABLE seat_bookings
+ADD CONSTRAINT uq_show_seat
+UNIQUE (show_id, seat);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS payments (
+    payment_id BIGSERIAL PRIMARY KEY,
+    booking_id BIGINT NOT NULL,
+    gateway_txn_id VARCHAR(100),
+    amount NUMERIC(10,2) NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    payment_method VARCHAR(50),
+    payment_response JSON,
+    transaction_time TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
@@ -0,0 +1,11 @@
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS fk_bookings_payments;
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS fk_users_bookings;
+
+ALTER TABLE seat_bookings DROP CONSTRAINT IF EXISTS fk_users_seat_bookings;
+ALTER TABLE seat_bookings
+ADD CONSTRAINT fk_users_seat_bookings
+FOREIGN KEY (user_id)
+REFERENCES users(user_id)
+ON DELETE CASCADE;
+
+ALTER TABLE users DROP COLUMN IF EXISTS anonymized_at;
@@ -0,0 +1,24 @@
+-- users are anonymised rather than deleted, so bookings and payments must
+-- never disappear together with an account
+ALTER TABLE users ADD COLUMN IF NOT EXISTS anonymized_at TIMESTAMP WITH TIME ZONE;
+
+ALTER TABLE seat_bookings DROP CONSTRAINT IF EXISTS fk_users_seat_bookings;
+ALTER TABLE seat_bookings
+ADD CONSTRAINT fk_users_seat_bookings
+FOREIGN KEY (user_id)
+REFERENCES users(user_id)
+ON DELETE RESTRICT;
+
+ALTER TABLE bookings DROP CONSTRAINT IF EXISTS fk_users_bookings;
+ALTER TABLE bookings
+ADD CONSTRAINT fk_users_bookings
+FOREIGN KEY (user_id)
+REFERENCES users(user_id)
+ON DELETE RESTRICT;
+
+ALTER TABLE payments DROP CONSTRAINT IF EXISTS fk_bookings_payments;
+ALTER TABLE payments
+ADD CONSTRAINT fk_bookings_payments
+FOREIGN KEY (booking_id)
+REFERENCES bookings(booking_id)
+ON DELETE RESTRICT;
@@ -0,0 +1,4 @@
+ALTER TABLE shows DROP CONSTRAINT IF EXISTS fk_screens_shows;
+ALTER TABLE shows DROP COLUMN IF EXISTS screen_id;
+
+DROP TABLE IF EXISTS screens;
@@ -0,0 +1,18 @@
+CREATE TABLE IF NOT EXISTS screens (
+    screen_id BIGSERIAL PRIMARY KEY,
+    theatre_id BIGINT NOT NULL REFERENCES theatres(theatre_id) ON DELETE CASCADE,
+    screen_name VARCHAR(100) NOT NULL,
+    total_seats BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_screens_theatre_name ON screens (theatre_id, screen_name);
+
+ALTER TABLE shows ADD COLUMN IF NOT EXISTS screen_id BIGINT;
+ALTER TABLE shows DROP CONSTRAINT IF EXISTS fk_screens_shows;
+ALTER TABLE shows
+ADD CONSTRAINT fk_screens_shows
+FOREIGN KEY (screen_id)
+REFERENCES screens(screen_id)
+ON DELETE SET NULL;
@@ -0,0 +1,5 @@
+ALTER TABLE movies DROP COLUMN IF EXISTS original_language;
+ALTER TABLE movies DROP COLUMN IF EXISTS release_date;
+ALTER TABLE movies DROP COLUMN IF EXISTS certification;
+ALTER TABLE movies DROP COLUMN IF EXISTS trailer_url;
+ALTER TABLE movies DROP COLUMN IF EXISTS genres;
@@ -0,0 +1,9 @@
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS genres JSONB DEFAULT '[]'::jsonb;
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS trailer_url TEXT;
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS certification VARCHAR(2);
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS release_date DATE;
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS original_language VARCHAR(50);
+
+-- the single genre becomes the first of the list
+UPDATE movies SET genres = jsonb_build_array(genre)
+WHERE genre IS NOT NULL AND genre <> '' AND (genres IS NULL OR genres = '[]'::jsonb);
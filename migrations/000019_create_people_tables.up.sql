@@ -0,0 +1,25 @@
+CREATE TABLE IF NOT EXISTS people (
+    person_id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    biography TEXT,
+    profile_url TEXT,
+    created_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE
+);
+
+CREATE INDEX IF NOT EXISTS idx_people_name ON people (LOWER(name));
+
+CREATE TABLE IF NOT EXISTS movie_credits (
+    credit_id BIGSERIAL PRIMARY KEY,
+    movie_id BIGINT NOT NULL REFERENCES movies(movie_id) ON DELETE CASCADE,
+    person_id BIGINT NOT NULL REFERENCES people(person_id) ON DELETE CASCADE,
+    role VARCHAR(20) NOT NULL CHECK (role IN ('actor', 'director', 'crew')),
+    character_name VARCHAR(255) NOT NULL DEFAULT '',
+    job VARCHAR(100) NOT NULL DEFAULT '',
+    billing_order INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE
+);
+
+-- one credit per role and job, a person can still direct and act in a movie
+CREATE UNIQUE INDEX IF NOT EXISTS idx_movie_credits_role ON movie_credits (movie_id, person_id, role, job);
+CREATE INDEX IF NOT EXISTS idx_movie_credits_person ON movie_credits (person_id);
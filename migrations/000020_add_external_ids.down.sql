@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_people_external_id;
+DROP INDEX IF EXISTS idx_movies_external_id;
+
+ALTER TABLE people DROP COLUMN IF EXISTS external_id;
+ALTER TABLE movies DROP COLUMN IF EXISTS external_id;
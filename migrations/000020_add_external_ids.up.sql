@@ -0,0 +1,6 @@
+-- ids of the catalogue an imported record came from, e.g. tmdb:550
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS external_id VARCHAR(64);
+ALTER TABLE people ADD COLUMN IF NOT EXISTS external_id VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_movies_external_id ON movies (external_id) WHERE external_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_people_external_id ON people (external_id) WHERE external_id IS NOT NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE theatres DROP COLUMN IF EXISTS theatre_image_thumbnails;
+ALTER TABLE movies DROP COLUMN IF EXISTS poster_thumbnails;
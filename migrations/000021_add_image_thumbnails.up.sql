@@ -0,0 +1,3 @@
+-- thumbnail URLs of uploaded images by width, e.g. {"w185": "https://..."}
+ALTER TABLE movies ADD COLUMN IF NOT EXISTS poster_thumbnails JSONB;
+ALTER TABLE theatres ADD COLUMN IF NOT EXISTS theatre_image_thumbnails JSONB;
@@ -0,0 +1,2 @@
+-- self declared, A-rated bookings need it
+ALTER TABLE users ADD COLUMN IF NOT EXISTS date_of_birth DATE;
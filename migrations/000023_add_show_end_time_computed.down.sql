@@ -0,0 +1 @@
+ALTER TABLE shows DROP COLUMN IF EXISTS end_time_computed;
@@ -0,0 +1,2 @@
+-- end times derived from the runtime of the movie follow changes to it
+ALTER TABLE shows ADD COLUMN IF NOT EXISTS end_time_computed BOOLEAN NOT NULL DEFAULT FALSE;
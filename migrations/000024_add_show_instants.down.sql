@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_shows_starts_at;
+ALTER TABLE shows DROP COLUMN IF EXISTS ends_at;
+ALTER TABLE shows DROP COLUMN IF EXISTS starts_at;
+ALTER TABLE shows DROP COLUMN IF EXISTS time_zone;
+ALTER TABLE cities DROP COLUMN IF EXISTS timezone;
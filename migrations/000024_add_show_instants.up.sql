@@ -0,0 +1,30 @@
+-- shows are scheduled in the time zone of their theatre's city, every city
+-- so far is in India
+ALTER TABLE cities ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'Asia/Kolkata';
+
+-- starts_at and ends_at are the instants a show runs, date, start_time and
+-- end_time stay as the local date and clock times for older clients
+ALTER TABLE shows ADD COLUMN IF NOT EXISTS time_zone VARCHAR(64);
+ALTER TABLE shows ADD COLUMN IF NOT EXISTS starts_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE shows ADD COLUMN IF NOT EXISTS ends_at TIMESTAMP WITH TIME ZONE;
+
+UPDATE shows SET time_zone = COALESCE(
+    (SELECT cities.timezone FROM theatres JOIN cities ON cities.city_id = theatres.city_id
+     WHERE theatres.theatre_id = shows.theatre_id),
+    'UTC')
+WHERE time_zone IS NULL;
+
+-- the legacy fields hold the local date at UTC midnight and the clock times
+-- on 0000-01-01 UTC, an end at or before the start is on the next day
+UPDATE shows SET
+    starts_at = ((date AT TIME ZONE 'UTC')::date + (start_time AT TIME ZONE 'UTC')::time) AT TIME ZONE time_zone,
+    ends_at = ((date AT TIME ZONE 'UTC')::date + (end_time AT TIME ZONE 'UTC')::time
+        + CASE WHEN (end_time AT TIME ZONE 'UTC')::time <= (start_time AT TIME ZONE 'UTC')::time
+            THEN INTERVAL '1 day' ELSE INTERVAL '0' END) AT TIME ZONE time_zone
+WHERE starts_at IS NULL;
+
+ALTER TABLE shows ALTER COLUMN time_zone SET NOT NULL;
+ALTER TABLE shows ALTER COLUMN starts_at SET NOT NULL;
+ALTER TABLE shows ALTER COLUMN ends_at SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_shows_starts_at ON shows (starts_at);
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"sync"
 	"time"
 
 	"gorm.io/datatypes"
@@ -15,59 +16,271 @@ type City struct {
 	CityID   int    `gorm:"primaryKey;column:city_id" json:"city_id"`
 	StateID  int    `gorm:"not null;column:state_id" json:"state_id"`
 	CityName string `gorm:"size:100;not null;column:city_name" json:"city_name"`
+	Timezone string `gorm:"size:64;not null;default:Asia/Kolkata;column:timezone" json:"timezone" binding:"omitempty,timezone" doc:"IANA time zone the shows of the city are scheduled in, defaults to Asia/Kolkata"`
+}
+
+// DefaultTimezone is the time zone of cities created without one.
+const DefaultTimezone = "Asia/Kolkata"
+
+var locations sync.Map
+
+// LoadLocation is time.LoadLocation, cached.
+func LoadLocation(name string) (*time.Location, error) {
+	if loc, ok := locations.Load(name); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
 }
 
 type Theatre struct {
-	TheatreID       int       `gorm:"primaryKey;column:theatre_id" json:"theatre_id"`
-	TheatreName     string    `gorm:"size:255;not null;column:theatre_name" json:"theatre_name"`
-	TheatreLocation string    `gorm:"size:255;column:theatre_location" json:"theatre_location"`
-	CityID          int       `gorm:"not null;column:city_id" json:"city_id"`
-	TotalSeats      int       `gorm:"not null;column:total_seats" json:"total_seats"`
-	TheatreImage    string    `gorm:"type:text;column:theatre_image" json:"theatre_image"`
-	TheatreStatus   string    `gorm:"size:255;not null;column:theatre_status" json:"theatre_status" binding:"required"`
-	CreatedAt       time.Time `gorm:"column:created_at" json:"created_at"`
-	UpdatedAt       time.Time `gorm:"column:updated_at" json:"updated_at"`
+	TheatreID       int            `gorm:"primaryKey;column:theatre_id" json:"theatre_id"`
+	TheatreName     string         `gorm:"size:255;not null;column:theatre_name" json:"theatre_name"`
+	TheatreLocation string         `gorm:"size:255;column:theatre_location" json:"theatre_location"`
+	CityID          int            `gorm:"not null;column:city_id" json:"city_id"`
+	TotalSeats      int            `gorm:"not null;column:total_seats" json:"total_seats"`
+	TheatreImage    string         `gorm:"type:text;column:theatre_image" json:"theatre_image"`
+	ImageThumbnails datatypes.JSON `gorm:"type:jsonb;column:theatre_image_thumbnails" json:"theatre_image_thumbnails" doc:"Thumbnail URLs by width, set when the image is uploaded"`
+	TheatreStatus   string         `gorm:"size:255;not null;column:theatre_status" json:"theatre_status" binding:"required"`
+	CreatedAt       time.Time      `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt       time.Time      `gorm:"column:updated_at" json:"updated_at"`
+}
+
+type Screen struct {
+	ScreenID   int       `gorm:"primaryKey;column:screen_id" json:"screen_id"`
+	TheatreID  int       `gorm:"not null;column:theatre_id" json:"theatre_id"`
+	ScreenName string    `gorm:"size:100;not null;column:screen_name" json:"screen_name" binding:"required"`
+	TotalSeats int       `gorm:"not null;column:total_seats" json:"total_seats" binding:"required,min=1"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at" json:"updated_at"`
 }
 
 type Movie struct {
 	MovieID          int            `gorm:"primaryKey;column:movie_id" json:"movie_id"`
+	ExternalID       *string        `gorm:"size:64;column:external_id" json:"external_id,omitempty" doc:"Id in the catalogue the movie was imported from, e.g. tmdb:550"`
 	MovieName        string         `gorm:"size:255;not null;column:movie_name" json:"movie_name" binding:"required"`
 	MovieDescription string         `gorm:"type:text;column:movie_description" json:"movie_description" binding:"required"`
 	Duration         int            `gorm:"column:duration" json:"duration" binding:"required"`
 	Languages        datatypes.JSON `gorm:"type:json;column:languages" json:"languages" binding:"required"`
-	Genre            string         `gorm:"size:100;column:genre" json:"genre" binding:"required"`
+	Genre            string         `gorm:"size:100;column:genre" json:"genre" doc:"The first of genres, kept for older clients"`
+	Genres           datatypes.JSON `gorm:"type:jsonb;column:genres" json:"genres"`
 	PosterURL        string         `gorm:"type:text;column:poster_url" json:"poster_url"`
+	PosterThumbnails datatypes.JSON `gorm:"type:jsonb;column:poster_thumbnails" json:"poster_thumbnails" doc:"Thumbnail URLs by width, set when the poster is uploaded"`
+	TrailerURL       string         `gorm:"type:text;column:trailer_url" json:"trailer_url"`
+	Certification    string         `gorm:"size:2;column:certification" json:"certification"`
+	ReleaseDate      *time.Time     `gorm:"type:date;column:release_date" json:"-"`
+	OriginalLanguage string         `gorm:"size:50;column:original_language" json:"original_language"`
 	Rating           float64        `gorm:"type:numeric(2,1);column:rating" json:"rating"`
 	StartDate        time.Time      `gorm:"column:start_date" json:"-"`
 	EndDate          time.Time      `gorm:"column:end_date" json:"-"`
-	MovieStatus      string         `gorm:"size:255;not null;column:movie_status" json:"movie_status" binding:"required"`
+	MovieStatus      string         `gorm:"size:255;not null;column:movie_status" json:"movie_status" doc:"Derived from the run dates and the scheduled shows: Upcoming, Now Showing, Not Scheduled or Expired"`
 	CreatedAt        time.Time      `gorm:"column:created_at" json:"created_at"`
 	UpdatedAt        time.Time      `gorm:"column:updated_at" json:"updated_at"`
 }
 
+// Movie statuses, derived from the run window and the scheduled shows.
+const (
+	MovieStatusUpcoming     = "Upcoming"
+	MovieStatusNowShowing   = "Now Showing"
+	MovieStatusNotScheduled = "Not Scheduled"
+	MovieStatusExpired      = "Expired"
+)
+
+// MovieStatusOn returns the status of a movie on day, scheduled tells whether
+// it has a show on or after day. A movie is Upcoming until its run starts,
+// then Now Showing while it has shows left and Not Scheduled while it has
+// none, and Expired once the run ended.
+func MovieStatusOn(startDate, endDate, day time.Time, scheduled bool) string {
+	switch {
+	case endDate.Before(day):
+		return MovieStatusExpired
+	case startDate.After(day):
+		return MovieStatusUpcoming
+	case scheduled:
+		return MovieStatusNowShowing
+	}
+	return MovieStatusNotScheduled
+}
+
+// Certifications a movie can be rated with.
+const (
+	CertificationU  = "U"
+	CertificationUA = "UA"
+	CertificationA  = "A"
+)
+
+// AdultAge is the age from which A-rated movies may be booked.
+const AdultAge = 18
+
+// AgeOn returns how many full years old someone born on birth is on day.
+func AgeOn(birth, day time.Time) int {
+	age := day.Year() - birth.Year()
+	if day.Month() < birth.Month() || day.Month() == birth.Month() && day.Day() < birth.Day() {
+		age--
+	}
+	return age
+}
+
+type Person struct {
+	PersonID   int       `gorm:"primaryKey;column:person_id" json:"person_id"`
+	ExternalID *string   `gorm:"size:64;column:external_id" json:"external_id,omitempty" doc:"Id in the catalogue the person was imported from, e.g. tmdb:819"`
+	Name       string    `gorm:"size:255;not null;column:name" json:"name" binding:"required,max=255"`
+	Biography  string    `gorm:"type:text;column:biography" json:"biography"`
+	ProfileURL string    `gorm:"type:text;column:profile_url" json:"profile_url" binding:"omitempty,url"`
+	CreatedAt  time.Time `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt  time.Time `gorm:"column:updated_at" json:"updated_at"`
+}
+
+// Credit roles, directors and crew members name their job, actors their
+// character.
+const (
+	CreditRoleActor    = "actor"
+	CreditRoleDirector = "director"
+	CreditRoleCrew     = "crew"
+)
+
+// MovieCredit links a person to a movie in a role.
+type MovieCredit struct {
+	CreditID     int       `gorm:"primaryKey;column:credit_id" json:"credit_id"`
+	MovieID      int       `gorm:"not null;column:movie_id" json:"movie_id"`
+	Movie        Movie     `gorm:"foreignKey:MovieID;references:MovieID" json:"-"`
+	PersonID     int       `gorm:"not null;column:person_id" json:"person_id"`
+	Person       Person    `gorm:"foreignKey:PersonID;references:PersonID" json:"-"`
+	Role         string    `gorm:"size:20;not null;column:role" json:"role"`
+	Character    string    `gorm:"size:255;not null;column:character_name" json:"character"`
+	Job          string    `gorm:"size:100;not null;column:job" json:"job"`
+	BillingOrder int       `gorm:"not null;column:billing_order" json:"billing_order"`
+	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
 type Show struct {
-	ShowID    uint           `gorm:"primaryKey" json:"show_id"`
-	MovieID   int            `gorm:"column:movie_id" json:"movie_id"`
-	Movie     Movie          `gorm:"foreignKey:MovieID;references:MovieID"`
-	TheatreID int            `gorm:"column:theatre_id" json:"theatre_id"`
-	Theatre   Theatre        `gorm:"foreignKey:TheatreID;references:TheatreID"`
-	Date      time.Time      `json:"date"`
-	StartTime time.Time      `json:"start_time"`
-	EndTime   time.Time      `json:"end_time"`
-	Languages datatypes.JSON `json:"languages"`
-	CreatedAt time.Time      `json:"created_at"`
-	UpdatedAt time.Time      `json:"updated_at"`
+	ShowID          uint           `gorm:"primaryKey" json:"show_id"`
+	MovieID         int            `gorm:"column:movie_id" json:"movie_id"`
+	Movie           Movie          `gorm:"foreignKey:MovieID;references:MovieID"`
+	TheatreID       int            `gorm:"column:theatre_id" json:"theatre_id"`
+	Theatre         Theatre        `gorm:"foreignKey:TheatreID;references:TheatreID"`
+	ScreenID        *int           `gorm:"column:screen_id" json:"screen_id"`
+	StartsAt        time.Time      `gorm:"not null;column:starts_at" json:"starts_at"`
+	EndsAt          time.Time      `gorm:"not null;column:ends_at" json:"ends_at"`
+	TimeZone        string         `gorm:"size:64;not null;column:time_zone" json:"time_zone" doc:"IANA time zone of the theatre's city, the legacy date and times are local to it"`
+	Date            time.Time      `json:"date" doc:"Legacy, the local date at UTC midnight"`
+	StartTime       time.Time      `json:"start_time" doc:"Legacy, the local start time on 0000-01-01"`
+	EndTime         time.Time      `json:"end_time" doc:"Legacy, the local end time on 0000-01-01"`
+	EndTimeComputed bool           `gorm:"column:end_time_computed" json:"end_time_computed" doc:"Whether end_time was derived from the runtime of the movie, such end times follow changes to it"`
+	Languages       datatypes.JSON `json:"languages"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+// Location is the time zone the show is scheduled in.
+func (s Show) Location() *time.Location {
+	loc, err := LoadLocation(s.TimeZone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// Interval returns when the show starts and ends.
+func (s Show) Interval() (start, end time.Time) {
+	return s.StartsAt, s.EndsAt
+}
+
+// SetStart schedules the show to start on date at the clock time of start,
+// both local to loc, the time zone of the theatre.
+func (s *Show) SetStart(date, start time.Time, loc *time.Location) {
+	s.TimeZone = loc.String()
+	s.StartsAt = time.Date(date.Year(), date.Month(), date.Day(), start.Hour(), start.Minute(), 0, 0, loc)
+	s.Date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	s.StartTime = clockTime(s.StartsAt)
+}
+
+// SetEnd ends the show at the clock time of end, on the day after it starts
+// when that is not after the start.
+func (s *Show) SetEnd(end time.Time) {
+	start := s.StartsAt.In(s.Location())
+	s.EndsAt = time.Date(start.Year(), start.Month(), start.Day(), end.Hour(), end.Minute(), 0, 0, start.Location())
+	if !s.EndsAt.After(s.StartsAt) {
+		s.EndsAt = s.EndsAt.AddDate(0, 0, 1)
+	}
+	s.EndTime = clockTime(s.EndsAt)
+	s.EndTimeComputed = false
+}
+
+// ComputeEndTime ends the show runtime minutes plus padding, for ads and the
+// interval, after it starts.
+func (s *Show) ComputeEndTime(runtime int, padding time.Duration) {
+	s.EndsAt = s.StartsAt.Add(time.Duration(runtime)*time.Minute + padding)
+	s.EndTime = clockTime(s.EndsAt.In(s.Location()))
+	s.EndTimeComputed = true
+}
+
+// clockTime returns the clock time of t on 0000-01-01, as the legacy time
+// fields hold them.
+func clockTime(t time.Time) time.Time {
+	return time.Date(0, time.January, 1, t.Hour(), t.Minute(), 0, 0, time.UTC)
 }
 
 type User struct {
-	UserID       int       `gorm:"primaryKey;column:user_id" json:"user_id"`
-	Name         string    `gorm:"size:255;not null;unique;column:name" json:"name"`
-	Email        string    `gorm:"size:255;unique;not null;column:email" json:"email"`
-	Phone        string    `gorm:"size:20;column:phone" json:"phone"`
-	PasswordHash string    `gorm:"type:text;not null;column:password_hash" json:"-"`
-	IsAdmin      bool      `gorm:"default:false;column:is_admin" json:"is_admin"`
-	CreatedAt    time.Time `gorm:"column:created_at" json:"created_at"`
-	UpdatedAt    time.Time `gorm:"column:updated_at" json:"updated_at"`
+	UserID       int        `gorm:"primaryKey;column:user_id" json:"user_id"`
+	Name         string     `gorm:"size:255;not null;unique;column:name" json:"name"`
+	Email        string     `gorm:"size:255;unique;not null;column:email" json:"email"`
+	Phone        string     `gorm:"size:20;column:phone" json:"phone"`
+	DateOfBirth  *time.Time `gorm:"type:date;column:date_of_birth" json:"-"`
+	PasswordHash string     `gorm:"type:text;not null;column:password_hash" json:"-"`
+	IsAdmin      bool       `gorm:"default:false;column:is_admin" json:"is_admin"`
+	CreatedAt    time.Time  `gorm:"column:created_at" json:"created_at"`
+	UpdatedAt    time.Time  `gorm:"column:updated_at" json:"updated_at"`
+
+	Preferences  datatypes.JSON `gorm:"type:json;column:preferences" json:"preferences"`
+	AnonymizedAt *time.Time     `gorm:"column:anonymized_at" json:"-"`
+
+	FailedLoginAttempts int        `gorm:"not null;default:0;column:failed_login_attempts" json:"-"`
+	LastFailedLoginAt   *time.Time `gorm:"column:last_failed_login_at" json:"-"`
+	LockedUntil         *time.Time `gorm:"column:locked_until" json:"locked_until,omitempty"`
+}
+
+type Session struct {
+	SessionID  string     `gorm:"primaryKey;size:64;column:session_id" json:"session_id"`
+	UserID     int        `gorm:"not null;index;column:user_id" json:"user_id"`
+	UserAgent  string     `gorm:"size:512;column:user_agent" json:"user_agent"`
+	IPAddress  string     `gorm:"size:64;column:ip_address" json:"ip_address"`
+	CreatedAt  time.Time  `gorm:"column:created_at" json:"created_at"`
+	LastSeenAt time.Time  `gorm:"column:last_seen_at" json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"not null;column:expires_at" json:"expires_at"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revoked_at,omitempty"`
+}
+
+type UserIdentity struct {
+	ID        uint      `gorm:"primaryKey;column:user_identity_id" json:"user_identity_id"`
+	UserID    int       `gorm:"not null;index;column:user_id" json:"user_id"`
+	Issuer    string    `gorm:"size:255;not null;uniqueIndex:idx_issuer_subject;column:issuer" json:"issuer"`
+	Subject   string    `gorm:"size:255;not null;uniqueIndex:idx_issuer_subject;column:subject" json:"subject"`
+	Email     string    `gorm:"size:255;column:email" json:"email"`
+	CreatedAt time.Time `gorm:"column:created_at" json:"created_at"`
+}
+
+type LoginAttempt struct {
+	ID        uint      `gorm:"primaryKey;column:login_attempt_id" json:"login_attempt_id"`
+	UserID    *int      `gorm:"index;column:user_id" json:"user_id"`
+	Email     string    `gorm:"size:255;index;column:email" json:"email"`
+	IPAddress string    `gorm:"size:64;index;column:ip_address" json:"ip_address"`
+	Success   bool      `gorm:"not null;column:success" json:"success"`
+	CreatedAt time.Time `gorm:"index;column:created_at" json:"created_at"`
+}
+
+type SecurityEvent struct {
+	ID        uint      `gorm:"primaryKey;column:security_event_id" json:"security_event_id"`
+	UserID    *int      `gorm:"index;column:user_id" json:"user_id"`
+	Email     string    `gorm:"size:255;column:email" json:"email"`
+	IPAddress string    `gorm:"size:64;column:ip_address" json:"ip_address"`
+	EventType string    `gorm:"size:50;not null;index;column:event_type" json:"event_type"`
+	Details   string    `gorm:"type:text;column:details" json:"details"`
+	CreatedAt time.Time `gorm:"index;column:created_at" json:"created_at"`
 }
 
 type Booking struct {
@@ -133,13 +346,17 @@ type Payment struct {
 	UpdatedAt       time.Time      `gorm:"column:updated_at" json:"updated_at"`
 }
 type BookingDetailsResponse struct {
-	TxnID    string   `json:"txnid"`
-	Amount   float64  `json:"amount"`
-	Status   string   `json:"status"`
-	Seats    []string `json:"selectedSeats"`
-	Movie    string   `json:"movie"`
-	Theatre  string   `json:"theatre"`
-	Date     string   `json:"date"`
-	ShowTime string   `json:"time"`
-	ShowID   int      `json:"show_id"`
+	TxnID    string    `json:"txnid"`
+	Amount   float64   `json:"amount"`
+	Status   string    `json:"status"`
+	Seats    []string  `json:"selectedSeats"`
+	Movie    string    `json:"movie"`
+	Theatre  string    `json:"theatre"`
+	Date     string    `json:"date"`
+	ShowTime string    `json:"time"`
+	ShowID   int       `json:"show_id"`
+	StartsAt time.Time `json:"starts_at" doc:"When the show starts, date and time are the legacy local date and start time"`
+
+	Certification   string `json:"certification"`
+	IDCheckRequired bool   `json:"id_check_required" doc:"Gate staff check the age of A-rated show visitors"`
 }
@@ -0,0 +1,134 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestShowTimes(t *testing.T) {
+	kolkata, err := LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	berlin, err := LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	clock := func(value string) time.Time {
+		parsed, err := time.Parse("15:04", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+
+	for _, tc := range []struct {
+		name     string
+		loc      *time.Location
+		date     time.Time
+		start    string
+		end      string // empty computes the end from runtime and padding
+		runtime  int
+		padding  time.Duration
+		startsAt string
+		endsAt   string
+		endTime  string
+	}{
+		{name: "same day", loc: kolkata, date: time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC),
+			start: "10:00", end: "12:30",
+			startsAt: "2030-01-10T04:30:00Z", endsAt: "2030-01-10T07:00:00Z", endTime: "12:30"},
+		{name: "overnight end", loc: kolkata, date: time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC),
+			start: "23:00", end: "01:15",
+			startsAt: "2030-01-10T17:30:00Z", endsAt: "2030-01-10T19:45:00Z", endTime: "01:15"},
+		{name: "overnight computed end", loc: kolkata, date: time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC),
+			start: "22:30", runtime: 150, padding: 20 * time.Minute,
+			startsAt: "2030-01-10T17:00:00Z", endsAt: "2030-01-10T19:50:00Z", endTime: "01:20"},
+		// clocks go forward from 02:00 to 03:00 on 31 March 2030, the show
+		// runs an hour shorter than the clock times suggest
+		{name: "overnight end across the DST change", loc: berlin, date: time.Date(2030, 3, 30, 0, 0, 0, 0, time.UTC),
+			start: "23:30", end: "03:30",
+			startsAt: "2030-03-30T22:30:00Z", endsAt: "2030-03-31T01:30:00Z", endTime: "03:30"},
+		{name: "computed end across the DST change", loc: berlin, date: time.Date(2030, 3, 30, 0, 0, 0, 0, time.UTC),
+			start: "23:30", runtime: 160, padding: 20 * time.Minute,
+			startsAt: "2030-03-30T22:30:00Z", endsAt: "2030-03-31T01:30:00Z", endTime: "03:30"},
+		{name: "summer time", loc: berlin, date: time.Date(2030, 7, 1, 0, 0, 0, 0, time.UTC),
+			start: "20:00", end: "22:00",
+			startsAt: "2030-07-01T18:00:00Z", endsAt: "2030-07-01T20:00:00Z", endTime: "22:00"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var show Show
+			show.SetStart(tc.date, clock(tc.start), tc.loc)
+			if tc.end == "" {
+				show.ComputeEndTime(tc.runtime, tc.padding)
+			} else {
+				show.SetEnd(clock(tc.end))
+			}
+
+			if show.TimeZone != tc.loc.String() {
+				t.Errorf("time zone %q, want %q", show.TimeZone, tc.loc)
+			}
+			if got := show.StartsAt.UTC().Format(time.RFC3339); got != tc.startsAt {
+				t.Errorf("starts at %s, want %s", got, tc.startsAt)
+			}
+			if got := show.EndsAt.UTC().Format(time.RFC3339); got != tc.endsAt {
+				t.Errorf("ends at %s, want %s", got, tc.endsAt)
+			}
+			if !show.Date.Equal(tc.date) {
+				t.Errorf("date %s, want the local start date %s", show.Date, tc.date)
+			}
+			if got := show.StartTime.Format("15:04"); got != tc.start {
+				t.Errorf("start time %s, want %s", got, tc.start)
+			}
+			if got := show.EndTime.Format("15:04"); got != tc.endTime {
+				t.Errorf("end time %s, want %s", got, tc.endTime)
+			}
+			if show.EndTimeComputed != (tc.end == "") {
+				t.Errorf("end time computed %v", show.EndTimeComputed)
+			}
+		})
+	}
+}
+
+func TestMovieStatusOn(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2030, time.January, d, 0, 0, 0, 0, time.UTC) }
+	start, end := day(10), day(20)
+
+	for _, tc := range []struct {
+		day       time.Time
+		scheduled bool
+		want      string
+	}{
+		{day(9), false, MovieStatusUpcoming},
+		{day(9), true, MovieStatusUpcoming},
+		{day(10), true, MovieStatusNowShowing},
+		{day(10), false, MovieStatusNotScheduled},
+		{day(20), true, MovieStatusNowShowing},
+		{day(20), false, MovieStatusNotScheduled},
+		{day(21), true, MovieStatusExpired},
+	} {
+		if got := MovieStatusOn(start, end, tc.day, tc.scheduled); got != tc.want {
+			t.Errorf("%s, scheduled %v: got %s, want %s", tc.day.Format(time.DateOnly), tc.scheduled, got, tc.want)
+		}
+	}
+}
+
+func TestAgeOn(t *testing.T) {
+	date := func(y int, m time.Month, d int) time.Time { return time.Date(y, m, d, 0, 0, 0, 0, time.UTC) }
+	for _, tc := range []struct {
+		birth, day time.Time
+		want       int
+	}{
+		{date(2012, time.June, 15), date(2030, time.June, 14), 17},
+		{date(2012, time.June, 15), date(2030, time.June, 15), 18},
+		{date(2012, time.June, 15), date(2030, time.May, 31), 17},
+		{date(2012, time.June, 15), date(2030, time.December, 31), 18},
+		// a leap day birthday comes round on 1 March in other years
+		{date(2012, time.February, 29), date(2030, time.February, 28), 17},
+		{date(2012, time.February, 29), date(2030, time.March, 1), 18},
+		{date(2012, time.February, 29), date(2032, time.February, 29), 20},
+	} {
+		if got := AgeOn(tc.birth, tc.day); got != tc.want {
+			t.Errorf("born %s, on %s: got %d, want %d", tc.birth.Format(time.DateOnly), tc.day.Format(time.DateOnly), got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,11 @@
+package oidc
+
+import "time"
+
+// SetJWKSRetryInterval shortens the back-off after a failed key fetch for the
+// duration of a test.
+func SetJWKSRetryInterval(interval time.Duration) (restore func()) {
+	saved := jwksRetryInterval
+	jwksRetryInterval = interval
+	return func() { jwksRetryInterval = saved }
+}
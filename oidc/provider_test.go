@@ -0,0 +1,97 @@
+package oidc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/oidc"
+	"backend/testharness"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestVerifyIDToken(t *testing.T) {
+	idp := testharness.NewOIDCProvider(t)
+	ctx := context.Background()
+
+	provider, err := oidc.Discover(ctx, idp.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims := func(nonce string) jwt.MapClaims {
+		return jwt.MapClaims{
+			"iss":   idp.URL,
+			"aud":   idp.ClientID,
+			"sub":   "subject-1",
+			"nonce": nonce,
+			"exp":   time.Now().Add(time.Minute).Unix(),
+		}
+	}
+
+	verified, err := provider.VerifyIDToken(ctx, idp.IDToken(t, testharness.OIDCKeyID, claims("n-1")), idp.ClientID, "n-1")
+	if err != nil || verified.Subject != "subject-1" {
+		t.Fatalf("verifying a valid token: %+v, %v", verified, err)
+	}
+
+	if _, err := provider.VerifyIDToken(ctx, idp.IDToken(t, testharness.OIDCKeyID, claims("n-1")), idp.ClientID, "n-2"); err == nil {
+		t.Error("accepted a token with another nonce")
+	}
+	if _, err := provider.VerifyIDToken(ctx, idp.IDToken(t, testharness.OIDCKeyID, claims("n-1")), "other-client", "n-1"); err == nil {
+		t.Error("accepted a token for another client")
+	}
+}
+
+func TestUnknownKeysDoNotRefetchJWKS(t *testing.T) {
+	idp := testharness.NewOIDCProvider(t)
+	ctx := context.Background()
+
+	provider, err := oidc.Discover(ctx, idp.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.MapClaims{"iss": idp.URL, "aud": idp.ClientID, "sub": "s", "exp": time.Now().Add(time.Minute).Unix()}
+
+	if _, err := provider.VerifyIDToken(ctx, idp.IDToken(t, testharness.OIDCKeyID, claims), idp.ClientID, ""); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if _, err := provider.VerifyIDToken(ctx, idp.IDToken(t, "forged", claims), idp.ClientID, ""); err == nil {
+			t.Fatal("accepted a token signed with an unknown key")
+		}
+	}
+	if fetches := idp.JWKSFetches.Load(); fetches != 1 {
+		t.Fatalf("expected one JWKS fetch, got %d", fetches)
+	}
+}
+
+func TestFailedJWKSFetchIsRetriedSoon(t *testing.T) {
+	defer oidc.SetJWKSRetryInterval(50 * time.Millisecond)()
+	idp := testharness.NewOIDCProvider(t)
+	ctx := context.Background()
+
+	provider, err := oidc.Discover(ctx, idp.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims := jwt.MapClaims{"iss": idp.URL, "aud": idp.ClientID, "sub": "s", "exp": time.Now().Add(time.Minute).Unix()}
+
+	idp.JWKSDown.Store(true)
+	if _, err := provider.VerifyIDToken(ctx, idp.IDToken(t, testharness.OIDCKeyID, claims), idp.ClientID, ""); err == nil {
+		t.Fatal("verified a token without the signing keys")
+	}
+	idp.JWKSDown.Store(false)
+	// within the back-off the keys are not fetched again
+	if _, err := provider.VerifyIDToken(ctx, idp.IDToken(t, testharness.OIDCKeyID, claims), idp.ClientID, ""); err == nil {
+		t.Fatal("verified a token without the signing keys")
+	}
+	if fetches := idp.JWKSFetches.Load(); fetches != 1 {
+		t.Fatalf("expected one JWKS fetch during the back-off, got %d", fetches)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, err := provider.VerifyIDToken(ctx, idp.IDToken(t, testharness.OIDCKeyID, claims), idp.ClientID, ""); err != nil {
+		t.Fatalf("keys were not fetched again after the back-off: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"backend/controllers"
+
+	"gorm.io/datatypes"
+)
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	jsonType    = reflect.TypeOf(datatypes.JSON{})
+	rawJSONType = reflect.TypeOf(json.RawMessage{})
+	minutesType = reflect.TypeOf(controllers.Minutes(0))
+	dateType    = reflect.TypeOf(controllers.MovieDate{})
+)
+
+// schemaOf returns the schema for v's type. Named structs are added to the
+// components once and referenced, everything else is inlined.
+func (b *builder) schemaOf(v interface{}) *Schema {
+	return b.schemaFor(reflect.TypeOf(v))
+}
+
+func (b *builder) schemaFor(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case jsonType, rawJSONType:
+		return &Schema{Description: "Arbitrary JSON"}
+	case minutesType:
+		return &Schema{
+			Description: "Minutes, a numeric string is accepted too",
+			OneOf:       []*Schema{{Type: "integer"}, {Type: "string"}},
+		}
+	case dateType:
+		return &Schema{
+			Description: "YYYY-MM-DD, an RFC 3339 timestamp whose date is used, or the legacy date object",
+			OneOf:       []*Schema{{Type: "string", Format: "date"}, b.schemaFor(reflect.TypeOf(controllers.DateParts{}))},
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := b.schemaFor(t.Elem())
+		if s.Ref != "" {
+			return s
+		}
+		s.Nullable = true
+		return s
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &Schema{Type: "array", Items: b.schemaFor(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: b.schemaFor(t.Elem())}
+	case reflect.Interface:
+		return &Schema{}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return b.structSchema(t)
+		}
+		name := componentName(t)
+		if _, ok := b.doc.Components.Schemas[name]; !ok {
+			// reserve the name first so recursive types terminate
+			b.doc.Components.Schemas[name] = &Schema{}
+			*b.doc.Components.Schemas[name] = *b.structSchema(t)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+	return &Schema{}
+}
+
+func componentName(t reflect.Type) string {
+	name := []rune(t.Name())
+	name[0] = unicode.ToUpper(name[0])
+	return string(name)
+}
+
+func (b *builder) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	b.addFields(s, t)
+	return s
+}
+
+func (b *builder) addFields(s *Schema, t reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Ptr {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				b.addFields(s, embedded)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		prop := b.schemaFor(field.Type)
+		// rules after dive apply to the elements
+		binding := strings.Split(field.Tag.Get("binding"), ",")
+		for i, rule := range binding {
+			if rule == "dive" {
+				binding = binding[:i]
+				break
+			}
+		}
+		if prop.Ref == "" {
+			applyBinding(prop, binding)
+		}
+		if desc := field.Tag.Get("doc"); desc != "" {
+			if prop.Ref != "" {
+				prop = &Schema{OneOf: []*Schema{prop}}
+			}
+			prop.Description = desc
+		}
+		s.Properties[name] = prop
+
+		if contains(binding, "required") && !strings.Contains(opts, "omitempty") {
+			s.Required = append(s.Required, name)
+			prop.Nullable = false
+		}
+	}
+}
+
+// applyBinding mirrors the validator rules clients are most likely to trip.
+func applyBinding(s *Schema, rules []string) {
+	for _, rule := range rules {
+		key, param, _ := strings.Cut(rule, "=")
+		switch key {
+		case "email":
+			s.Format = "email"
+		case "url":
+			s.Format = "uri"
+		case "oneof":
+			s.Enum = strings.Fields(param)
+		case "min", "max":
+			n, err := strconv.ParseFloat(param, 64)
+			if err != nil {
+				continue
+			}
+			switch s.Type {
+			case "string":
+				length := int(n)
+				if key == "min" {
+					s.MinLength = &length
+				} else {
+					s.MaxLength = &length
+				}
+			case "integer", "number":
+				if key == "min" {
+					s.Minimum = &n
+				} else {
+					s.Maximum = &n
+				}
+			}
+		}
+	}
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,575 @@
+package openapi
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"backend/controllers"
+	"backend/middlewares"
+	"backend/models"
+	"backend/tmdb"
+)
+
+// access is who may call an operation.
+type access int
+
+const (
+	public access = iota
+	user
+	admin
+)
+
+type operation struct {
+	method, path string
+	// legacy is the deprecated unversioned alias of path
+	legacy      string
+	id          string
+	tag         string
+	summary     string
+	description string
+	access      access
+	// params lists query parameters and path parameters that are not
+	// integers, other path parameters are added automatically
+	params []Parameter
+	body   interface{}
+	// form is a form encoded body instead of JSON
+	form interface{}
+	// upload names the file field of a multipart body instead of JSON
+	upload   string
+	status   int
+	response interface{}
+	// legacyResponse replaces response on the legacy alias when the
+	// current version changed its shape
+	legacyResponse interface{}
+	// dryRun is the 200 response with dry_run=true, the parameter is added
+	// automatically
+	dryRun interface{}
+	// headers of the successful response
+	headers map[string]Header
+	// html, redirect and file describe non JSON successful responses
+	html     bool
+	redirect bool
+	file     bool
+}
+
+// response bodies that handlers render from gin.H
+type (
+	message struct {
+		Message string `json:"message"`
+	}
+	csrfToken struct {
+		CSRFToken string `json:"csrf_token"`
+	}
+	currentUser struct {
+		UserID  int    `json:"user_id"`
+		Name    string `json:"name"`
+		Email   string `json:"email"`
+		IsAdmin bool   `json:"is_admin"`
+	}
+	profile struct {
+		User controllers.UserProfile `json:"user"`
+	}
+	bookedSeats struct {
+		BookedSeats []string `json:"bookedSeats"`
+	}
+)
+
+// payuCallback lists the PayU callback fields the hash is computed over.
+type payuCallback struct {
+	TxnID       string `json:"txnid" binding:"required"`
+	Status      string `json:"status" binding:"required"`
+	Hash        string `json:"hash" binding:"required"`
+	Email       string `json:"email"`
+	Amount      string `json:"amount"`
+	ProductInfo string `json:"productinfo"`
+	FirstName   string `json:"firstname"`
+}
+
+func stringPath(name, description string) Parameter {
+	return Parameter{Name: name, In: "path", Required: true, Description: description, Schema: &Schema{Type: "string"}}
+}
+
+func query(name, typ, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: typ}}
+}
+
+func dateQuery(name, description string) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: &Schema{Type: "string", Format: "date"}}
+}
+
+var movieSearchParams = []Parameter{
+	query("q", "string", "Text matched against name and description"),
+	query("genre", "string", "Only this genre"),
+	query("language", "string", "Only movies available in this language"),
+	query("city_id", "integer", "Only count shows in this city's theatres towards the status"),
+	dateQuery("from", "Only movies still running on or after this date"),
+	dateQuery("to", "Only movies starting on or before this date"),
+	{Name: "sort", In: "query", Description: "Sort order, prefix with - to descend, defaults to movie id. release_date sorts by release date, movies without one last, then by the start of the run, then by movie id",
+		Schema: &Schema{Type: "string", Enum: []string{"rating", "-rating", "release_date", "-release_date", "name", "-name"}}},
+	query("limit", "integer", "Page size, 1 to 100"),
+	query("offset", "integer", "Number of matches to skip"),
+}
+
+var totalCount = map[string]Header{
+	controllers.TotalCountHeader: {Description: "Number of matches", Schema: &Schema{Type: "integer"}},
+}
+
+// operations lists every route in the order routes.New registers them.
+// Paths are the /api/v1 ones, the legacy aliases are documented as
+// deprecated operations.
+var operations = []operation{
+	{method: http.MethodGet, path: "/healthz", id: "healthz", tag: "Health",
+		summary: "Liveness probe", description: "Answers while the process serves requests, the database is not checked.",
+		response: controllers.Health{}},
+	{method: http.MethodGet, path: "/readyz", id: "readyz", tag: "Health",
+		summary: "Readiness probe", description: "Checks the database connection and that its schema is at the expected migration version. Responds 503 with the same body when a check fails.",
+		response: controllers.Readiness{}},
+
+	{method: http.MethodPost, path: "/api/v1/payment/success", legacy: "/api/payment/success", id: "paymentSuccess", tag: "Payments",
+		summary: "PayU success callback", description: "Verifies the PayU hash, marks the booking paid and redirects to the frontend.",
+		form: payuCallback{}, redirect: true},
+	{method: http.MethodPost, path: "/api/v1/payment/failure", legacy: "/api/payment/failure", id: "paymentFailure", tag: "Payments",
+		summary: "PayU failure callback", description: "Marks the booking failed and redirects to the frontend.",
+		form: payuCallback{}, redirect: true},
+
+	{method: http.MethodGet, path: "/api/v1/csrf-token", legacy: "/csrf-token", id: "getCSRFToken", tag: "Auth",
+		summary: "Get the CSRF token", description: "Cookie authenticated clients echo it in the X-CSRF-Token header on mutating requests.",
+		response: csrfToken{}},
+	{method: http.MethodPost, path: "/api/v1/register", legacy: "/register", id: "register", tag: "Auth",
+		summary: "Create an account", body: controllers.RegisterInput{}, response: controllers.AuthResponse{}},
+	{method: http.MethodPost, path: "/api/v1/login", legacy: "/login", id: "login", tag: "Auth",
+		summary: "Log in with email and password", body: controllers.LoginInput{}, response: controllers.AuthResponse{}},
+	{method: http.MethodGet, path: "/api/v1/auth/oidc/login", legacy: "/auth/oidc/login", id: "oidcLogin", tag: "Auth",
+		summary: "Start OpenID Connect login", description: "Redirects to the identity provider.", redirect: true},
+	{method: http.MethodGet, path: "/api/v1/auth/oidc/callback", legacy: "/auth/oidc/callback", id: "oidcCallback", tag: "Auth",
+		summary: "Finish OpenID Connect login", redirect: true,
+		description: "A new identity with the verified email of an existing account is linked to that account, its password stops working and its sessions are revoked.",
+		params: []Parameter{
+			query("code", "string", "Authorization code"),
+			query("state", "string", "State issued by the login redirect"),
+			query("error", "string", "Error reported by the identity provider"),
+		}},
+	{method: http.MethodGet, path: "/api/v1/me", legacy: "/me", id: "me", tag: "Auth", access: user,
+		summary: "Get the authenticated user", response: currentUser{}},
+	{method: http.MethodGet, path: "/api/v1/booking/:txnid", legacy: "/api/booking/:txnid", id: "getBookingDetails", tag: "Bookings",
+		summary: "Get a booking by transaction id", params: []Parameter{stringPath("txnid", "Payment transaction id")},
+		response: models.BookingDetailsResponse{}},
+
+	{method: http.MethodGet, path: "/api/v1/profile", legacy: "/api/profile", id: "getProfile", tag: "Profile", access: user,
+		summary: "Get the profile", response: profile{}},
+	{method: http.MethodPut, path: "/api/v1/profile", legacy: "/api/profile", id: "updateProfile", tag: "Profile", access: user,
+		summary: "Update the profile", body: controllers.UpdateProfileInput{}, response: profile{}},
+	{method: http.MethodDelete, path: "/api/v1/profile", legacy: "/api/profile", id: "deleteAccount", tag: "Profile", access: user,
+		summary: "Delete the account", description: "Anonymizes the account and revokes every session. Requires the password, or for accounts with a linked identity and no password given a session started in the last 5 minutes.",
+		body: controllers.DeleteAccountInput{}, response: message{}},
+	{method: http.MethodGet, path: "/api/v1/profile/export", legacy: "/api/profile/export", id: "exportUserData", tag: "Profile", access: user,
+		summary: "Export all personal data", response: controllers.UserDataExport{}},
+	{method: http.MethodPut, path: "/api/v1/profile/password", legacy: "/api/profile/password", id: "changePassword", tag: "Profile", access: user,
+		summary: "Change the password", description: "Revokes every other session.",
+		body: controllers.ChangePasswordInput{}, response: message{}},
+	{method: http.MethodGet, path: "/api/v1/profile/sessions", legacy: "/api/profile/sessions", id: "getSessions", tag: "Profile", access: user,
+		summary: "List active sessions", response: []controllers.SessionResponse{}},
+	{method: http.MethodDelete, path: "/api/v1/profile/sessions", legacy: "/api/profile/sessions", id: "revokeOtherSessions", tag: "Profile", access: user,
+		summary: "Revoke every session but the current one", response: message{}},
+	{method: http.MethodDelete, path: "/api/v1/profile/sessions/:id", legacy: "/api/profile/sessions/:id", id: "revokeSession", tag: "Profile", access: user,
+		summary: "Revoke a session", params: []Parameter{stringPath("id", "Session id")}, response: message{}},
+	{method: http.MethodPost, path: "/api/v1/logout", legacy: "/api/logout", id: "logout", tag: "Auth", access: user,
+		summary: "Log out and revoke the current session", response: message{}},
+	{method: http.MethodPost, path: "/api/v1/payment/initiate", legacy: "/api/payment/initiate", id: "initiatePayment", tag: "Payments", access: user,
+		summary: "Start a payment", description: "Creates a pending booking and returns an HTML form that auto-submits to PayU. " +
+			"A-rated shows respond 403 unless the profile's date of birth makes the user 18 or older on the show date.",
+		body: controllers.PaymentRequest{}, html: true},
+
+	{method: http.MethodPost, path: "/api/v1/admin/users/:id/unlock", legacy: "/api/admin/users/:id/unlock", id: "unlockUser", tag: "Admin", access: admin,
+		summary: "Unlock a locked account", response: message{}},
+	{method: http.MethodGet, path: "/api/v1/admin/security-events", legacy: "/api/admin/security-events", id: "getSecurityEvents", tag: "Admin", access: admin,
+		summary: "List recent security events",
+		params: []Parameter{
+			query("user_id", "integer", "Only events of this user"),
+			query("event_type", "string", "Only events of this type"),
+		},
+		response: []models.SecurityEvent{}},
+	{method: http.MethodPost, path: "/api/v1/admin/imports/tmdb", id: "importTMDB", tag: "Admin", access: admin,
+		summary: "Import movies from a TMDB export",
+		description: "The body is an array of TMDB movie details with credits, videos and release_dates appended, up to 64 MiB. " +
+			"Movies and people are matched on their TMDB id, the report counts what was created, updated or skipped as unchanged. " +
+			"A new runtime moves the computed end times of upcoming shows like a movie update does, movies whose new runtime would make shows overlap are rejected.",
+		params: []Parameter{
+			query("dry_run", "boolean", "Report what would change without writing anything"),
+			query("country", "string", "Country whose certification is used, defaults to IN"),
+		},
+		body: []tmdb.Movie{}, response: tmdb.Report{}},
+
+	{method: http.MethodPost, path: "/api/v1/movies", legacy: "/movies", id: "createMovie", tag: "Movies",
+		summary: "Create a movie", body: controllers.MovieInput{}, status: http.StatusCreated, response: controllers.MovieResponse{}},
+	{method: http.MethodGet, path: "/api/v1/movies", legacy: "/movies", id: "listMovies", tag: "Movies",
+		summary: "Search movies", description: "Filters combine, pages hold 20 movies unless limit is given. The legacy alias returns a bare array of every match unless limit is given.",
+		params: append(movieSearchParams,
+			Parameter{Name: "status", In: "query", Description: "Only movies with this derived status",
+				Schema: &Schema{Type: "string", Enum: []string{models.MovieStatusUpcoming, models.MovieStatusNowShowing, models.MovieStatusNotScheduled, models.MovieStatusExpired}}}),
+		response: controllers.MoviePage{}, legacyResponse: []controllers.MovieResponse{}, headers: totalCount},
+	{method: http.MethodGet, path: "/api/v1/movies/now-showing", id: "nowShowing", tag: "Movies",
+		summary: "Movies now showing", description: "Movies whose run has started and that have shows scheduled from today on.",
+		params: movieSearchParams, response: controllers.MoviePage{}, headers: totalCount},
+	{method: http.MethodGet, path: "/api/v1/movies/coming-soon", id: "comingSoon", tag: "Movies",
+		summary: "Movies coming soon", description: "Movies whose run has not started yet, earliest release first unless sort is given. Movies in their run without shows are Not Scheduled and left out.",
+		params: movieSearchParams, response: controllers.MoviePage{}, headers: totalCount},
+	{method: http.MethodGet, path: "/api/v1/movies/:id", legacy: "/movies/:id", id: "getMovie", tag: "Movies",
+		summary: "Get a movie", response: controllers.MovieResponse{}},
+	{method: http.MethodPut, path: "/api/v1/movies/:id", legacy: "/movies/:id", id: "updateMovie", tag: "Movies",
+		summary: "Replace a movie", description: "A new duration moves the computed end times of the movie's shows that have not started. " +
+			"When a moved show would come closer than the cleaning buffer to another show on its screen the update fails with 409, error fields name the moved shows as shows[id].",
+		body: controllers.MovieInput{}, response: controllers.MovieResponse{}},
+	{method: http.MethodPatch, path: "/api/v1/movies/:id", legacy: "/movies/:id", id: "patchMovie", tag: "Movies",
+		summary: "Update some fields of a movie", description: "Only the fields present in the body are changed, null clears release_date, certification, poster_url, trailer_url and original_language. A new duration moves computed show end times like a full update.",
+		body: controllers.MoviePatch{}, response: controllers.MovieResponse{}},
+	{method: http.MethodDelete, path: "/api/v1/movies/:id", legacy: "/movies/:id", id: "deleteMovie", tag: "Movies",
+		summary: "Delete a movie and its shows", response: message{}},
+	{method: http.MethodGet, path: "/api/v1/movies/:id/credits", id: "getMovieCredits", tag: "People",
+		summary: "Cast and crew of a movie", description: "Both lists are in billing order, directors lead the crew.",
+		response: controllers.MovieCredits{}},
+	{method: http.MethodPost, path: "/api/v1/movies/:id/credits", id: "addMovieCredit", tag: "People", access: admin,
+		summary: "Credit a person in a movie", description: "Fails with 409 when the person already has the role, with the same job, in the movie.",
+		body: controllers.CreditInput{}, status: http.StatusCreated, response: models.MovieCredit{}},
+	{method: http.MethodDelete, path: "/api/v1/movies/:id/credits/:credit_id", id: "deleteMovieCredit", tag: "People", access: admin,
+		summary: "Remove a credit from a movie", response: message{}},
+	{method: http.MethodPost, path: "/api/v1/movies/:id/poster", id: "uploadPoster", tag: "Movies", access: admin,
+		summary: "Upload the poster of a movie",
+		description: "The image field holds a JPEG, PNG or GIF of at most the configured upload size, 5 MiB by default. " +
+			"Sets poster_url to the stored image and poster_thumbnails to JPEG thumbnails 185, 342 and 780 pixels wide. " +
+			"Responds 415 for other content and 413 for larger files.",
+		upload: "image", response: controllers.ImageUpload{}},
+
+	{method: http.MethodPost, path: "/api/v1/people", id: "createPerson", tag: "People", access: admin,
+		summary: "Add a person", body: models.Person{}, status: http.StatusCreated, response: models.Person{}},
+	{method: http.MethodGet, path: "/api/v1/people", id: "listPeople", tag: "People",
+		summary: "List people by name", description: "Pages hold 20 people unless limit is given.",
+		params: []Parameter{
+			query("q", "string", "Text matched against the name"),
+			query("limit", "integer", "Page size, 1 to 100"),
+			query("offset", "integer", "Number of matches to skip"),
+		},
+		response: controllers.PeoplePage{}, headers: totalCount},
+	{method: http.MethodGet, path: "/api/v1/people/:id", id: "getPerson", tag: "People",
+		summary: "Get a person", response: models.Person{}},
+	{method: http.MethodPut, path: "/api/v1/people/:id", id: "updatePerson", tag: "People", access: admin,
+		summary: "Update a person", body: models.Person{}, response: models.Person{}},
+	{method: http.MethodDelete, path: "/api/v1/people/:id", id: "deletePerson", tag: "People", access: admin,
+		summary: "Delete a person and their credits", response: message{}},
+	{method: http.MethodGet, path: "/api/v1/people/:id/filmography", id: "getFilmography", tag: "People",
+		summary: "A person's filmography", description: "Every credit of the person, latest release first.",
+		response: controllers.Filmography{}},
+
+	{method: http.MethodPost, path: "/api/v1/theatres", legacy: "/theatres", id: "createTheatre", tag: "Theatres",
+		summary: "Create a theatre", body: models.Theatre{}, status: http.StatusCreated, response: models.Theatre{}},
+	{method: http.MethodGet, path: "/api/v1/theatres", legacy: "/theatres", id: "listTheatres", tag: "Theatres",
+		summary: "List theatres", response: []models.Theatre{}},
+	{method: http.MethodGet, path: "/api/v1/theatres/:id", legacy: "/theatres/:id", id: "getTheatre", tag: "Theatres",
+		summary: "Get a theatre", response: models.Theatre{}},
+	{method: http.MethodPut, path: "/api/v1/theatres/:id", legacy: "/theatres/:id", id: "updateTheatre", tag: "Theatres",
+		summary: "Update a theatre", body: models.Theatre{}, response: models.Theatre{}},
+	{method: http.MethodDelete, path: "/api/v1/theatres/:id", legacy: "/theatres/:id", id: "deleteTheatre", tag: "Theatres",
+		summary: "Delete a theatre with its screens and shows", response: message{}},
+	{method: http.MethodGet, path: "/api/v1/theatres/:id/screens", legacy: "/theatres/:id/screens", id: "listScreens", tag: "Theatres",
+		summary: "List the screens of a theatre", response: []models.Screen{}},
+	{method: http.MethodPost, path: "/api/v1/theatres/:id/screens", legacy: "/theatres/:id/screens", id: "createScreen", tag: "Theatres", access: admin,
+		summary: "Add a screen to a theatre", body: models.Screen{}, status: http.StatusCreated, response: models.Screen{}},
+	{method: http.MethodPost, path: "/api/v1/theatres/:id/image", id: "uploadTheatreImage", tag: "Theatres", access: admin,
+		summary: "Upload the image of a theatre",
+		description: "The image field holds a JPEG, PNG or GIF of at most the configured upload size, 5 MiB by default. " +
+			"Sets theatre_image to the stored image and theatre_image_thumbnails to JPEG thumbnails 320, 640 and 1280 pixels wide. " +
+			"Responds 415 for other content and 413 for larger files.",
+		upload: "image", response: controllers.ImageUpload{}},
+
+	{method: http.MethodPost, path: "/api/v1/shows", legacy: "/shows", id: "createShows", tag: "Shows",
+		summary: "Schedule shows", description: "Creates one show per entry in times. date is YYYY-MM-DD, times are HH:MM local to the time zone of the theatre's city, an end time before the start time is on the next day. " +
+			"Responses carry the instants starts_at and ends_at along with the legacy local date, start_time and end_time. " +
+			"An omitted end time is the movie's duration plus the configured padding for ads and the interval, such shows have end_time_computed set. " +
+			"Shows must fall in the movie's run and last at least its duration, or the request fails with 400. " +
+			"Shows closer than the cleaning buffer to another show on the same screen, or to each other, fail with 409. " +
+			"Error fields and the dry run report name the entries of times at fault.",
+		body: controllers.CreateShowInput{}, status: http.StatusCreated, response: []models.Show{}, dryRun: controllers.ScheduleCheck{}},
+	{method: http.MethodGet, path: "/api/v1/shows", legacy: "/shows", id: "listShows", tag: "Shows",
+		summary: "List shows", response: []models.Show{}},
+	{method: http.MethodGet, path: "/api/v1/shows/:id", legacy: "/shows/:id", id: "getShow", tag: "Shows",
+		summary: "Get a show", response: models.Show{}},
+	{method: http.MethodPut, path: "/api/v1/shows/:id", legacy: "/shows/:id", id: "updateShow", tag: "Shows",
+		summary: "Update a show", description: "Checked like new shows, the show does not conflict with itself. Omitting end_time computes it again.",
+		body: controllers.UpdateShowInput{}, response: models.Show{}, dryRun: controllers.ScheduleCheck{}},
+	{method: http.MethodDelete, path: "/api/v1/shows/:id", legacy: "/shows/:id", id: "deleteShow", tag: "Shows",
+		summary: "Delete a show", response: message{}},
+
+	{method: http.MethodPost, path: "/api/v1/reviews", legacy: "/reviews", id: "createReview", tag: "Reviews", access: user,
+		summary: "Review a movie", body: controllers.CreateReviewInput{}, status: http.StatusCreated, response: models.Review{}},
+	{method: http.MethodGet, path: "/api/v1/reviews", legacy: "/reviews", id: "listReviews", tag: "Reviews",
+		summary: "List reviews", response: []models.Review{}},
+	{method: http.MethodGet, path: "/api/v1/reviews/:id", legacy: "/reviews/:id", id: "getReview", tag: "Reviews",
+		summary: "Get a review", response: models.Review{}},
+	{method: http.MethodPut, path: "/api/v1/reviews/:id", legacy: "/reviews/:id", id: "updateReview", tag: "Reviews",
+		summary: "Update a review", body: controllers.UpdateReviewInput{}, response: models.Review{}},
+	{method: http.MethodDelete, path: "/api/v1/reviews/:id", legacy: "/reviews/:id", id: "deleteReview", tag: "Reviews",
+		summary: "Delete a review", response: message{}},
+
+	{method: http.MethodPost, path: "/api/v1/states", legacy: "/states", id: "createState", tag: "Locations",
+		summary: "Create a state", body: models.State{}, status: http.StatusCreated, response: models.State{}},
+	{method: http.MethodGet, path: "/api/v1/states", legacy: "/states", id: "listStates", tag: "Locations",
+		summary: "List states", response: []models.State{}},
+	{method: http.MethodGet, path: "/api/v1/states/:id", legacy: "/states/:id", id: "getState", tag: "Locations",
+		summary: "Get a state", response: models.State{}},
+	{method: http.MethodPut, path: "/api/v1/states/:id", legacy: "/states/:id", id: "updateState", tag: "Locations",
+		summary: "Update a state", body: models.State{}, response: models.State{}},
+	{method: http.MethodDelete, path: "/api/v1/states/:id", legacy: "/states/:id", id: "deleteState", tag: "Locations",
+		summary: "Delete a state", response: message{}},
+
+	{method: http.MethodPost, path: "/api/v1/cities", legacy: "/cities", id: "createCity", tag: "Locations",
+		summary: "Create a city", body: models.City{}, status: http.StatusCreated, response: models.City{}},
+	{method: http.MethodGet, path: "/api/v1/cities", legacy: "/cities", id: "listCities", tag: "Locations",
+		summary: "List cities", response: []models.City{}},
+	{method: http.MethodGet, path: "/api/v1/cities/:id", legacy: "/cities/:id", id: "getCity", tag: "Locations",
+		summary: "Get a city", response: models.City{}},
+	{method: http.MethodPut, path: "/api/v1/cities/:id", legacy: "/cities/:id", id: "updateCity", tag: "Locations",
+		summary: "Update a city", description: "An omitted timezone is left as it is. Shows already scheduled keep their time zone.",
+		body: models.City{}, response: models.City{}},
+	{method: http.MethodDelete, path: "/api/v1/cities/:id", legacy: "/cities/:id", id: "deleteCity", tag: "Locations",
+		summary: "Delete a city", response: message{}},
+	{method: http.MethodGet, path: "/api/v1/cities/:id/showtimes", id: "getCityShowtimes", tag: "Shows",
+		summary: "What plays in a city on a day", description: "Shows grouped by movie and theatre, with the seats still available.",
+		params: []Parameter{
+			dateQuery("date", "Day of the shows, defaults to today in the time zone of the city"),
+			query("movie_id", "integer", "Only shows of this movie"),
+			query("language", "string", "Only shows in this language"),
+		},
+		response: controllers.CityShowtimes{}},
+
+	{method: http.MethodGet, path: "/api/v1/seats/show/:id", legacy: "/seats/show/:id", id: "getBookedSeats", tag: "Bookings",
+		summary: "List the booked seats of a show", response: bookedSeats{}},
+	{method: http.MethodPost, path: "/api/v1/seats/book", legacy: "/seats/book", id: "bookSeat", tag: "Bookings",
+		summary: "Book a seat", description: "Fails with 409 when the seat is already booked.",
+		body: models.SeatBooking{}, status: http.StatusCreated, response: message{}},
+
+	{method: http.MethodGet, path: SpecPath, id: "getOpenAPI", tag: "Docs",
+		summary: "This document", response: map[string]interface{}{}},
+	{method: http.MethodGet, path: UIPath, id: "swaggerUI", tag: "Docs",
+		summary: "Swagger UI for this document", html: true},
+	{method: http.MethodGet, path: controllers.MediaPath + "*key", id: "getMedia", tag: "Media",
+		summary:     "An uploaded image or thumbnail",
+		description: "Keys change with the content, responses may be cached for a year. Answers 304 when If-None-Match holds the ETag.",
+		params:      []Parameter{stringPath("key", "Storage key, as in the URLs uploads return")},
+		headers: map[string]Header{
+			"Cache-Control": {Schema: &Schema{Type: "string"}},
+			"ETag":          {Schema: &Schema{Type: "string"}},
+			"Last-Modified": {Schema: &Schema{Type: "string"}},
+		},
+		file: true},
+}
+
+const (
+	SpecPath = "/openapi.json"
+	UIPath   = "/docs"
+)
+
+var tags = []Tag{
+	{Name: "Auth", Description: "Accounts, login and sessions"},
+	{Name: "Profile", Description: "The authenticated user's own data"},
+	{Name: "Admin", Description: "Administrator only operations"},
+	{Name: "Movies"},
+	{Name: "People", Description: "Cast and crew and their credits"},
+	{Name: "Theatres", Description: "Theatres and their screens"},
+	{Name: "Shows"},
+	{Name: "Bookings", Description: "Seat bookings and booking details"},
+	{Name: "Payments", Description: "PayU checkout and callbacks"},
+	{Name: "Reviews"},
+	{Name: "Locations", Description: "States and cities"},
+	{Name: "Docs"},
+	{Name: "Media", Description: "Uploaded images and their thumbnails"},
+	{Name: "Health", Description: "Liveness and readiness probes"},
+}
+
+type builder struct {
+	doc *Document
+}
+
+// Spec builds the document for the API served at serverURL.
+func Spec(serverURL string) *Document {
+	b := &builder{doc: &Document{
+		OpenAPI: Version,
+		Info: Info{
+			Title:       "Movie Booking API",
+			Description: "Errors are returned as {\"error\": {\"code\", \"message\", \"fields\", \"request_id\"}}.",
+			Version:     "1.0.0",
+		},
+		Tags:  tags,
+		Paths: map[string]*PathItem{},
+		Components: Components{
+			Schemas: map[string]*Schema{},
+			SecuritySchemes: map[string]*SecurityScheme{
+				"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"},
+				"cookieAuth": {Type: "apiKey", In: "cookie", Name: "token",
+					Description: "Set by login, mutating requests also need the X-CSRF-Token header"},
+			},
+		},
+	}}
+	if serverURL != "" {
+		b.doc.Servers = []Server{{URL: serverURL}}
+	}
+
+	b.doc.Components.Responses = map[string]*Response{
+		"Error": {
+			Description: "Error",
+			Content:     jsonContent(b.schemaOf(middlewares.ErrorResponse{})),
+		},
+	}
+
+	for _, op := range operations {
+		b.add(op)
+		if op.legacy != "" {
+			b.addLegacy(op)
+		}
+	}
+	return b.doc
+}
+
+// PathFor converts a gin route path such as /movies/:id to /movies/{id}.
+func PathFor(ginPath string) string {
+	segments := strings.Split(ginPath, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+func methodKey(method string) string {
+	return strings.ToLower(method)
+}
+
+func jsonContent(s *Schema) map[string]MediaType {
+	return map[string]MediaType{"application/json": {Schema: s}}
+}
+
+func (b *builder) add(op operation) {
+	o := &Operation{
+		Tags:        []string{op.tag},
+		Summary:     op.summary,
+		Description: op.description,
+		OperationID: op.id,
+		Responses:   map[string]*Response{"default": {Ref: "#/components/responses/Error"}},
+	}
+
+	declared := map[string]bool{}
+	for _, p := range op.params {
+		if p.In == "path" {
+			declared[p.Name] = true
+		}
+	}
+	for _, segment := range strings.Split(op.path, "/") {
+		if strings.HasPrefix(segment, ":") && !declared[segment[1:]] {
+			o.Parameters = append(o.Parameters, Parameter{
+				Name: segment[1:], In: "path", Required: true,
+				Schema: &Schema{Type: "integer"},
+			})
+		}
+	}
+	o.Parameters = append(o.Parameters, op.params...)
+	if op.dryRun != nil {
+		o.Parameters = append(o.Parameters, query("dry_run", "boolean", "Report what would happen without writing anything"))
+	}
+
+	switch {
+	case op.body != nil:
+		o.RequestBody = &RequestBody{Required: true, Content: jsonContent(b.schemaOf(op.body))}
+	case op.form != nil:
+		o.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{
+			"application/x-www-form-urlencoded": {Schema: b.formSchema(op.form)},
+		}}
+	case op.upload != "":
+		o.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{
+			"multipart/form-data": {Schema: &Schema{
+				Type:       "object",
+				Properties: map[string]*Schema{op.upload: {Type: "string", Format: "binary"}},
+				Required:   []string{op.upload},
+			}},
+		}}
+	}
+
+	status := op.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	switch {
+	case op.redirect:
+		o.Responses[strconv.Itoa(http.StatusFound)] = &Response{
+			Description: "Redirect",
+			Headers:     map[string]Header{"Location": {Schema: &Schema{Type: "string"}}},
+		}
+	case op.html:
+		o.Responses[strconv.Itoa(status)] = &Response{
+			Description: "HTML page",
+			Content:     map[string]MediaType{"text/html": {Schema: &Schema{Type: "string"}}},
+		}
+	case op.file:
+		o.Responses[strconv.Itoa(status)] = &Response{
+			Description: "File",
+			Headers:     op.headers,
+			Content:     map[string]MediaType{"image/*": {Schema: &Schema{Type: "string", Format: "binary"}}},
+		}
+	default:
+		o.Responses[strconv.Itoa(status)] = &Response{
+			Description: http.StatusText(status),
+			Headers:     op.headers,
+			Content:     jsonContent(b.schemaOf(op.response)),
+		}
+	}
+	switch {
+	case op.dryRun == nil:
+	case status == http.StatusOK:
+		response := o.Responses[strconv.Itoa(status)]
+		response.Description += ", or the dry run report"
+		response.Content = jsonContent(&Schema{OneOf: []*Schema{b.schemaOf(op.response), b.schemaOf(op.dryRun)}})
+	default:
+		o.Responses[strconv.Itoa(http.StatusOK)] = &Response{
+			Description: "Dry run report",
+			Content:     jsonContent(b.schemaOf(op.dryRun)),
+		}
+	}
+
+	switch op.access {
+	case admin:
+		o.Description = strings.TrimSpace(o.Description + " Requires an administrator.")
+		fallthrough
+	case user:
+		o.Security = []map[string][]string{{"bearerAuth": {}}, {"cookieAuth": {}}}
+	}
+
+	path := PathFor(op.path)
+	item, ok := b.doc.Paths[path]
+	if !ok {
+		item = &PathItem{}
+		b.doc.Paths[path] = item
+	}
+	(*item)[methodKey(op.method)] = o
+}
+
+func (b *builder) addLegacy(op operation) {
+	successor := PathFor(op.path)
+	op.path, op.legacy = op.legacy, ""
+	op.id += "Legacy"
+	if op.legacyResponse != nil {
+		op.response = op.legacyResponse
+		op.description = strings.TrimSpace(op.description + " Responds with the response shape from before the current version.")
+	}
+	op.description = strings.TrimSpace(op.description + " Deprecated alias of " + successor +
+		", responses carry Deprecation, Sunset and Link headers.")
+	b.add(op)
+	o := b.doc.Operation(op.method, PathFor(op.path))
+	o.Deprecated = true
+}
+
+// formSchema inlines a form body, Swagger UI renders referenced form schemas
+// poorly.
+func (b *builder) formSchema(v interface{}) *Schema {
+	return b.structSchema(reflect.TypeOf(v))
+}
@@ -0,0 +1,116 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"backend/models"
+	"backend/repository"
+)
+
+type bookingRepository struct {
+	*store
+}
+
+func (r *bookingRepository) Create(ctx context.Context, booking *models.Booking) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	booking.BookingID = r.nextID("bookings")
+	r.bookings[booking.BookingID] = *booking
+	return nil
+}
+
+func (r *bookingRepository) GetByTxnID(ctx context.Context, txnID string) (*models.Booking, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, booking := range r.bookings {
+		if booking.TxnID == txnID {
+			return &booking, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *bookingRepository) UpdateStatusByTxnID(ctx context.Context, txnID, status string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, booking := range r.bookings {
+		if booking.TxnID == txnID {
+			booking.Status = status
+			r.bookings[id] = booking
+		}
+	}
+	return nil
+}
+
+func (r *bookingRepository) ListByUser(ctx context.Context, userID int) ([]models.Booking, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	bookings := []models.Booking{}
+	for _, booking := range r.bookings {
+		if booking.UserID == userID {
+			bookings = append(bookings, booking)
+		}
+	}
+	sort.Slice(bookings, func(i, j int) bool { return bookings[i].BookingID < bookings[j].BookingID })
+	return bookings, nil
+}
+
+func (r *bookingRepository) BookSeat(ctx context.Context, seat *models.SeatBooking) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.seats {
+		if existing.ShowID == seat.ShowID && existing.Seat == seat.Seat {
+			return repository.ErrSeatBooked
+		}
+	}
+	seat.ID = uint(r.nextID("seat_bookings"))
+	r.seats[seat.ID] = *seat
+	return nil
+}
+
+func (r *bookingRepository) ListSeats(ctx context.Context, showID uint) ([]models.SeatBooking, error) {
+	return r.filterSeats(func(seat models.SeatBooking) bool { return seat.ShowID == showID }), nil
+}
+
+func (r *bookingRepository) ListSeatsByUser(ctx context.Context, userID int) ([]models.SeatBooking, error) {
+	return r.filterSeats(func(seat models.SeatBooking) bool { return seat.UserID == uint(userID) }), nil
+}
+
+func (r *bookingRepository) filterSeats(keep func(models.SeatBooking) bool) []models.SeatBooking {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	seats := []models.SeatBooking{}
+	for _, seat := range r.seats {
+		if keep(seat) {
+			seats = append(seats, seat)
+		}
+	}
+	sort.Slice(seats, func(i, j int) bool { return seats[i].ID < seats[j].ID })
+	return seats
+}
+
+type paymentRepository struct {
+	*store
+}
+
+func (r *paymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	payment.PaymentID = uint(r.nextID("payments"))
+	r.payments[payment.PaymentID] = *payment
+	return nil
+}
+
+func (r *paymentRepository) ListByUser(ctx context.Context, userID int) ([]models.Payment, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	payments := []models.Payment{}
+	for _, payment := range r.payments {
+		if booking, ok := r.bookings[payment.BookingID]; ok && booking.UserID == userID {
+			payments = append(payments, payment)
+		}
+	}
+	sort.Slice(payments, func(i, j int) bool { return payments[i].PaymentID < payments[j].PaymentID })
+	return payments, nil
+}
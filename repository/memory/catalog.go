@@ -0,0 +1,601 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/repository"
+)
+
+type movieRepository struct {
+	*store
+}
+
+func (r *movieRepository) Create(ctx context.Context, movie *models.Movie) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	movie.MovieID = r.nextID("movies")
+	r.movies[movie.MovieID] = *movie
+	return nil
+}
+
+// withStatus derives the status of movie at now, shows count from the current
+// date of their own time zone on. The caller holds the lock.
+func (r *movieRepository) withStatus(movie models.Movie, now time.Time, cityID int) models.Movie {
+	day := repository.Day(now)
+	scheduled := false
+	for _, show := range r.shows {
+		if show.MovieID == movie.MovieID && !show.Date.Before(repository.LocalDay(now, show.Location())) &&
+			(cityID == 0 || r.theatres[show.TheatreID].CityID == cityID) {
+			scheduled = true
+			break
+		}
+	}
+	movie.MovieStatus = models.MovieStatusOn(movie.StartDate, movie.EndDate, day, scheduled)
+	return movie
+}
+
+func (r *movieRepository) List(ctx context.Context) ([]models.Movie, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := time.Now()
+	movies := make([]models.Movie, 0, len(r.movies))
+	for _, movie := range r.movies {
+		movies = append(movies, r.withStatus(movie, now, 0))
+	}
+	sort.Slice(movies, func(i, j int) bool { return movies[i].MovieID < movies[j].MovieID })
+	return movies, nil
+}
+
+func (r *movieRepository) Search(ctx context.Context, filter repository.MovieFilter) ([]models.Movie, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := filter.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	var movies []models.Movie
+	for _, movie := range r.movies {
+		movie = r.withStatus(movie, now, filter.CityID)
+		if matchesMovie(movie, filter) {
+			movies = append(movies, movie)
+		}
+	}
+
+	compare := func(a, b models.Movie) int {
+		switch filter.Sort {
+		case repository.MovieSortRating:
+			return cmp.Compare(a.Rating, b.Rating)
+		case repository.MovieSortReleaseDate:
+			// like postgres, a missing release date sorts after every date
+			if c := compareDates(a.ReleaseDate, b.ReleaseDate); c != 0 {
+				return c
+			}
+			return a.StartDate.Compare(b.StartDate)
+		case repository.MovieSortName:
+			return strings.Compare(a.MovieName, b.MovieName)
+		}
+		return 0
+	}
+	sort.SliceStable(movies, func(i, j int) bool {
+		c := compare(movies[i], movies[j])
+		if filter.Descending {
+			c = -c
+		}
+		if c == 0 {
+			return movies[i].MovieID < movies[j].MovieID
+		}
+		return c < 0
+	})
+
+	total := int64(len(movies))
+	movies = movies[min(filter.Offset, len(movies)):]
+	if filter.Limit > 0 && len(movies) > filter.Limit {
+		movies = movies[:filter.Limit]
+	}
+	return movies, total, nil
+}
+
+func compareDates(a, b *time.Time) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	}
+	return a.Compare(*b)
+}
+
+func matchesMovie(movie models.Movie, filter repository.MovieFilter) bool {
+	if filter.Query != "" {
+		query := strings.ToLower(filter.Query)
+		if !strings.Contains(strings.ToLower(movie.MovieName), query) &&
+			!strings.Contains(strings.ToLower(movie.MovieDescription), query) {
+			return false
+		}
+	}
+	if filter.Genre != "" {
+		var genres []string
+		json.Unmarshal(movie.Genres, &genres)
+		if !slices.ContainsFunc(genres, func(g string) bool { return strings.EqualFold(g, filter.Genre) }) {
+			return false
+		}
+	}
+	if filter.Language != "" {
+		var languages []string
+		json.Unmarshal(movie.Languages, &languages)
+		if !slices.ContainsFunc(languages, func(l string) bool { return strings.EqualFold(l, filter.Language) }) {
+			return false
+		}
+	}
+	if filter.Status != "" && !strings.EqualFold(movie.MovieStatus, filter.Status) {
+		return false
+	}
+	if filter.From != nil && movie.EndDate.Before(*filter.From) {
+		return false
+	}
+	if filter.To != nil && movie.StartDate.After(*filter.To) {
+		return false
+	}
+	return true
+}
+
+func (r *movieRepository) Get(ctx context.Context, id int) (*models.Movie, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	movie, ok := r.movies[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	movie = r.withStatus(movie, time.Now(), 0)
+	return &movie, nil
+}
+
+func (r *movieRepository) GetByExternalID(ctx context.Context, externalID string) (*models.Movie, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, movie := range r.movies {
+		if movie.ExternalID != nil && *movie.ExternalID == externalID {
+			movie = r.withStatus(movie, time.Now(), 0)
+			return &movie, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *movieRepository) Update(ctx context.Context, movie *models.Movie) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.movies[movie.MovieID]; !ok {
+		return repository.ErrNotFound
+	}
+	r.movies[movie.MovieID] = *movie
+	return nil
+}
+
+func (r *movieRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.movies[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.movies, id)
+	// mirror ON DELETE CASCADE of shows.movie_id and movie_credits.movie_id
+	for showID, show := range r.shows {
+		if show.MovieID == id {
+			r.deleteShow(showID)
+		}
+	}
+	for creditID, credit := range r.credits {
+		if credit.MovieID == id {
+			delete(r.credits, creditID)
+		}
+	}
+	return nil
+}
+
+type stateRepository struct {
+	*store
+}
+
+func (r *stateRepository) Create(ctx context.Context, state *models.State) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	state.StateID = r.nextID("states")
+	r.states[state.StateID] = *state
+	return nil
+}
+
+func (r *stateRepository) List(ctx context.Context) ([]models.State, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	states := make([]models.State, 0, len(r.states))
+	for _, state := range r.states {
+		states = append(states, state)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].StateID < states[j].StateID })
+	return states, nil
+}
+
+func (r *stateRepository) Get(ctx context.Context, id int) (*models.State, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	state, ok := r.states[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &state, nil
+}
+
+func (r *stateRepository) Update(ctx context.Context, state *models.State) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.states[state.StateID]; !ok {
+		return repository.ErrNotFound
+	}
+	r.states[state.StateID] = *state
+	return nil
+}
+
+func (r *stateRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.states[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.states, id)
+	return nil
+}
+
+type cityRepository struct {
+	*store
+}
+
+func (r *cityRepository) Create(ctx context.Context, city *models.City) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if city.Timezone == "" {
+		city.Timezone = models.DefaultTimezone
+	}
+	city.CityID = r.nextID("cities")
+	r.cities[city.CityID] = *city
+	return nil
+}
+
+func (r *cityRepository) List(ctx context.Context) ([]models.City, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	cities := make([]models.City, 0, len(r.cities))
+	for _, city := range r.cities {
+		cities = append(cities, city)
+	}
+	sort.Slice(cities, func(i, j int) bool { return cities[i].CityID < cities[j].CityID })
+	return cities, nil
+}
+
+func (r *cityRepository) Get(ctx context.Context, id int) (*models.City, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	city, ok := r.cities[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &city, nil
+}
+
+func (r *cityRepository) Update(ctx context.Context, city *models.City) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.cities[city.CityID]; !ok {
+		return repository.ErrNotFound
+	}
+	r.cities[city.CityID] = *city
+	return nil
+}
+
+func (r *cityRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.cities[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.cities, id)
+	return nil
+}
+
+func (r *cityRepository) Location(ctx context.Context, cityID int) (*time.Location, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	city, ok := r.cities[cityID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return models.LoadLocation(city.Timezone)
+}
+
+type theatreRepository struct {
+	*store
+}
+
+func (r *theatreRepository) Create(ctx context.Context, theatre *models.Theatre) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	theatre.TheatreID = r.nextID("theatres")
+	r.theatres[theatre.TheatreID] = *theatre
+	return nil
+}
+
+func (r *theatreRepository) List(ctx context.Context) ([]models.Theatre, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	theatres := make([]models.Theatre, 0, len(r.theatres))
+	for _, theatre := range r.theatres {
+		theatres = append(theatres, theatre)
+	}
+	sort.Slice(theatres, func(i, j int) bool { return theatres[i].TheatreID < theatres[j].TheatreID })
+	return theatres, nil
+}
+
+func (r *theatreRepository) Get(ctx context.Context, id int) (*models.Theatre, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	theatre, ok := r.theatres[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &theatre, nil
+}
+
+func (r *theatreRepository) Update(ctx context.Context, theatre *models.Theatre) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.theatres[theatre.TheatreID]; !ok {
+		return repository.ErrNotFound
+	}
+	r.theatres[theatre.TheatreID] = *theatre
+	return nil
+}
+
+func (r *theatreRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.theatres[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.theatres, id)
+	for screenID, screen := range r.screens {
+		if screen.TheatreID == id {
+			delete(r.screens, screenID)
+		}
+	}
+	for showID, show := range r.shows {
+		if show.TheatreID == id {
+			r.deleteShow(showID)
+		}
+	}
+	return nil
+}
+
+func (r *theatreRepository) CreateScreen(ctx context.Context, screen *models.Screen) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	screen.ScreenID = r.nextID("screens")
+	r.screens[screen.ScreenID] = *screen
+	return nil
+}
+
+func (r *theatreRepository) ListScreens(ctx context.Context, theatreID int) ([]models.Screen, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	screens := []models.Screen{}
+	for _, screen := range r.screens {
+		if screen.TheatreID == theatreID {
+			screens = append(screens, screen)
+		}
+	}
+	sort.Slice(screens, func(i, j int) bool { return screens[i].ScreenName < screens[j].ScreenName })
+	return screens, nil
+}
+
+func (r *theatreRepository) GetScreen(ctx context.Context, theatreID, screenID int) (*models.Screen, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	screen, ok := r.screens[screenID]
+	if !ok || screen.TheatreID != theatreID {
+		return nil, repository.ErrNotFound
+	}
+	return &screen, nil
+}
+
+func (r *theatreRepository) Location(ctx context.Context, theatreID int) (*time.Location, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	theatre, ok := r.theatres[theatreID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	city, ok := r.cities[theatre.CityID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return models.LoadLocation(city.Timezone)
+}
+
+type showRepository struct {
+	*store
+}
+
+func (r *showRepository) Create(ctx context.Context, show *models.Show) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	show.ShowID = uint(r.nextID("shows"))
+	r.shows[show.ShowID] = stripShow(*show)
+	return nil
+}
+
+func (r *showRepository) List(ctx context.Context) ([]models.Show, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	shows := make([]models.Show, 0, len(r.shows))
+	for _, show := range r.shows {
+		shows = append(shows, r.withAssociations(show))
+	}
+	sort.Slice(shows, func(i, j int) bool { return shows[i].ShowID < shows[j].ShowID })
+	return shows, nil
+}
+
+func (r *showRepository) Get(ctx context.Context, id uint) (*models.Show, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	show, ok := r.shows[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	show = r.withAssociations(show)
+	return &show, nil
+}
+
+func (r *showRepository) Update(ctx context.Context, show *models.Show) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.shows[show.ShowID]; !ok {
+		return repository.ErrNotFound
+	}
+	r.shows[show.ShowID] = stripShow(*show)
+	return nil
+}
+
+func (r *showRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.shows[id]; !ok {
+		return repository.ErrNotFound
+	}
+	r.deleteShow(id)
+	return nil
+}
+
+func (r *showRepository) ListOnScreen(ctx context.Context, theatreID int, screenID *int, from, to time.Time) ([]models.Show, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	end := to.AddDate(0, 0, 1)
+
+	var shows []models.Show
+	for _, show := range r.shows {
+		if show.TheatreID != theatreID || show.Date.Before(from) || !show.Date.Before(end) {
+			continue
+		}
+		if (show.ScreenID == nil) != (screenID == nil) || screenID != nil && *show.ScreenID != *screenID {
+			continue
+		}
+		show = r.withAssociations(show)
+		show.Theatre = models.Theatre{}
+		shows = append(shows, show)
+	}
+	sort.Slice(shows, func(i, j int) bool {
+		if !shows[i].StartsAt.Equal(shows[j].StartsAt) {
+			return shows[i].StartsAt.Before(shows[j].StartsAt)
+		}
+		return shows[i].ShowID < shows[j].ShowID
+	})
+	return shows, nil
+}
+
+func (r *showRepository) ListByMovie(ctx context.Context, movieID int, from time.Time) ([]models.Show, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var shows []models.Show
+	for _, show := range r.shows {
+		if show.MovieID == movieID && !show.Date.Before(from) {
+			shows = append(shows, show)
+		}
+	}
+	sort.Slice(shows, func(i, j int) bool {
+		if !shows[i].StartsAt.Equal(shows[j].StartsAt) {
+			return shows[i].StartsAt.Before(shows[j].StartsAt)
+		}
+		return shows[i].ShowID < shows[j].ShowID
+	})
+	return shows, nil
+}
+
+func (r *showRepository) ListShowtimes(ctx context.Context, filter repository.ShowtimeFilter) ([]repository.Showtime, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	end := filter.Date.AddDate(0, 0, 1)
+
+	booked := map[uint]int{}
+	for _, seat := range r.seats {
+		booked[seat.ShowID]++
+	}
+
+	var showtimes []repository.Showtime
+	for _, show := range r.shows {
+		if r.theatres[show.TheatreID].CityID != filter.CityID ||
+			show.Date.Before(filter.Date) || !show.Date.Before(end) ||
+			(filter.MovieID != 0 && show.MovieID != filter.MovieID) {
+			continue
+		}
+		if filter.Language != "" {
+			var languages []string
+			json.Unmarshal(show.Languages, &languages)
+			if !slices.ContainsFunc(languages, func(l string) bool { return strings.EqualFold(l, filter.Language) }) {
+				continue
+			}
+		}
+
+		show = r.withAssociations(show)
+		showtime := repository.Showtime{Show: show, Capacity: show.Theatre.TotalSeats, BookedSeats: booked[show.ShowID]}
+		if show.ScreenID != nil {
+			screen := r.screens[*show.ScreenID]
+			showtime.ScreenName = screen.ScreenName
+			showtime.Capacity = screen.TotalSeats
+		}
+		showtimes = append(showtimes, showtime)
+	}
+	sort.Slice(showtimes, func(i, j int) bool {
+		a, b := showtimes[i].Show, showtimes[j].Show
+		if !a.StartsAt.Equal(b.StartsAt) {
+			return a.StartsAt.Before(b.StartsAt)
+		}
+		return a.ShowID < b.ShowID
+	})
+	return showtimes, nil
+}
+
+// LockScreen has nothing to do, transactions on the store run one at a time.
+func (r *showRepository) LockScreen(ctx context.Context, theatreID int, screenID *int) error {
+	return nil
+}
+
+// stripShow drops the associations so the stored copy never goes stale.
+func stripShow(show models.Show) models.Show {
+	show.Movie = models.Movie{}
+	show.Theatre = models.Theatre{}
+	return show
+}
+
+func (s *store) withAssociations(show models.Show) models.Show {
+	show.Movie = s.movies[show.MovieID]
+	show.Theatre = s.theatres[show.TheatreID]
+	return show
+}
+
+// deleteShow removes a show and its seat bookings. Callers hold s.mu.
+func (s *store) deleteShow(id uint) {
+	delete(s.shows, id)
+	for seatID, seat := range s.seats {
+		if seat.ShowID == id {
+			delete(s.seats, seatID)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Package memory implements the repository interfaces in process. All
+// repositories returned by New share one store so cross-aggregate reads such
+// as a show with its movie behave like the database.
+package memory
+
+import (
+	"context"
+	"maps"
+	"sync"
+
+	"backend/models"
+	"backend/repository"
+)
+
+type store struct {
+	mu sync.RWMutex
+	// txMu serialises transactions, which also makes LockScreen redundant
+	txMu sync.Mutex
+
+	tables
+}
+
+type tables struct {
+	movies        map[int]models.Movie
+	people        map[int]models.Person
+	credits       map[int]models.MovieCredit
+	states        map[int]models.State
+	cities        map[int]models.City
+	theatres      map[int]models.Theatre
+	screens       map[int]models.Screen
+	shows         map[uint]models.Show
+	bookings      map[int]models.Booking
+	seats         map[uint]models.SeatBooking
+	payments      map[uint]models.Payment
+	users         map[int]models.User
+	identities    map[uint]models.UserIdentity
+	sessions      map[string]models.Session
+	loginAttempts map[uint]models.LoginAttempt
+	events        map[uint]models.SecurityEvent
+	reviews       map[uint]models.Review
+
+	// lastIDs emulates one serial sequence per table
+	lastIDs map[string]int
+}
+
+// clone copies the tables so a transaction can be rolled back.
+func (t tables) clone() tables {
+	return tables{
+		movies:        maps.Clone(t.movies),
+		people:        maps.Clone(t.people),
+		credits:       maps.Clone(t.credits),
+		states:        maps.Clone(t.states),
+		cities:        maps.Clone(t.cities),
+		theatres:      maps.Clone(t.theatres),
+		screens:       maps.Clone(t.screens),
+		shows:         maps.Clone(t.shows),
+		bookings:      maps.Clone(t.bookings),
+		seats:         maps.Clone(t.seats),
+		payments:      maps.Clone(t.payments),
+		users:         maps.Clone(t.users),
+		identities:    maps.Clone(t.identities),
+		sessions:      maps.Clone(t.sessions),
+		loginAttempts: maps.Clone(t.loginAttempts),
+		events:        maps.Clone(t.events),
+		reviews:       maps.Clone(t.reviews),
+		lastIDs:       maps.Clone(t.lastIDs),
+	}
+}
+
+// New returns empty repositories sharing one in-memory store.
+func New() repository.Repositories {
+	s := &store{tables: tables{
+		movies:        make(map[int]models.Movie),
+		people:        make(map[int]models.Person),
+		credits:       make(map[int]models.MovieCredit),
+		states:        make(map[int]models.State),
+		cities:        make(map[int]models.City),
+		theatres:      make(map[int]models.Theatre),
+		screens:       make(map[int]models.Screen),
+		shows:         make(map[uint]models.Show),
+		bookings:      make(map[int]models.Booking),
+		seats:         make(map[uint]models.SeatBooking),
+		payments:      make(map[uint]models.Payment),
+		users:         make(map[int]models.User),
+		identities:    make(map[uint]models.UserIdentity),
+		sessions:      make(map[string]models.Session),
+		loginAttempts: make(map[uint]models.LoginAttempt),
+		events:        make(map[uint]models.SecurityEvent),
+		reviews:       make(map[uint]models.Review),
+		lastIDs:       make(map[string]int),
+	}}
+	return s.repositories(false)
+}
+
+func (s *store) repositories(inTransaction bool) repository.Repositories {
+	return repository.Repositories{
+		Transactor: &transactor{store: s, inTransaction: inTransaction},
+		Movies:     &movieRepository{s},
+		People:     &personRepository{s},
+		States:     &stateRepository{s},
+		Cities:     &cityRepository{s},
+		Theatres:   &theatreRepository{s},
+		Shows:      &showRepository{s},
+		Reviews:    &reviewRepository{s},
+		Bookings:   &bookingRepository{s},
+		Payments:   &paymentRepository{s},
+		Users:      &userRepository{s},
+		Sessions:   &sessionRepository{s},
+		Security:   &securityRepository{s},
+	}
+}
+
+type transactor struct {
+	*store
+	// inTransaction is set on the repositories passed to fn, which already
+	// hold txMu
+	inTransaction bool
+}
+
+// Transaction runs fn while no other transaction runs and restores the tables
+// as they were when fn fails.
+func (t *transactor) Transaction(ctx context.Context, fn func(repository.Repositories) error) error {
+	if !t.inTransaction {
+		t.txMu.Lock()
+		defer t.txMu.Unlock()
+	}
+
+	t.mu.RLock()
+	saved := t.tables.clone()
+	t.mu.RUnlock()
+
+	if err := fn(t.repositories(true)); err != nil {
+		t.mu.Lock()
+		t.tables = saved
+		t.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// nextID returns the next id of table. Callers hold s.mu.
+func (s *store) nextID(table string) int {
+	s.lastIDs[table]++
+	return s.lastIDs[table]
+}
@@ -0,0 +1,186 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"backend/models"
+	"backend/repository"
+)
+
+type personRepository struct {
+	*store
+}
+
+func (r *personRepository) Create(ctx context.Context, person *models.Person) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	person.PersonID = r.nextID("people")
+	r.people[person.PersonID] = *person
+	return nil
+}
+
+func (r *personRepository) List(ctx context.Context, filter repository.PersonFilter) ([]models.Person, int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	query := strings.ToLower(filter.Query)
+	var people []models.Person
+	for _, person := range r.people {
+		if strings.Contains(strings.ToLower(person.Name), query) {
+			people = append(people, person)
+		}
+	}
+	sort.Slice(people, func(i, j int) bool {
+		a, b := strings.ToLower(people[i].Name), strings.ToLower(people[j].Name)
+		if a == b {
+			return people[i].PersonID < people[j].PersonID
+		}
+		return a < b
+	})
+
+	total := int64(len(people))
+	people = people[min(filter.Offset, len(people)):]
+	if filter.Limit > 0 && len(people) > filter.Limit {
+		people = people[:filter.Limit]
+	}
+	return people, total, nil
+}
+
+func (r *personRepository) Get(ctx context.Context, id int) (*models.Person, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	person, ok := r.people[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &person, nil
+}
+
+func (r *personRepository) GetByExternalID(ctx context.Context, externalID string) (*models.Person, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, person := range r.people {
+		if person.ExternalID != nil && *person.ExternalID == externalID {
+			return &person, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *personRepository) Update(ctx context.Context, person *models.Person) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.people[person.PersonID]; !ok {
+		return repository.ErrNotFound
+	}
+	r.people[person.PersonID] = *person
+	return nil
+}
+
+func (r *personRepository) Delete(ctx context.Context, id int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.people[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.people, id)
+	// mirror ON DELETE CASCADE of movie_credits.person_id
+	for creditID, credit := range r.credits {
+		if credit.PersonID == id {
+			delete(r.credits, creditID)
+		}
+	}
+	return nil
+}
+
+func (r *personRepository) AddCredit(ctx context.Context, credit *models.MovieCredit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.movies[credit.MovieID]; !ok {
+		return repository.ErrNotFound
+	}
+	if _, ok := r.people[credit.PersonID]; !ok {
+		return repository.ErrNotFound
+	}
+	for _, existing := range r.credits {
+		if existing.MovieID == credit.MovieID && existing.PersonID == credit.PersonID &&
+			existing.Role == credit.Role && existing.Job == credit.Job {
+			return repository.ErrDuplicate
+		}
+	}
+	credit.CreditID = r.nextID("movie_credits")
+	stored := *credit
+	stored.Movie, stored.Person = models.Movie{}, models.Person{}
+	r.credits[credit.CreditID] = stored
+	return nil
+}
+
+func (r *personRepository) UpdateCredit(ctx context.Context, credit *models.MovieCredit) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	stored, ok := r.credits[credit.CreditID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	stored.Character, stored.BillingOrder = credit.Character, credit.BillingOrder
+	r.credits[credit.CreditID] = stored
+	return nil
+}
+
+func (r *personRepository) DeleteCredit(ctx context.Context, movieID, creditID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	credit, ok := r.credits[creditID]
+	if !ok || credit.MovieID != movieID {
+		return repository.ErrNotFound
+	}
+	delete(r.credits, creditID)
+	return nil
+}
+
+func (r *personRepository) ListCredits(ctx context.Context, movieID int) ([]models.MovieCredit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var credits []models.MovieCredit
+	for _, credit := range r.credits {
+		if credit.MovieID == movieID {
+			credit.Person = r.people[credit.PersonID]
+			credits = append(credits, credit)
+		}
+	}
+	sort.Slice(credits, func(i, j int) bool {
+		if credits[i].BillingOrder != credits[j].BillingOrder {
+			return credits[i].BillingOrder < credits[j].BillingOrder
+		}
+		return credits[i].CreditID < credits[j].CreditID
+	})
+	return credits, nil
+}
+
+func (r *personRepository) Filmography(ctx context.Context, personID int) ([]models.MovieCredit, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var credits []models.MovieCredit
+	for _, credit := range r.credits {
+		if credit.PersonID == personID {
+			credit.Movie = r.movies[credit.MovieID]
+			credits = append(credits, credit)
+		}
+	}
+	// movies without a release date are ordered by the start of their run
+	released := func(movie models.Movie) int64 {
+		if movie.ReleaseDate != nil {
+			return repository.Day(*movie.ReleaseDate).Unix()
+		}
+		return repository.Day(movie.StartDate).Unix()
+	}
+	sort.Slice(credits, func(i, j int) bool {
+		a, b := released(credits[i].Movie), released(credits[j].Movie)
+		if a != b {
+			return a > b
+		}
+		return credits[i].CreditID < credits[j].CreditID
+	})
+	return credits, nil
+}
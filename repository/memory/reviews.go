@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"backend/models"
+	"backend/repository"
+)
+
+type reviewRepository struct {
+	*store
+}
+
+func (r *reviewRepository) Create(ctx context.Context, review *models.Review) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.reviews {
+		if existing.UserID == review.UserID && existing.MovieID == review.MovieID {
+			return repository.ErrDuplicate
+		}
+	}
+	review.ReviewID = uint(r.nextID("reviews"))
+	r.reviews[review.ReviewID] = *review
+	return nil
+}
+
+func (r *reviewRepository) List(ctx context.Context) ([]models.Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reviews := make([]models.Review, 0, len(r.reviews))
+	for _, review := range r.reviews {
+		reviews = append(reviews, review)
+	}
+	sort.Slice(reviews, func(i, j int) bool { return reviews[i].ReviewID < reviews[j].ReviewID })
+	return reviews, nil
+}
+
+func (r *reviewRepository) Get(ctx context.Context, id uint) (*models.Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	review, ok := r.reviews[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &review, nil
+}
+
+func (r *reviewRepository) Update(ctx context.Context, review *models.Review) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.reviews[review.ReviewID]; !ok {
+		return repository.ErrNotFound
+	}
+	r.reviews[review.ReviewID] = *review
+	return nil
+}
+
+func (r *reviewRepository) Delete(ctx context.Context, id uint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.reviews[id]; !ok {
+		return repository.ErrNotFound
+	}
+	delete(r.reviews, id)
+	return nil
+}
@@ -0,0 +1,381 @@
+package memory
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"backend/models"
+	"backend/repository"
+)
+
+type userRepository struct {
+	*store
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user.UserID = r.nextID("users")
+	now := time.Now()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = now
+	}
+	if user.UpdatedAt.IsZero() {
+		user.UpdatedAt = now
+	}
+	r.users[user.UserID] = *user
+	return nil
+}
+
+func (r *userRepository) Get(ctx context.Context, id int) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	user, ok := r.users[id]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	return &user, nil
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if user.Email == email {
+			return &user, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *userRepository) EmailOrNameTaken(ctx context.Context, email, name string, exceptUserID int) (bool, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, user := range r.users {
+		if user.UserID == exceptUserID {
+			continue
+		}
+		if (email != "" && user.Email == email) || (name != "" && user.Name == name) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (r *userRepository) UpdateProfile(ctx context.Context, userID int, changes repository.ProfileChanges, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if changes.Name != nil {
+		user.Name = *changes.Name
+	}
+	if changes.Phone != nil {
+		user.Phone = *changes.Phone
+	}
+	if changes.Preferences != nil {
+		user.Preferences = *changes.Preferences
+	}
+	if changes.DateOfBirth != nil {
+		birth := *changes.DateOfBirth
+		user.DateOfBirth = &birth
+	}
+	user.UpdatedAt = at
+	r.users[userID] = user
+	return nil
+}
+
+func (r *userRepository) UpdateLoginState(ctx context.Context, userID, failedAttempts int, lastFailedAt, lockedUntil *time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userID]
+	if !ok {
+		return nil
+	}
+	user.FailedLoginAttempts = failedAttempts
+	user.LastFailedLoginAt = lastFailedAt
+	user.LockedUntil = lockedUntil
+	r.users[userID] = user
+	return nil
+}
+
+func (r *userRepository) RegisterFailedLogin(ctx context.Context, userID int, at time.Time, window time.Duration, maxFailures int, lockedUntil time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userID]
+	if !ok {
+		return 0, repository.ErrNotFound
+	}
+	if user.LastFailedLoginAt == nil || user.LastFailedLoginAt.Before(at.Add(-window)) {
+		user.FailedLoginAttempts = 0
+	}
+	user.FailedLoginAttempts++
+	user.LastFailedLoginAt = &at
+	if user.FailedLoginAttempts >= maxFailures {
+		user.LockedUntil = &lockedUntil
+	}
+	r.users[userID] = user
+	return user.FailedLoginAttempts, nil
+}
+
+func (r *userRepository) ChangePassword(ctx context.Context, userID int, passwordHash, keepSessionID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	user, ok := r.users[userID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	user.PasswordHash = passwordHash
+	user.UpdatedAt = at
+	r.users[userID] = user
+	r.revokeAllExcept(userID, keepSessionID, at)
+	return nil
+}
+
+func (r *userRepository) GetIdentity(ctx context.Context, issuer, subject string) (*models.UserIdentity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, identity := range r.identities {
+		if identity.Issuer == issuer && identity.Subject == subject {
+			return &identity, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (r *userRepository) CreateIdentity(ctx context.Context, identity *models.UserIdentity) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	identity.ID = uint(r.nextID("user_identities"))
+	r.identities[identity.ID] = *identity
+	return nil
+}
+
+func (r *userRepository) ListIdentities(ctx context.Context, userID int) ([]models.UserIdentity, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	identities := []models.UserIdentity{}
+	for _, identity := range r.identities {
+		if identity.UserID == userID {
+			identities = append(identities, identity)
+		}
+	}
+	sort.Slice(identities, func(i, j int) bool { return identities[i].ID < identities[j].ID })
+	return identities, nil
+}
+
+func (r *userRepository) Anonymize(ctx context.Context, user *models.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.users[user.UserID]; !ok {
+		return repository.ErrNotFound
+	}
+	r.users[user.UserID] = *user
+
+	for id, identity := range r.identities {
+		if identity.UserID == user.UserID {
+			delete(r.identities, id)
+		}
+	}
+	for id, session := range r.sessions {
+		if session.UserID == user.UserID {
+			delete(r.sessions, id)
+		}
+	}
+	for id, attempt := range r.loginAttempts {
+		if attempt.UserID != nil && *attempt.UserID == user.UserID {
+			attempt.Email, attempt.IPAddress = user.Email, ""
+			r.loginAttempts[id] = attempt
+		}
+	}
+	for id, event := range r.events {
+		if event.UserID != nil && *event.UserID == user.UserID {
+			event.Email, event.IPAddress = user.Email, ""
+			r.events[id] = event
+		}
+	}
+	for id, payment := range r.payments {
+		if booking, ok := r.bookings[payment.BookingID]; ok && booking.UserID == user.UserID {
+			payment.PaymentResponse = nil
+			r.payments[id] = payment
+		}
+	}
+	for id, review := range r.reviews {
+		if int(review.UserID) == user.UserID {
+			review.Comments = ""
+			r.reviews[id] = review
+		}
+	}
+	return nil
+}
+
+func (r *userRepository) ListReviews(ctx context.Context, userID int) ([]models.Review, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reviews := []models.Review{}
+	for _, review := range r.reviews {
+		if review.UserID == uint(userID) {
+			reviews = append(reviews, review)
+		}
+	}
+	sort.Slice(reviews, func(i, j int) bool { return reviews[i].ReviewID < reviews[j].ReviewID })
+	return reviews, nil
+}
+
+type sessionRepository struct {
+	*store
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sessions[session.SessionID] = *session
+	return nil
+}
+
+func (r *sessionRepository) GetActive(ctx context.Context, sessionID string, userID int, now time.Time) (*models.Session, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	session, ok := r.sessions[sessionID]
+	if !ok || session.UserID != userID || !active(session, now) {
+		return nil, repository.ErrNotFound
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, sessionID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if session, ok := r.sessions[sessionID]; ok {
+		session.LastSeenAt = at
+		r.sessions[sessionID] = session
+	}
+	return nil
+}
+
+func (r *sessionRepository) ListActive(ctx context.Context, userID int, now time.Time) ([]models.Session, error) {
+	sessions := r.filterSessions(func(session models.Session) bool {
+		return session.UserID == userID && active(session, now)
+	})
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+func (r *sessionRepository) ListByUser(ctx context.Context, userID int) ([]models.Session, error) {
+	sessions := r.filterSessions(func(session models.Session) bool { return session.UserID == userID })
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].CreatedAt.Before(sessions[j].CreatedAt) })
+	return sessions, nil
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, userID int, sessionID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[sessionID]
+	if !ok || session.UserID != userID || session.RevokedAt != nil {
+		return repository.ErrNotFound
+	}
+	session.RevokedAt = &at
+	r.sessions[sessionID] = session
+	return nil
+}
+
+func (r *sessionRepository) RevokeAllExcept(ctx context.Context, userID int, keepSessionID string, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.revokeAllExcept(userID, keepSessionID, at)
+	return nil
+}
+
+func (r *sessionRepository) filterSessions(keep func(models.Session) bool) []models.Session {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	sessions := []models.Session{}
+	for _, session := range r.sessions {
+		if keep(session) {
+			sessions = append(sessions, session)
+		}
+	}
+	return sessions
+}
+
+// revokeAllExcept is shared with ChangePassword. Callers hold s.mu.
+func (s *store) revokeAllExcept(userID int, keepSessionID string, at time.Time) {
+	for id, session := range s.sessions {
+		if session.UserID == userID && id != keepSessionID && session.RevokedAt == nil {
+			session.RevokedAt = &at
+			s.sessions[id] = session
+		}
+	}
+}
+
+func active(session models.Session, now time.Time) bool {
+	return session.RevokedAt == nil && session.ExpiresAt.After(now)
+}
+
+type securityRepository struct {
+	*store
+}
+
+func (r *securityRepository) RecordLoginAttempt(ctx context.Context, attempt *models.LoginAttempt) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	attempt.ID = uint(r.nextID("login_attempts"))
+	r.loginAttempts[attempt.ID] = *attempt
+	return nil
+}
+
+func (r *securityRepository) CountFailedLoginsFromIP(ctx context.Context, ip string, since time.Time) (int64, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var count int64
+	for _, attempt := range r.loginAttempts {
+		if attempt.IPAddress == ip && !attempt.Success && attempt.CreatedAt.After(since) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *securityRepository) ListLoginAttempts(ctx context.Context, userID int) ([]models.LoginAttempt, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	attempts := []models.LoginAttempt{}
+	for _, attempt := range r.loginAttempts {
+		if attempt.UserID != nil && *attempt.UserID == userID {
+			attempts = append(attempts, attempt)
+		}
+	}
+	sort.Slice(attempts, func(i, j int) bool { return attempts[i].ID < attempts[j].ID })
+	return attempts, nil
+}
+
+func (r *securityRepository) RecordEvent(ctx context.Context, event *models.SecurityEvent) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	event.ID = uint(r.nextID("security_events"))
+	r.events[event.ID] = *event
+	return nil
+}
+
+func (r *securityRepository) ListEvents(ctx context.Context, filter repository.SecurityEventFilter) ([]models.SecurityEvent, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	events := []models.SecurityEvent{}
+	for _, event := range r.events {
+		if filter.UserID != nil && (event.UserID == nil || *event.UserID != *filter.UserID) {
+			continue
+		}
+		if filter.EventType != "" && event.EventType != filter.EventType {
+			continue
+		}
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].ID > events[j].ID })
+	if filter.Limit > 0 && len(events) > filter.Limit {
+		events = events[:filter.Limit]
+	}
+	return events, nil
+}
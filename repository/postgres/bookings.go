@@ -0,0 +1,84 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"backend/models"
+	"backend/repository"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+type bookingRepository struct {
+	db *gorm.DB
+}
+
+func (r *bookingRepository) Create(ctx context.Context, booking *models.Booking) error {
+	return r.db.WithContext(ctx).Create(booking).Error
+}
+
+func (r *bookingRepository) GetByTxnID(ctx context.Context, txnID string) (*models.Booking, error) {
+	var booking models.Booking
+	if err := r.db.WithContext(ctx).Where("txn_id = ?", txnID).First(&booking).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &booking, nil
+}
+
+func (r *bookingRepository) UpdateStatusByTxnID(ctx context.Context, txnID, status string) error {
+	return r.db.WithContext(ctx).Model(&models.Booking{}).Where("txn_id = ?", txnID).Update("status", status).Error
+}
+
+func (r *bookingRepository) ListByUser(ctx context.Context, userID int) ([]models.Booking, error) {
+	var bookings []models.Booking
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&bookings).Error
+	return bookings, err
+}
+
+func (r *bookingRepository) BookSeat(ctx context.Context, seat *models.SeatBooking) error {
+	var count int64
+	db := r.db.WithContext(ctx)
+	if err := db.Model(&models.SeatBooking{}).Where("show_id = ? AND seat = ?", seat.ShowID, seat.Seat).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return repository.ErrSeatBooked
+	}
+
+	err := db.Create(seat).Error
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return repository.ErrSeatBooked
+	}
+	return err
+}
+
+func (r *bookingRepository) ListSeats(ctx context.Context, showID uint) ([]models.SeatBooking, error) {
+	var seats []models.SeatBooking
+	err := r.db.WithContext(ctx).Where("show_id = ?", showID).Find(&seats).Error
+	return seats, err
+}
+
+func (r *bookingRepository) ListSeatsByUser(ctx context.Context, userID int) ([]models.SeatBooking, error) {
+	var seats []models.SeatBooking
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&seats).Error
+	return seats, err
+}
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func (r *paymentRepository) Create(ctx context.Context, payment *models.Payment) error {
+	return r.db.WithContext(ctx).Create(payment).Error
+}
+
+func (r *paymentRepository) ListByUser(ctx context.Context, userID int) ([]models.Payment, error) {
+	var payments []models.Payment
+	db := r.db.WithContext(ctx)
+	err := db.Where("booking_id IN (?)", db.Model(&models.Booking{}).Select("booking_id").Where("user_id = ?", userID)).
+		Order("created_at").Find(&payments).Error
+	return payments, err
+}
@@ -0,0 +1,415 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"backend/models"
+	"backend/repository"
+
+	"gorm.io/gorm"
+)
+
+type movieRepository struct {
+	db *gorm.DB
+}
+
+func (r *movieRepository) Create(ctx context.Context, movie *models.Movie) error {
+	return r.db.WithContext(ctx).Create(movie).Error
+}
+
+// movieRow carries the status derived by movieStatusSQL next to the stored
+// columns.
+type movieRow struct {
+	models.Movie  `gorm:"embedded"`
+	DerivedStatus string `gorm:"column:derived_status"`
+}
+
+// localToday is the date of now in the time zone of a show, at UTC midnight
+// like shows.date.
+const localToday = "((?::timestamptz AT TIME ZONE shows.time_zone)::date AT TIME ZONE 'UTC')"
+
+// movieStatusSQL derives models.MovieStatusOn for the movies table at now,
+// shows count from the current date of their own time zone on.
+func movieStatusSQL(now time.Time, cityID int) (string, []interface{}) {
+	day := repository.Day(now)
+	scheduled := "EXISTS (SELECT 1 FROM shows WHERE shows.movie_id = movies.movie_id AND shows.date >= " + localToday + ")"
+	scheduledArgs := []interface{}{now}
+	if cityID != 0 {
+		scheduled = "EXISTS (SELECT 1 FROM shows JOIN theatres ON theatres.theatre_id = shows.theatre_id " +
+			"WHERE shows.movie_id = movies.movie_id AND shows.date >= " + localToday + " AND theatres.city_id = ?)"
+		scheduledArgs = append(scheduledArgs, cityID)
+	}
+	sql := "(CASE WHEN movies.end_date < ? THEN ?::text WHEN movies.start_date > ? THEN ?::text " +
+		"WHEN " + scheduled + " THEN ?::text ELSE ?::text END)"
+	args := []interface{}{day, models.MovieStatusExpired, day, models.MovieStatusUpcoming}
+	args = append(args, scheduledArgs...)
+	args = append(args, models.MovieStatusNowShowing, models.MovieStatusNotScheduled)
+	return sql, args
+}
+
+// findMovies runs query selecting the derived status along with the movies.
+func findMovies(query *gorm.DB, now time.Time, cityID int) ([]models.Movie, error) {
+	status, args := movieStatusSQL(now, cityID)
+	var rows []movieRow
+	if err := query.Select("movies.*, "+status+" AS derived_status", args...).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	movies := make([]models.Movie, len(rows))
+	for i, row := range rows {
+		movies[i] = row.Movie
+		movies[i].MovieStatus = row.DerivedStatus
+	}
+	return movies, nil
+}
+
+func (r *movieRepository) List(ctx context.Context) ([]models.Movie, error) {
+	return findMovies(r.db.WithContext(ctx).Model(&models.Movie{}).Order("movie_id"), time.Now(), 0)
+}
+
+// movieSortColumns lists the columns of each sort, movies without a release
+// date sort after the dated ones and then by the start of their run
+var movieSortColumns = map[repository.MovieSort][]string{
+	repository.MovieSortRating:      {"rating"},
+	repository.MovieSortReleaseDate: {"release_date", "start_date"},
+	repository.MovieSortName:        {"movie_name"},
+}
+
+func (r *movieRepository) Search(ctx context.Context, filter repository.MovieFilter) ([]models.Movie, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Movie{})
+	if filter.Query != "" {
+		pattern := "%" + escapeLike(filter.Query) + "%"
+		query = query.Where("(movie_name ILIKE ? OR movie_description ILIKE ?)", pattern, pattern)
+	}
+	if filter.Genre != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM jsonb_array_elements_text(genres) AS g WHERE LOWER(g) = LOWER(?))", filter.Genre)
+	}
+	if filter.Language != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM jsonb_array_elements_text(languages::jsonb) AS lang WHERE LOWER(lang) = LOWER(?))", filter.Language)
+	}
+	now := filter.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	if filter.Status != "" {
+		status, args := movieStatusSQL(now, filter.CityID)
+		query = query.Where("LOWER("+status+") = LOWER(?)", append(args, filter.Status)...)
+	}
+	if filter.From != nil {
+		query = query.Where("end_date >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("start_date <= ?", *filter.To)
+	}
+
+	// a new session so counting does not leak into the page query
+	query = query.Session(&gorm.Session{})
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if columns, ok := movieSortColumns[filter.Sort]; ok {
+		direction := " ASC"
+		if filter.Descending {
+			direction = " DESC"
+		}
+		for _, column := range columns {
+			query = query.Order(column + direction)
+		}
+	}
+	query = query.Order("movie_id")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+
+	movies, err := findMovies(query, now, filter.CityID)
+	return movies, total, err
+}
+
+func (r *movieRepository) Get(ctx context.Context, id int) (*models.Movie, error) {
+	movies, err := findMovies(r.db.WithContext(ctx).Model(&models.Movie{}).Where("movie_id = ?", id), time.Now(), 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(movies) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return &movies[0], nil
+}
+
+func (r *movieRepository) GetByExternalID(ctx context.Context, externalID string) (*models.Movie, error) {
+	movies, err := findMovies(r.db.WithContext(ctx).Model(&models.Movie{}).Where("external_id = ?", externalID), time.Now(), 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(movies) == 0 {
+		return nil, repository.ErrNotFound
+	}
+	return &movies[0], nil
+}
+
+func (r *movieRepository) Update(ctx context.Context, movie *models.Movie) error {
+	return r.db.WithContext(ctx).Save(movie).Error
+}
+
+func (r *movieRepository) Delete(ctx context.Context, id int) error {
+	return deleted(r.db.WithContext(ctx).Delete(&models.Movie{}, id))
+}
+
+type stateRepository struct {
+	db *gorm.DB
+}
+
+func (r *stateRepository) Create(ctx context.Context, state *models.State) error {
+	return r.db.WithContext(ctx).Create(state).Error
+}
+
+func (r *stateRepository) List(ctx context.Context) ([]models.State, error) {
+	var states []models.State
+	err := r.db.WithContext(ctx).Order("state_id").Find(&states).Error
+	return states, err
+}
+
+func (r *stateRepository) Get(ctx context.Context, id int) (*models.State, error) {
+	var state models.State
+	if err := r.db.WithContext(ctx).First(&state, "state_id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &state, nil
+}
+
+func (r *stateRepository) Update(ctx context.Context, state *models.State) error {
+	return r.db.WithContext(ctx).Save(state).Error
+}
+
+func (r *stateRepository) Delete(ctx context.Context, id int) error {
+	return deleted(r.db.WithContext(ctx).Delete(&models.State{}, "state_id = ?", id))
+}
+
+type cityRepository struct {
+	db *gorm.DB
+}
+
+func (r *cityRepository) Create(ctx context.Context, city *models.City) error {
+	return r.db.WithContext(ctx).Create(city).Error
+}
+
+func (r *cityRepository) List(ctx context.Context) ([]models.City, error) {
+	var cities []models.City
+	err := r.db.WithContext(ctx).Order("city_id").Find(&cities).Error
+	return cities, err
+}
+
+func (r *cityRepository) Get(ctx context.Context, id int) (*models.City, error) {
+	var city models.City
+	if err := r.db.WithContext(ctx).First(&city, "city_id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &city, nil
+}
+
+func (r *cityRepository) Update(ctx context.Context, city *models.City) error {
+	return r.db.WithContext(ctx).Save(city).Error
+}
+
+func (r *cityRepository) Delete(ctx context.Context, id int) error {
+	return deleted(r.db.WithContext(ctx).Delete(&models.City{}, "city_id = ?", id))
+}
+
+func (r *cityRepository) Location(ctx context.Context, cityID int) (*time.Location, error) {
+	var city models.City
+	if err := r.db.WithContext(ctx).First(&city, "city_id = ?", cityID).Error; err != nil {
+		return nil, translate(err)
+	}
+	return models.LoadLocation(city.Timezone)
+}
+
+type theatreRepository struct {
+	db *gorm.DB
+}
+
+func (r *theatreRepository) Create(ctx context.Context, theatre *models.Theatre) error {
+	return r.db.WithContext(ctx).Create(theatre).Error
+}
+
+func (r *theatreRepository) List(ctx context.Context) ([]models.Theatre, error) {
+	var theatres []models.Theatre
+	err := r.db.WithContext(ctx).Find(&theatres).Error
+	return theatres, err
+}
+
+func (r *theatreRepository) Get(ctx context.Context, id int) (*models.Theatre, error) {
+	var theatre models.Theatre
+	if err := r.db.WithContext(ctx).First(&theatre, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &theatre, nil
+}
+
+func (r *theatreRepository) Update(ctx context.Context, theatre *models.Theatre) error {
+	return r.db.WithContext(ctx).Save(theatre).Error
+}
+
+func (r *theatreRepository) Delete(ctx context.Context, id int) error {
+	return deleted(r.db.WithContext(ctx).Delete(&models.Theatre{}, id))
+}
+
+func (r *theatreRepository) CreateScreen(ctx context.Context, screen *models.Screen) error {
+	return r.db.WithContext(ctx).Create(screen).Error
+}
+
+func (r *theatreRepository) ListScreens(ctx context.Context, theatreID int) ([]models.Screen, error) {
+	var screens []models.Screen
+	err := r.db.WithContext(ctx).Where("theatre_id = ?", theatreID).Order("screen_name").Find(&screens).Error
+	return screens, err
+}
+
+func (r *theatreRepository) Location(ctx context.Context, theatreID int) (*time.Location, error) {
+	var city models.City
+	err := r.db.WithContext(ctx).Joins("JOIN theatres ON theatres.city_id = cities.city_id").
+		Where("theatres.theatre_id = ?", theatreID).First(&city).Error
+	if err != nil {
+		return nil, translate(err)
+	}
+	return models.LoadLocation(city.Timezone)
+}
+
+func (r *theatreRepository) GetScreen(ctx context.Context, theatreID, screenID int) (*models.Screen, error) {
+	var screen models.Screen
+	err := r.db.WithContext(ctx).First(&screen, "screen_id = ? AND theatre_id = ?", screenID, theatreID).Error
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &screen, nil
+}
+
+type showRepository struct {
+	db *gorm.DB
+}
+
+func (r *showRepository) Create(ctx context.Context, show *models.Show) error {
+	return r.db.WithContext(ctx).Omit("Movie", "Theatre").Create(show).Error
+}
+
+func (r *showRepository) List(ctx context.Context) ([]models.Show, error) {
+	var shows []models.Show
+	err := r.db.WithContext(ctx).Preload("Movie").Preload("Theatre").Find(&shows).Error
+	return shows, err
+}
+
+func (r *showRepository) Get(ctx context.Context, id uint) (*models.Show, error) {
+	var show models.Show
+	if err := r.db.WithContext(ctx).Preload("Movie").Preload("Theatre").First(&show, "show_id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &show, nil
+}
+
+func (r *showRepository) Update(ctx context.Context, show *models.Show) error {
+	// without Omit, Save would also upsert the preloaded movie and theatre
+	return r.db.WithContext(ctx).Omit("Movie", "Theatre").Save(show).Error
+}
+
+func (r *showRepository) Delete(ctx context.Context, id uint) error {
+	return deleted(r.db.WithContext(ctx).Delete(&models.Show{}, "show_id = ?", id))
+}
+
+func (r *showRepository) ListOnScreen(ctx context.Context, theatreID int, screenID *int, from, to time.Time) ([]models.Show, error) {
+	query := r.db.WithContext(ctx).Preload("Movie").
+		Where("theatre_id = ?", theatreID).
+		Where("shows.date >= ? AND shows.date < ?", from, to.AddDate(0, 0, 1))
+	if screenID == nil {
+		query = query.Where("screen_id IS NULL")
+	} else {
+		query = query.Where("screen_id = ?", *screenID)
+	}
+	var shows []models.Show
+	err := query.Order("starts_at, show_id").Find(&shows).Error
+	return shows, err
+}
+
+func (r *showRepository) ListByMovie(ctx context.Context, movieID int, from time.Time) ([]models.Show, error) {
+	var shows []models.Show
+	err := r.db.WithContext(ctx).
+		Where("movie_id = ? AND shows.date >= ?", movieID, from).
+		Order("starts_at, show_id").Find(&shows).Error
+	return shows, err
+}
+
+func (r *showRepository) LockScreen(ctx context.Context, theatreID int, screenID *int) error {
+	// serial ids start at 1, 0 stands for the shows without a screen
+	screen := 0
+	if screenID != nil {
+		screen = *screenID
+	}
+	return r.db.WithContext(ctx).Exec("SELECT pg_advisory_xact_lock(?::int, ?::int)", theatreID, screen).Error
+}
+
+func (r *showRepository) ListShowtimes(ctx context.Context, filter repository.ShowtimeFilter) ([]repository.Showtime, error) {
+	db := r.db.WithContext(ctx)
+	query := db.Preload("Movie").Preload("Theatre").
+		Where("theatre_id IN (SELECT theatre_id FROM theatres WHERE city_id = ?)", filter.CityID).
+		Where("shows.date >= ? AND shows.date < ?", filter.Date, filter.Date.AddDate(0, 0, 1))
+	if filter.MovieID != 0 {
+		query = query.Where("movie_id = ?", filter.MovieID)
+	}
+	if filter.Language != "" {
+		query = query.Where("EXISTS (SELECT 1 FROM jsonb_array_elements_text(languages) AS lang WHERE LOWER(lang) = LOWER(?))", filter.Language)
+	}
+	var shows []models.Show
+	if err := query.Order("starts_at, show_id").Find(&shows).Error; err != nil {
+		return nil, err
+	}
+	if len(shows) == 0 {
+		return nil, nil
+	}
+
+	showIDs := make([]uint, len(shows))
+	var screenIDs []int
+	for i, show := range shows {
+		showIDs[i] = show.ShowID
+		if show.ScreenID != nil {
+			screenIDs = append(screenIDs, *show.ScreenID)
+		}
+	}
+
+	screens := map[int]models.Screen{}
+	if len(screenIDs) > 0 {
+		var rows []models.Screen
+		if err := db.Where("screen_id IN ?", screenIDs).Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, screen := range rows {
+			screens[screen.ScreenID] = screen
+		}
+	}
+
+	var counts []struct {
+		ShowID uint
+		Booked int
+	}
+	err := db.Model(&models.SeatBooking{}).Select("show_id, COUNT(*) AS booked").
+		Where("show_id IN ?", showIDs).Group("show_id").Scan(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+	booked := make(map[uint]int, len(counts))
+	for _, count := range counts {
+		booked[count.ShowID] = count.Booked
+	}
+
+	showtimes := make([]repository.Showtime, len(shows))
+	for i, show := range shows {
+		showtimes[i] = repository.Showtime{Show: show, Capacity: show.Theatre.TotalSeats, BookedSeats: booked[show.ShowID]}
+		if show.ScreenID != nil {
+			screen := screens[*show.ScreenID]
+			showtimes[i].ScreenName = screen.ScreenName
+			showtimes[i].Capacity = screen.TotalSeats
+		}
+	}
+	return showtimes, nil
+}
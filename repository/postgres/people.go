@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"backend/models"
+	"backend/repository"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+type personRepository struct {
+	db *gorm.DB
+}
+
+func (r *personRepository) Create(ctx context.Context, person *models.Person) error {
+	return r.db.WithContext(ctx).Create(person).Error
+}
+
+func (r *personRepository) List(ctx context.Context, filter repository.PersonFilter) ([]models.Person, int64, error) {
+	query := r.db.WithContext(ctx).Model(&models.Person{})
+	if filter.Query != "" {
+		query = query.Where("name ILIKE ?", "%"+escapeLike(filter.Query)+"%")
+	}
+
+	query = query.Session(&gorm.Session{})
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = query.Order("LOWER(name), person_id")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.Offset > 0 {
+		query = query.Offset(filter.Offset)
+	}
+	var people []models.Person
+	err := query.Find(&people).Error
+	return people, total, err
+}
+
+func (r *personRepository) Get(ctx context.Context, id int) (*models.Person, error) {
+	var person models.Person
+	if err := r.db.WithContext(ctx).First(&person, "person_id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &person, nil
+}
+
+func (r *personRepository) GetByExternalID(ctx context.Context, externalID string) (*models.Person, error) {
+	var person models.Person
+	if err := r.db.WithContext(ctx).First(&person, "external_id = ?", externalID).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &person, nil
+}
+
+func (r *personRepository) Update(ctx context.Context, person *models.Person) error {
+	return r.db.WithContext(ctx).Save(person).Error
+}
+
+func (r *personRepository) Delete(ctx context.Context, id int) error {
+	return deleted(r.db.WithContext(ctx).Delete(&models.Person{}, "person_id = ?", id))
+}
+
+func (r *personRepository) AddCredit(ctx context.Context, credit *models.MovieCredit) error {
+	err := r.db.WithContext(ctx).Omit("Movie", "Person").Create(credit).Error
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch pgErr.Code {
+		case "23505":
+			return repository.ErrDuplicate
+		case "23503":
+			// the movie or the person was deleted meanwhile
+			return repository.ErrNotFound
+		}
+	}
+	return err
+}
+
+func (r *personRepository) UpdateCredit(ctx context.Context, credit *models.MovieCredit) error {
+	return deleted(r.db.WithContext(ctx).Model(&models.MovieCredit{}).Where("credit_id = ?", credit.CreditID).
+		Updates(map[string]interface{}{"character_name": credit.Character, "billing_order": credit.BillingOrder}))
+}
+
+func (r *personRepository) DeleteCredit(ctx context.Context, movieID, creditID int) error {
+	return deleted(r.db.WithContext(ctx).Delete(&models.MovieCredit{}, "credit_id = ? AND movie_id = ?", creditID, movieID))
+}
+
+func (r *personRepository) ListCredits(ctx context.Context, movieID int) ([]models.MovieCredit, error) {
+	var credits []models.MovieCredit
+	err := r.db.WithContext(ctx).Joins("Person").
+		Where("movie_credits.movie_id = ?", movieID).
+		Order("movie_credits.billing_order, movie_credits.credit_id").
+		Find(&credits).Error
+	return credits, err
+}
+
+func (r *personRepository) Filmography(ctx context.Context, personID int) ([]models.MovieCredit, error) {
+	var credits []models.MovieCredit
+	// movies without a release date are ordered by the start of their run
+	err := r.db.WithContext(ctx).Joins("Movie").
+		Where("movie_credits.person_id = ?", personID).
+		Order(`COALESCE("Movie".release_date, "Movie".start_date::date) DESC, movie_credits.credit_id`).
+		Find(&credits).Error
+	return credits, err
+}
@@ -0,0 +1,69 @@
+// Package postgres implements the repository interfaces on top of gorm.
+package postgres
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"backend/repository"
+
+	"gorm.io/gorm"
+)
+
+// New returns the repositories backed by db.
+func New(db *gorm.DB) repository.Repositories {
+	return repository.Repositories{
+		Transactor: &transactor{db: db},
+		Movies:     &movieRepository{db: db},
+		People:     &personRepository{db: db},
+		States:     &stateRepository{db: db},
+		Cities:     &cityRepository{db: db},
+		Theatres:   &theatreRepository{db: db},
+		Shows:      &showRepository{db: db},
+		Reviews:    &reviewRepository{db: db},
+		Bookings:   &bookingRepository{db: db},
+		Payments:   &paymentRepository{db: db},
+		Users:      &userRepository{db: db},
+		Sessions:   &sessionRepository{db: db},
+		Security:   &securityRepository{db: db},
+	}
+}
+
+type transactor struct {
+	db *gorm.DB
+}
+
+// Transaction runs fn in a database transaction, or in a savepoint when db is
+// already in one.
+func (t *transactor) Transaction(ctx context.Context, fn func(repository.Repositories) error) error {
+	return t.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(New(tx))
+	})
+}
+
+// translate maps gorm errors onto the repository ones.
+func translate(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return repository.ErrNotFound
+	}
+	return err
+}
+
+// deleted reports ErrNotFound when a delete or update touched no row.
+func deleted(result *gorm.DB) error {
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// escapeLike makes user input match literally inside a LIKE pattern.
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"backend/models"
+	"backend/repository"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"gorm.io/gorm"
+)
+
+type reviewRepository struct {
+	db *gorm.DB
+}
+
+func (r *reviewRepository) Create(ctx context.Context, review *models.Review) error {
+	err := r.db.WithContext(ctx).Create(review).Error
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return repository.ErrDuplicate
+	}
+	return err
+}
+
+func (r *reviewRepository) List(ctx context.Context) ([]models.Review, error) {
+	var reviews []models.Review
+	err := r.db.WithContext(ctx).Order("review_id").Find(&reviews).Error
+	return reviews, err
+}
+
+func (r *reviewRepository) Get(ctx context.Context, id uint) (*models.Review, error) {
+	var review models.Review
+	if err := r.db.WithContext(ctx).First(&review, "review_id = ?", id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &review, nil
+}
+
+func (r *reviewRepository) Update(ctx context.Context, review *models.Review) error {
+	return r.db.WithContext(ctx).Save(review).Error
+}
+
+func (r *reviewRepository) Delete(ctx context.Context, id uint) error {
+	return deleted(r.db.WithContext(ctx).Delete(&models.Review{}, "review_id = ?", id))
+}
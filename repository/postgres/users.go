@@ -0,0 +1,266 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"backend/models"
+	"backend/repository"
+
+	"gorm.io/gorm"
+)
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *userRepository) Get(ctx context.Context, id int) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).First(&user, id).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	if err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &user, nil
+}
+
+func (r *userRepository) EmailOrNameTaken(ctx context.Context, email, name string, exceptUserID int) (bool, error) {
+	if email == "" && name == "" {
+		return false, nil
+	}
+	query := r.db.WithContext(ctx).Model(&models.User{}).Where("user_id <> ?", exceptUserID)
+	switch {
+	case email != "" && name != "":
+		query = query.Where("email = ? OR name = ?", email, name)
+	case email != "":
+		query = query.Where("email = ?", email)
+	default:
+		query = query.Where("name = ?", name)
+	}
+	var count int64
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+func (r *userRepository) UpdateProfile(ctx context.Context, userID int, changes repository.ProfileChanges, at time.Time) error {
+	columns := map[string]interface{}{"updated_at": at}
+	if changes.Name != nil {
+		columns["name"] = *changes.Name
+	}
+	if changes.Phone != nil {
+		columns["phone"] = *changes.Phone
+	}
+	if changes.Preferences != nil {
+		columns["preferences"] = *changes.Preferences
+	}
+	if changes.DateOfBirth != nil {
+		columns["date_of_birth"] = *changes.DateOfBirth
+	}
+	result := r.db.WithContext(ctx).Model(&models.User{}).Where("user_id = ?", userID).Updates(columns)
+	if result.Error != nil {
+		return translate(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return repository.ErrNotFound
+	}
+	return nil
+}
+
+func (r *userRepository) UpdateLoginState(ctx context.Context, userID, failedAttempts int, lastFailedAt, lockedUntil *time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.User{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+		"failed_login_attempts": failedAttempts,
+		"last_failed_login_at":  lastFailedAt,
+		"locked_until":          lockedUntil,
+	}).Error
+}
+
+func (r *userRepository) RegisterFailedLogin(ctx context.Context, userID int, at time.Time, window time.Duration, maxFailures int, lockedUntil time.Time) (int, error) {
+	// SET expressions see the row as it was, so the new count is spelled out
+	// twice
+	count := "CASE WHEN last_failed_login_at IS NULL OR last_failed_login_at < @since THEN 1 ELSE failed_login_attempts + 1 END"
+	var failures []int
+	err := r.db.WithContext(ctx).Raw(`UPDATE users SET
+		failed_login_attempts = `+count+`,
+		last_failed_login_at = @at,
+		locked_until = CASE WHEN `+count+` >= @max THEN @until ELSE locked_until END
+		WHERE user_id = @id RETURNING failed_login_attempts`,
+		map[string]interface{}{"id": userID, "at": at, "since": at.Add(-window), "max": maxFailures, "until": lockedUntil},
+	).Scan(&failures).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(failures) == 0 {
+		return 0, repository.ErrNotFound
+	}
+	return failures[0], nil
+}
+
+func (r *userRepository) ChangePassword(ctx context.Context, userID int, passwordHash, keepSessionID string, at time.Time) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("user_id = ?", userID).Updates(map[string]interface{}{
+			"password_hash": passwordHash,
+			"updated_at":    at,
+		}).Error; err != nil {
+			return err
+		}
+		return revokeAllExcept(tx, userID, keepSessionID, at)
+	})
+}
+
+func (r *userRepository) GetIdentity(ctx context.Context, issuer, subject string) (*models.UserIdentity, error) {
+	var identity models.UserIdentity
+	if err := r.db.WithContext(ctx).Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error; err != nil {
+		return nil, translate(err)
+	}
+	return &identity, nil
+}
+
+func (r *userRepository) CreateIdentity(ctx context.Context, identity *models.UserIdentity) error {
+	return r.db.WithContext(ctx).Create(identity).Error
+}
+
+func (r *userRepository) ListIdentities(ctx context.Context, userID int) ([]models.UserIdentity, error) {
+	var identities []models.UserIdentity
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Find(&identities).Error
+	return identities, err
+}
+
+func (r *userRepository) Anonymize(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.UserID).Delete(&models.UserIdentity{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.UserID).Delete(&models.Session{}).Error; err != nil {
+			return err
+		}
+		scrubbed := map[string]interface{}{"email": user.Email, "ip_address": ""}
+		if err := tx.Model(&models.LoginAttempt{}).Where("user_id = ?", user.UserID).Updates(scrubbed).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.SecurityEvent{}).Where("user_id = ?", user.UserID).Updates(scrubbed).Error; err != nil {
+			return err
+		}
+		// the gateway echoes the payer's name, email and phone back
+		bookings := tx.Model(&models.Booking{}).Select("booking_id").Where("user_id = ?", user.UserID)
+		if err := tx.Model(&models.Payment{}).Where("booking_id IN (?)", bookings).Update("payment_response", nil).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Review{}).Where("user_id = ?", user.UserID).Update("comments", "").Error
+	})
+}
+
+func (r *userRepository) ListReviews(ctx context.Context, userID int) ([]models.Review, error) {
+	var reviews []models.Review
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&reviews).Error
+	return reviews, err
+}
+
+type sessionRepository struct {
+	db *gorm.DB
+}
+
+func (r *sessionRepository) Create(ctx context.Context, session *models.Session) error {
+	return r.db.WithContext(ctx).Create(session).Error
+}
+
+func (r *sessionRepository) GetActive(ctx context.Context, sessionID string, userID int, now time.Time) (*models.Session, error) {
+	var session models.Session
+	err := r.db.WithContext(ctx).
+		Where("session_id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, userID, now).
+		First(&session).Error
+	if err != nil {
+		return nil, translate(err)
+	}
+	return &session, nil
+}
+
+func (r *sessionRepository) Touch(ctx context.Context, sessionID string, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.Session{}).Where("session_id = ?", sessionID).Update("last_seen_at", at).Error
+}
+
+func (r *sessionRepository) ListActive(ctx context.Context, userID int, now time.Time) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) ListByUser(ctx context.Context, userID int) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&sessions).Error
+	return sessions, err
+}
+
+func (r *sessionRepository) Revoke(ctx context.Context, userID int, sessionID string, at time.Time) error {
+	return deleted(r.db.WithContext(ctx).Model(&models.Session{}).
+		Where("session_id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", at))
+}
+
+func (r *sessionRepository) RevokeAllExcept(ctx context.Context, userID int, keepSessionID string, at time.Time) error {
+	return revokeAllExcept(r.db.WithContext(ctx), userID, keepSessionID, at)
+}
+
+func revokeAllExcept(db *gorm.DB, userID int, keepSessionID string, at time.Time) error {
+	return db.Model(&models.Session{}).
+		Where("user_id = ? AND session_id <> ? AND revoked_at IS NULL", userID, keepSessionID).
+		Update("revoked_at", at).Error
+}
+
+type securityRepository struct {
+	db *gorm.DB
+}
+
+func (r *securityRepository) RecordLoginAttempt(ctx context.Context, attempt *models.LoginAttempt) error {
+	return r.db.WithContext(ctx).Create(attempt).Error
+}
+
+func (r *securityRepository) CountFailedLoginsFromIP(ctx context.Context, ip string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.LoginAttempt{}).
+		Where("ip_address = ? AND success = ? AND created_at > ?", ip, false, since).
+		Count(&count).Error
+	return count, err
+}
+
+func (r *securityRepository) ListLoginAttempts(ctx context.Context, userID int) ([]models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at").Find(&attempts).Error
+	return attempts, err
+}
+
+func (r *securityRepository) RecordEvent(ctx context.Context, event *models.SecurityEvent) error {
+	return r.db.WithContext(ctx).Create(event).Error
+}
+
+func (r *securityRepository) ListEvents(ctx context.Context, filter repository.SecurityEventFilter) ([]models.SecurityEvent, error) {
+	query := r.db.WithContext(ctx).Order("created_at DESC")
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.EventType != "" {
+		query = query.Where("event_type = ?", filter.EventType)
+	}
+
+	var events []models.SecurityEvent
+	err := query.Find(&events).Error
+	return events, err
+}
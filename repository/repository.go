@@ -0,0 +1,311 @@
+// Package repository defines the persistence interfaces the HTTP handlers
+// depend on. The postgres sub-package implements them with gorm, the memory
+// sub-package keeps everything in process for unit tests and local tooling.
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"backend/models"
+
+	"gorm.io/datatypes"
+)
+
+var (
+	ErrNotFound   = errors.New("record not found")
+	ErrSeatBooked = errors.New("seat already booked")
+	ErrDuplicate  = errors.New("duplicate record")
+)
+
+// MovieSort orders movie search results, ties are broken by movie id.
+type MovieSort string
+
+const (
+	MovieSortID          MovieSort = ""
+	MovieSortRating      MovieSort = "rating"
+	MovieSortReleaseDate MovieSort = "release_date"
+	MovieSortName        MovieSort = "name"
+)
+
+// MovieFilter narrows a movie search, zero values match everything.
+type MovieFilter struct {
+	// Query is matched case-insensitively against name and description.
+	Query    string
+	Genre    string
+	Language string
+	// Status matches the derived status, see models.MovieStatusOn.
+	Status string
+	// CityID limits the shows that make a movie Now Showing to the theatres
+	// of the city.
+	CityID int
+	// Now is the time statuses are derived at, zero is the current time.
+	Now time.Time
+	// From and To select movies whose run overlaps the range.
+	From *time.Time
+	To   *time.Time
+
+	Sort       MovieSort
+	Descending bool
+	// Limit 0 returns every match.
+	Limit  int
+	Offset int
+}
+
+// Day truncates t to the start of its UTC day, the granularity of movie runs
+// and show dates.
+func Day(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// LocalDay returns the date t falls on in loc at UTC midnight, the way show
+// dates are stored.
+func LocalDay(t time.Time, loc *time.Location) time.Time {
+	t = t.In(loc)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// MovieRepository returns movies with MovieStatus derived for the current day
+// rather than the stored value.
+type MovieRepository interface {
+	Create(ctx context.Context, movie *models.Movie) error
+	List(ctx context.Context) ([]models.Movie, error)
+	// Search returns a page of the matching movies and how many match in
+	// total.
+	Search(ctx context.Context, filter MovieFilter) ([]models.Movie, int64, error)
+	Get(ctx context.Context, id int) (*models.Movie, error)
+	// GetByExternalID finds a movie by its id in the catalogue it was
+	// imported from.
+	GetByExternalID(ctx context.Context, externalID string) (*models.Movie, error)
+	Update(ctx context.Context, movie *models.Movie) error
+	Delete(ctx context.Context, id int) error
+}
+
+// PersonFilter narrows a people listing, zero values match everything.
+type PersonFilter struct {
+	// Query is matched case-insensitively against the name.
+	Query string
+	// Limit 0 returns every match.
+	Limit  int
+	Offset int
+}
+
+type PersonRepository interface {
+	Create(ctx context.Context, person *models.Person) error
+	// List returns a page of the matching people by name and how many match
+	// in total.
+	List(ctx context.Context, filter PersonFilter) ([]models.Person, int64, error)
+	Get(ctx context.Context, id int) (*models.Person, error)
+	GetByExternalID(ctx context.Context, externalID string) (*models.Person, error)
+	Update(ctx context.Context, person *models.Person) error
+	// Delete removes the person along with their credits.
+	Delete(ctx context.Context, id int) error
+
+	// AddCredit returns ErrDuplicate when the person already has the role,
+	// with the same job, in the movie.
+	AddCredit(ctx context.Context, credit *models.MovieCredit) error
+	// UpdateCredit stores the character and billing order of a credit.
+	UpdateCredit(ctx context.Context, credit *models.MovieCredit) error
+	DeleteCredit(ctx context.Context, movieID, creditID int) error
+	// ListCredits returns the credits of a movie with Person populated, in
+	// billing order.
+	ListCredits(ctx context.Context, movieID int) ([]models.MovieCredit, error)
+	// Filmography returns the credits of a person with Movie populated,
+	// latest release first.
+	Filmography(ctx context.Context, personID int) ([]models.MovieCredit, error)
+}
+
+type TheatreRepository interface {
+	Create(ctx context.Context, theatre *models.Theatre) error
+	List(ctx context.Context) ([]models.Theatre, error)
+	Get(ctx context.Context, id int) (*models.Theatre, error)
+	Update(ctx context.Context, theatre *models.Theatre) error
+	Delete(ctx context.Context, id int) error
+
+	CreateScreen(ctx context.Context, screen *models.Screen) error
+	ListScreens(ctx context.Context, theatreID int) ([]models.Screen, error)
+	GetScreen(ctx context.Context, theatreID, screenID int) (*models.Screen, error)
+	// Location returns the time zone of the theatre's city, the one its
+	// shows are scheduled in.
+	Location(ctx context.Context, theatreID int) (*time.Location, error)
+}
+
+type StateRepository interface {
+	Create(ctx context.Context, state *models.State) error
+	List(ctx context.Context) ([]models.State, error)
+	Get(ctx context.Context, id int) (*models.State, error)
+	Update(ctx context.Context, state *models.State) error
+	Delete(ctx context.Context, id int) error
+}
+
+type CityRepository interface {
+	Create(ctx context.Context, city *models.City) error
+	List(ctx context.Context) ([]models.City, error)
+	Get(ctx context.Context, id int) (*models.City, error)
+	Update(ctx context.Context, city *models.City) error
+	Delete(ctx context.Context, id int) error
+	// Location returns the time zone of the city.
+	Location(ctx context.Context, cityID int) (*time.Location, error)
+}
+
+// ShowtimeFilter selects the shows of one day in a city.
+type ShowtimeFilter struct {
+	CityID int
+	// Date is a day as returned by Day.
+	Date time.Time
+	// MovieID 0 matches every movie.
+	MovieID  int
+	Language string
+}
+
+// Showtime is a show with its seat availability.
+type Showtime struct {
+	// Show has Movie and Theatre populated.
+	Show       models.Show
+	ScreenName string
+	// Capacity is the screen's seat count, or the theatre's for shows
+	// without a screen.
+	Capacity    int
+	BookedSeats int
+}
+
+type ShowRepository interface {
+	Create(ctx context.Context, show *models.Show) error
+	// List and Get return shows with Movie and Theatre populated.
+	List(ctx context.Context) ([]models.Show, error)
+	Get(ctx context.Context, id uint) (*models.Show, error)
+	Update(ctx context.Context, show *models.Show) error
+	Delete(ctx context.Context, id uint) error
+	// ListOnScreen returns the shows of a screen dated between the days from
+	// and to inclusive, with Movie populated, in start order. A nil screenID
+	// lists the shows of the theatre that have no screen.
+	ListOnScreen(ctx context.Context, theatreID int, screenID *int, from, to time.Time) ([]models.Show, error)
+	// ListByMovie returns the shows of a movie dated on or after the day
+	// from, in start order.
+	ListByMovie(ctx context.Context, movieID int, from time.Time) ([]models.Show, error)
+	// ListShowtimes returns the matching shows ordered by start time.
+	ListShowtimes(ctx context.Context, filter ShowtimeFilter) ([]Showtime, error)
+	// LockScreen holds the schedule of a screen, or of the theatre's shows
+	// without a screen when screenID is nil, until the transaction it is
+	// called in ends, so that a schedule check stays true until the shows are
+	// saved. Outside a transaction it has no effect.
+	LockScreen(ctx context.Context, theatreID int, screenID *int) error
+}
+
+type ReviewRepository interface {
+	// Create returns ErrDuplicate when the user already reviewed the movie.
+	Create(ctx context.Context, review *models.Review) error
+	List(ctx context.Context) ([]models.Review, error)
+	Get(ctx context.Context, id uint) (*models.Review, error)
+	Update(ctx context.Context, review *models.Review) error
+	Delete(ctx context.Context, id uint) error
+}
+
+type BookingRepository interface {
+	Create(ctx context.Context, booking *models.Booking) error
+	GetByTxnID(ctx context.Context, txnID string) (*models.Booking, error)
+	UpdateStatusByTxnID(ctx context.Context, txnID, status string) error
+	ListByUser(ctx context.Context, userID int) ([]models.Booking, error)
+
+	// BookSeat returns ErrSeatBooked when the seat of the show is taken.
+	BookSeat(ctx context.Context, seat *models.SeatBooking) error
+	ListSeats(ctx context.Context, showID uint) ([]models.SeatBooking, error)
+	ListSeatsByUser(ctx context.Context, userID int) ([]models.SeatBooking, error)
+}
+
+type PaymentRepository interface {
+	Create(ctx context.Context, payment *models.Payment) error
+	ListByUser(ctx context.Context, userID int) ([]models.Payment, error)
+}
+
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	Get(ctx context.Context, id int) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	// EmailOrNameTaken reports whether another user than exceptUserID uses
+	// email or name. Empty values are ignored.
+	EmailOrNameTaken(ctx context.Context, email, name string, exceptUserID int) (bool, error)
+	// UpdateProfile stores only the changed profile fields of a user.
+	UpdateProfile(ctx context.Context, userID int, changes ProfileChanges, at time.Time) error
+	// UpdateLoginState stores the brute-force protection counters of a user.
+	UpdateLoginState(ctx context.Context, userID, failedAttempts int, lastFailedAt, lockedUntil *time.Time) error
+	// RegisterFailedLogin counts a failed login at the given time in one
+	// atomic step, starting over when the previous failure is older than
+	// window, and locks the account until lockedUntil once the count reaches
+	// maxFailures. It returns the new count.
+	RegisterFailedLogin(ctx context.Context, userID int, at time.Time, window time.Duration, maxFailures int, lockedUntil time.Time) (int, error)
+	// ChangePassword stores a new password hash and revokes every other
+	// active session of the user in one unit of work.
+	ChangePassword(ctx context.Context, userID int, passwordHash, keepSessionID string, at time.Time) error
+
+	GetIdentity(ctx context.Context, issuer, subject string) (*models.UserIdentity, error)
+	CreateIdentity(ctx context.Context, identity *models.UserIdentity) error
+	ListIdentities(ctx context.Context, userID int) ([]models.UserIdentity, error)
+
+	// Anonymize saves the already scrubbed user, drops its identities and
+	// sessions and scrubs its audit records, payment gateway responses and
+	// review texts in one unit of work.
+	Anonymize(ctx context.Context, user *models.User) error
+
+	ListReviews(ctx context.Context, userID int) ([]models.Review, error)
+}
+
+// ProfileChanges lists the profile fields to store, nil fields are left
+// alone. A non-nil Preferences holding null clears the preferences.
+type ProfileChanges struct {
+	Name        *string
+	Phone       *string
+	Preferences *datatypes.JSON
+	DateOfBirth *time.Time
+}
+
+type SessionRepository interface {
+	Create(ctx context.Context, session *models.Session) error
+	// GetActive returns a session that is neither revoked nor expired at now.
+	GetActive(ctx context.Context, sessionID string, userID int, now time.Time) (*models.Session, error)
+	Touch(ctx context.Context, sessionID string, at time.Time) error
+	ListActive(ctx context.Context, userID int, now time.Time) ([]models.Session, error)
+	ListByUser(ctx context.Context, userID int) ([]models.Session, error)
+	// Revoke returns ErrNotFound when userID has no such active session.
+	Revoke(ctx context.Context, userID int, sessionID string, at time.Time) error
+	RevokeAllExcept(ctx context.Context, userID int, keepSessionID string, at time.Time) error
+}
+
+type SecurityEventFilter struct {
+	UserID    *int
+	EventType string
+	Limit     int
+}
+
+type SecurityRepository interface {
+	RecordLoginAttempt(ctx context.Context, attempt *models.LoginAttempt) error
+	CountFailedLoginsFromIP(ctx context.Context, ip string, since time.Time) (int64, error)
+	ListLoginAttempts(ctx context.Context, userID int) ([]models.LoginAttempt, error)
+
+	RecordEvent(ctx context.Context, event *models.SecurityEvent) error
+	ListEvents(ctx context.Context, filter SecurityEventFilter) ([]models.SecurityEvent, error)
+}
+
+// Transactor runs units of work. The repositories passed to fn share one
+// transaction and every write through them is rolled back when fn returns an
+// error. Nested calls roll back only their own writes.
+type Transactor interface {
+	Transaction(ctx context.Context, fn func(Repositories) error) error
+}
+
+type Repositories struct {
+	Transactor
+	Movies   MovieRepository
+	People   PersonRepository
+	States   StateRepository
+	Cities   CityRepository
+	Theatres TheatreRepository
+	Shows    ShowRepository
+	Reviews  ReviewRepository
+	Bookings BookingRepository
+	Payments PaymentRepository
+	Users    UserRepository
+	Sessions SessionRepository
+	Security SecurityRepository
+}
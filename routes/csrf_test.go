@@ -0,0 +1,92 @@
+package routes_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend/middlewares"
+	"backend/repository/memory"
+	"backend/routes"
+	"backend/testharness"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCSRFProtection(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := routes.New(testharness.Config(), nil, memory.New())
+
+	send := func(method, path string, header http.Header, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for name, values := range header {
+			for _, value := range values {
+				req.Header.Add(name, value)
+			}
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	// register returns the token of a fresh session, each case logs out
+	register := func(name string) string {
+		w := send(http.MethodPost, "/api/v1/register", nil,
+			fmt.Sprintf(`{"name":%q,"email":"%s@example.com","password":"Password123!"}`, name, name))
+		if w.Code != http.StatusOK {
+			t.Fatalf("register: %d %s", w.Code, w.Body)
+		}
+		var resp struct {
+			Token string `json:"token"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp.Token
+	}
+	cookies := func(token, csrf string) string {
+		return fmt.Sprintf("token=%s; %s=%s", token, middlewares.CSRFCookieName, csrf)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		header func(token string) http.Header
+		want   int
+	}{
+		{"cookie without the header", func(token string) http.Header {
+			return http.Header{"Cookie": {cookies(token, "csrf-secret")}}
+		}, http.StatusForbidden},
+		{"header not matching the cookie", func(token string) http.Header {
+			return http.Header{"Cookie": {cookies(token, "csrf-secret")}, middlewares.CSRFHeaderName: {"other"}}
+		}, http.StatusForbidden},
+		{"header matching the cookie", func(token string) http.Header {
+			return http.Header{"Cookie": {cookies(token, "csrf-secret")}, middlewares.CSRFHeaderName: {"csrf-secret"}}
+		}, http.StatusOK},
+		{"bearer token", func(token string) http.Header {
+			return http.Header{"Authorization": {"Bearer " + token}}
+		}, http.StatusOK},
+		// a junk Bearer header skips the check, it must not fall back to the
+		// cookie for authentication then
+		{"cookie with a junk bearer header", func(token string) http.Header {
+			return http.Header{"Cookie": {cookies(token, "csrf-secret")}, "Authorization": {"Bearer junk"}}
+		}, http.StatusUnauthorized},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			token := register(strings.ReplaceAll(tc.name, " ", "-"))
+			if w := send(http.MethodPost, "/api/v1/logout", tc.header(token), ""); w.Code != tc.want {
+				t.Errorf("expected %d, got %d: %s", tc.want, w.Code, w.Body)
+			}
+		})
+	}
+
+	// PayU posts its callbacks cross-site, they are verified by hash instead
+	for _, path := range []string{"/api/v1/payment/success", "/api/v1/payment/failure", "/api/payment/success"} {
+		w := send(http.MethodPost, path, http.Header{"Cookie": {cookies("any", "csrf-secret")}}, "")
+		if w.Code == http.StatusForbidden {
+			t.Errorf("POST %s was refused by the CSRF check: %s", path, w.Body)
+		}
+	}
+}
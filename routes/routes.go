@@ -0,0 +1,219 @@
+// Package routes wires the HTTP handlers to their dependencies and paths.
+package routes
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/gin-contrib/cors"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"gorm.io/gorm"
+
+	"backend/config"
+	"backend/controllers"
+	"backend/errs"
+	"backend/middlewares"
+	"backend/openapi"
+	"backend/repository"
+	"backend/storage"
+)
+
+var registerValidatorOnce sync.Once
+
+// registerValidator makes validation errors name fields by their json key, the
+// way clients sent them.
+func registerValidator() {
+	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(func(field reflect.StructField) string {
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" {
+				return ""
+			}
+			if name == "" {
+				return field.Name
+			}
+			return name
+		})
+	}
+}
+
+// New builds the router. db is only used by the readiness probe.
+func New(cfg *config.Config, db *gorm.DB, repos repository.Repositories) *gin.Engine {
+	users := controllers.NewUserHandler(cfg, repos)
+	movies := controllers.NewMovieHandler(cfg, repos.Transactor, repos.Movies, repos.Shows)
+	people := controllers.NewPersonHandler(repos.People, repos.Movies)
+	theatres := controllers.NewTheatreHandler(repos.Theatres)
+	states := controllers.NewStateHandler(repos.States)
+	cities := controllers.NewCityHandler(repos.Cities, repos.States)
+	reviews := controllers.NewReviewHandler(repos.Reviews)
+	shows := controllers.NewShowHandler(cfg, repos.Transactor, repos.Shows, repos.Movies, repos.Theatres, repos.Cities)
+	bookings := controllers.NewBookingHandler(repos.Bookings, repos.Shows)
+	payments := controllers.NewPaymentHandler(cfg, repos.Bookings, repos.Shows, repos.Users)
+	health := controllers.NewHealthHandler(db)
+	imports := controllers.NewImportHandler(cfg, repos.Transactor)
+	uploads := controllers.NewUploadHandler(cfg, storage.New(cfg.Storage), repos.Movies, repos.Theatres)
+
+	auth := middlewares.AuthMiddleware(cfg.JWT.Secret, repos.Users, repos.Sessions)
+	admin := middlewares.AdminMiddleware()
+
+	registerValidatorOnce.Do(registerValidator)
+
+	router := gin.Default()
+	router.Use(middlewares.RequestID(), middlewares.Errors())
+	router.NoRoute(func(c *gin.Context) {
+		errs.Abort(c, errs.NotFound("Route not found"))
+	})
+
+	// probes are unversioned, load balancers and orchestrators hard-code them
+	router.GET("/healthz", health.Healthz)
+	router.GET("/readyz", health.Readyz)
+
+	// the PayU callbacks are registered ahead of CORS and CSRF, PayU posts
+	// them cross-site and they are verified by hash instead
+	callbacks := newVersioned(router, "/api", cfg.API.LegacySunset)
+	callbacks.POST("/payment/success", payments.PaymentSuccessHandler)
+	callbacks.POST("/payment/failure", payments.PaymentFailureHandler)
+
+	router.Use(cors.New(cors.Config{
+		AllowOrigins:     cfg.CORS.AllowOrigins,
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", middlewares.CSRFHeaderName, middlewares.RequestIDHeader},
+		ExposeHeaders:    []string{"Content-Length", middlewares.RequestIDHeader, controllers.TotalCountHeader, "Deprecation", "Sunset", "Link"},
+		AllowCredentials: true,
+	}))
+
+	router.Use(middlewares.CSRFMiddleware())
+
+	router.GET(openapi.SpecPath, openapi.Handler(openapi.Spec(cfg.URLs.BaseURL)))
+	router.GET(openapi.UIPath, openapi.UIHandler("Movie Booking API", openapi.SpecPath))
+
+	// stored files are unversioned, their URLs are saved in the records
+	router.GET(controllers.MediaPath+"*key", uploads.ServeMedia)
+
+	// routes that used to live at the root and under /api
+	root := newVersioned(router, "", cfg.API.LegacySunset)
+	api := newVersioned(router, "/api", cfg.API.LegacySunset)
+
+	root.GET("/csrf-token", controllers.GetCSRFToken)
+
+	root.POST("/register", users.Register)
+	root.POST("/login", users.Login)
+	root.GET("/auth/oidc/login", users.OIDCLogin)
+	root.GET("/auth/oidc/callback", users.OIDCCallback)
+	root.GET("/me", auth, users.Me)
+	api.GET("/booking/:txnid", bookings.GetBookingDetails)
+
+	protected := api.Group("")
+	protected.Use(auth)
+	{
+		protected.GET("/profile", users.GetProfile)
+		protected.PUT("/profile", users.UpdateProfile)
+		protected.DELETE("/profile", users.DeleteAccount)
+		protected.GET("/profile/export", users.ExportUserData)
+		protected.PUT("/profile/password", users.ChangePassword)
+		protected.GET("/profile/sessions", users.GetSessions)
+		protected.DELETE("/profile/sessions", users.RevokeOtherSessions)
+		protected.DELETE("/profile/sessions/:id", users.RevokeSession)
+		protected.POST("/logout", users.Logout)
+
+		// Payment initiation (requires authentication)
+		protected.POST("/payment/initiate", payments.InitiatePayment)
+	}
+
+	adminRoutes := api.Group("/admin")
+	adminRoutes.Use(auth, admin)
+	{
+		adminRoutes.POST("/users/:id/unlock", users.UnlockUser)
+		adminRoutes.GET("/security-events", users.GetSecurityEvents)
+		adminRoutes.current.POST("/imports/tmdb", imports.ImportTMDB)
+	}
+
+	movieRoutes := root.Group("/movies")
+	{
+		movieRoutes.POST("", movies.CreateMovie)
+		movieRoutes.HandleVersions(http.MethodGet, "", movies.SearchMovies, movies.GetMovies)
+		// new listings are not added to the deprecated paths
+		movieRoutes.current.GET("/now-showing", movies.NowShowing)
+		movieRoutes.current.GET("/coming-soon", movies.ComingSoon)
+		movieRoutes.GET("/:id", movies.GetMovieByID)
+		movieRoutes.PUT("/:id", movies.UpdateMovie)
+		movieRoutes.PATCH("/:id", movies.PatchMovie)
+		movieRoutes.DELETE("/:id", movies.DeleteMovie)
+		movieRoutes.current.GET("/:id/credits", people.GetMovieCredits)
+		movieRoutes.current.POST("/:id/credits", auth, admin, people.AddMovieCredit)
+		movieRoutes.current.DELETE("/:id/credits/:credit_id", auth, admin, people.DeleteMovieCredit)
+		movieRoutes.current.POST("/:id/poster", auth, admin, uploads.UploadPoster)
+	}
+
+	// people came after the versioned API, they have no legacy paths
+	peopleRoutes := root.current.Group("/people")
+	{
+		peopleRoutes.POST("", auth, admin, people.CreatePerson)
+		peopleRoutes.GET("", people.GetPeople)
+		peopleRoutes.GET("/:id", people.GetPerson)
+		peopleRoutes.PUT("/:id", auth, admin, people.UpdatePerson)
+		peopleRoutes.DELETE("/:id", auth, admin, people.DeletePerson)
+		peopleRoutes.GET("/:id/filmography", people.GetFilmography)
+	}
+
+	theatreRoutes := root.Group("/theatres")
+	{
+		theatreRoutes.POST("", theatres.CreateTheatre)
+		theatreRoutes.GET("", theatres.GetTheatres)
+		theatreRoutes.GET("/:id", theatres.GetTheatreByID)
+		theatreRoutes.PUT("/:id", theatres.UpdateTheatre)
+		theatreRoutes.DELETE("/:id", theatres.DeleteTheatre)
+		theatreRoutes.GET("/:id/screens", theatres.GetScreens)
+		theatreRoutes.POST("/:id/screens", auth, admin, theatres.CreateScreen)
+		theatreRoutes.current.POST("/:id/image", auth, admin, uploads.UploadTheatreImage)
+	}
+
+	showRoutes := root.Group("/shows")
+	{
+		showRoutes.POST("", shows.CreateShow)
+		showRoutes.GET("", shows.GetShows)
+		showRoutes.GET("/:id", shows.GetShowByID)
+		showRoutes.PUT("/:id", shows.UpdateShow)
+		showRoutes.DELETE("/:id", shows.DeleteShow)
+	}
+
+	reviewRoutes := root.Group("/reviews")
+	{
+		reviewRoutes.POST("", auth, reviews.CreateReview)
+		reviewRoutes.GET("", reviews.GetReviews)
+		reviewRoutes.GET("/:id", reviews.GetReviewByID)
+		reviewRoutes.PUT("/:id", reviews.UpdateReview)
+		reviewRoutes.DELETE("/:id", reviews.DeleteReview)
+	}
+
+	stateRoutes := root.Group("/states")
+	{
+		stateRoutes.POST("", states.CreateState)
+		stateRoutes.GET("", states.GetStates)
+		stateRoutes.GET("/:id", states.GetStateByID)
+		stateRoutes.PUT("/:id", states.UpdateState)
+		stateRoutes.DELETE("/:id", states.DeleteState)
+	}
+
+	cityRoutes := root.Group("/cities")
+	{
+		cityRoutes.POST("", cities.CreateCity)
+		cityRoutes.GET("", cities.GetCities)
+		cityRoutes.GET("/:id", cities.GetCityByID)
+		cityRoutes.PUT("/:id", cities.UpdateCity)
+		cityRoutes.DELETE("/:id", cities.DeleteCity)
+		cityRoutes.current.GET("/:id/showtimes", shows.GetCityShowtimes)
+	}
+
+	seatRoutes := root.Group("/seats")
+	{
+		seatRoutes.GET("/show/:id", bookings.GetBookedSeats)
+		seatRoutes.POST("/book", bookings.BookSeat)
+	}
+
+	return router
+}
@@ -0,0 +1,175 @@
+package routes_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/openapi"
+	"backend/repository/memory"
+	"backend/routes"
+	"backend/testharness"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TestOpenAPIMatchesRoutes fails when a route is added without documenting it
+// in openapi/spec.go, or the spec documents a route that no longer exists.
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := testharness.Config()
+	router := routes.New(cfg, nil, memory.New())
+	doc := openapi.Spec(cfg.URLs.BaseURL)
+
+	registered := map[string]bool{}
+	for _, route := range router.Routes() {
+		path := openapi.PathFor(route.Path)
+		registered[route.Method+" "+path] = true
+
+		op := doc.Operation(route.Method, path)
+		if op == nil {
+			t.Errorf("%s %s is not documented in the OpenAPI spec", route.Method, path)
+			continue
+		}
+		for _, param := range pathParams(path) {
+			if !hasPathParam(op, param) {
+				t.Errorf("%s %s does not document path parameter %q", route.Method, path, param)
+			}
+		}
+	}
+
+	for path, item := range doc.Paths {
+		for method := range *item {
+			key := strings.ToUpper(method) + " " + path
+			if !registered[key] {
+				t.Errorf("the OpenAPI spec documents %s which is not routed", key)
+			}
+		}
+	}
+}
+
+func pathParams(path string) []string {
+	var params []string
+	for _, segment := range strings.Split(path, "/") {
+		if strings.HasPrefix(segment, "{") {
+			params = append(params, strings.Trim(segment, "{}"))
+		}
+	}
+	return params
+}
+
+func hasPathParam(op *openapi.Operation, name string) bool {
+	for _, p := range op.Parameters {
+		if p.In == "path" && p.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// every schema reference must resolve
+func TestOpenAPIReferencesResolve(t *testing.T) {
+	doc := openapi.Spec("")
+	var check func(where string, s *openapi.Schema)
+	check = func(where string, s *openapi.Schema) {
+		if s == nil {
+			return
+		}
+		if name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/"); ok {
+			if _, ok := doc.Components.Schemas[name]; !ok {
+				t.Errorf("%s references missing schema %s", where, name)
+			}
+		}
+		check(where, s.Items)
+		check(where, s.AdditionalProperties)
+		for name, prop := range s.Properties {
+			check(where+"."+name, prop)
+		}
+		for _, one := range s.OneOf {
+			check(where, one)
+		}
+	}
+
+	for name, s := range doc.Components.Schemas {
+		check(name, s)
+	}
+	for path, item := range doc.Paths {
+		for method, op := range *item {
+			where := fmt.Sprintf("%s %s", strings.ToUpper(method), path)
+			if op.RequestBody != nil {
+				for _, media := range op.RequestBody.Content {
+					check(where, media.Schema)
+				}
+			}
+			for _, resp := range op.Responses {
+				for _, media := range resp.Content {
+					check(where, media.Schema)
+				}
+			}
+		}
+	}
+}
+
+func TestLegacyRoutesAreDeprecated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := testharness.Config()
+	cfg.API.LegacySunset = time.Date(2027, time.April, 30, 0, 0, 0, 0, time.UTC)
+	router := routes.New(cfg, nil, memory.New())
+
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	current := get("/api/v1/movies/42")
+	if current.Code != http.StatusNotFound {
+		t.Fatalf("GET /api/v1/movies/42: expected 404, got %d", current.Code)
+	}
+	if current.Header().Get("Deprecation") != "" {
+		t.Errorf("versioned route carries a Deprecation header")
+	}
+
+	legacy := get("/movies/42")
+	if legacy.Code != current.Code {
+		t.Errorf("legacy route answered %d, versioned route %d", legacy.Code, current.Code)
+	}
+	if got := legacy.Header().Get("Deprecation"); got != fmt.Sprintf("@%d", routes.LegacyDeprecatedAt.Unix()) {
+		t.Errorf("Deprecation header = %q", got)
+	}
+	if got := legacy.Header().Get("Sunset"); got != "Fri, 30 Apr 2027 00:00:00 GMT" {
+		t.Errorf("Sunset header = %q", got)
+	}
+	if got := legacy.Header().Get("Link"); got != `</api/v1/movies/42>; rel="successor-version"` {
+		t.Errorf("Link header = %q", got)
+	}
+
+	legacyAPI := get("/api/profile")
+	if got := legacyAPI.Header().Get("Link"); got != `</api/v1/profile>; rel="successor-version"` {
+		t.Errorf("Link header of /api/profile = %q", got)
+	}
+}
+
+func TestCatalogWritesRequireAuthentication(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := routes.New(testharness.Config(), nil, memory.New())
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodPost, "/api/v1/people"},
+		{http.MethodPut, "/api/v1/people/1"},
+		{http.MethodDelete, "/api/v1/people/1"},
+		{http.MethodPost, "/api/v1/movies/1/credits"},
+		{http.MethodDelete, "/api/v1/movies/1/credits/1"},
+		{http.MethodPost, "/api/v1/theatres/1/screens"},
+		{http.MethodPost, "/theatres/1/screens"},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(route.method, route.path, strings.NewReader("{}")))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("anonymous %s %s: expected 401, got %d", route.method, route.path, w.Code)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"time"
+
+	"backend/middlewares"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIPrefix is where the current version of the API is served.
+const APIPrefix = "/api/v1"
+
+// LegacyDeprecatedAt is when the unversioned paths were deprecated in favour
+// of APIPrefix.
+var LegacyDeprecatedAt = time.Date(2026, time.October, 19, 0, 0, 0, 0, time.UTC)
+
+// versioned registers each route twice: under the current API version and,
+// until legacy clients have migrated, at its old unversioned path where
+// responses carry deprecation headers.
+type versioned struct {
+	current *gin.RouterGroup
+	legacy  *gin.RouterGroup
+}
+
+func (v versioned) Group(path string, handlers ...gin.HandlerFunc) versioned {
+	return versioned{
+		current: v.current.Group(path, handlers...),
+		legacy:  v.legacy.Group(path, handlers...),
+	}
+}
+
+func (v versioned) Use(handlers ...gin.HandlerFunc) {
+	v.current.Use(handlers...)
+	v.legacy.Use(handlers...)
+}
+
+func (v versioned) Handle(method, path string, handlers ...gin.HandlerFunc) {
+	v.current.Handle(method, path, handlers...)
+	v.legacy.Handle(method, path, handlers...)
+}
+
+// HandleVersions registers different handlers for the two versions, for
+// routes whose response changed shape in the current version.
+func (v versioned) HandleVersions(method, path string, current, legacy gin.HandlerFunc) {
+	v.current.Handle(method, path, current)
+	v.legacy.Handle(method, path, legacy)
+}
+
+func (v versioned) GET(path string, handlers ...gin.HandlerFunc) {
+	v.Handle("GET", path, handlers...)
+}
+
+func (v versioned) POST(path string, handlers ...gin.HandlerFunc) {
+	v.Handle("POST", path, handlers...)
+}
+
+func (v versioned) PUT(path string, handlers ...gin.HandlerFunc) {
+	v.Handle("PUT", path, handlers...)
+}
+
+func (v versioned) PATCH(path string, handlers ...gin.HandlerFunc) {
+	v.Handle("PATCH", path, handlers...)
+}
+
+func (v versioned) DELETE(path string, handlers ...gin.HandlerFunc) {
+	v.Handle("DELETE", path, handlers...)
+}
+
+// newVersioned returns routes served under APIPrefix that also answer at
+// legacyPrefix, deprecated since LegacyDeprecatedAt.
+func newVersioned(router *gin.Engine, legacyPrefix string, sunset time.Time) versioned {
+	return versioned{
+		current: router.Group(APIPrefix),
+		legacy:  router.Group(legacyPrefix, middlewares.Deprecated(legacyPrefix, APIPrefix, LegacyDeprecatedAt, sunset)),
+	}
+}
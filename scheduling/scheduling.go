@@ -0,0 +1,142 @@
+// Package scheduling holds the rules for placing shows on screens that the
+// show and movie handlers and the catalogue import share. The repositories
+// only store shows and lock screens, the rules live here.
+package scheduling
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"backend/models"
+	"backend/repository"
+)
+
+// Overlap reports whether two shows are closer than the cleaning buffer.
+func Overlap(start, end, otherStart, otherEnd time.Time, buffer time.Duration) bool {
+	return start.Before(otherEnd.Add(buffer)) && otherStart.Before(end.Add(buffer))
+}
+
+// Retimed is a show whose computed end time moved with the runtime of its
+// movie.
+type Retimed struct {
+	Show models.Show
+	// Overlaps are the shows on the same screen the show now runs into,
+	// cleaning buffer included.
+	Overlaps []models.Show
+}
+
+// RecomputeEndTimes moves the computed end times of the shows of movie that
+// have not started by now to its runtime plus padding and returns the shows
+// that changed with the shows each now overlaps. It is meant to run in a
+// transaction: the screens of the shows are locked before they are retimed
+// and until it ends, so the caller can roll back when there are overlaps.
+func RecomputeEndTimes(ctx context.Context, shows repository.ShowRepository, movie *models.Movie, padding, buffer time.Duration, now time.Time) ([]Retimed, error) {
+	// show dates are local, ahead of UTC they can be a day later
+	upcoming, err := shows.ListByMovie(ctx, movie.MovieID, repository.Day(now).AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	var affected []models.Show
+	var screens []Screen
+	for _, show := range upcoming {
+		if start, _ := show.Interval(); show.EndTimeComputed && start.After(now) {
+			affected = append(affected, show)
+			screens = append(screens, ScreenOf(show))
+		}
+	}
+	if err := LockScreens(ctx, shows, screens...); err != nil {
+		return nil, err
+	}
+
+	var retimed []Retimed
+	for _, show := range affected {
+		end := show.EndsAt
+		show.ComputeEndTime(movie.Duration, padding)
+		if show.EndsAt.Equal(end) {
+			continue
+		}
+		show.UpdatedAt = now
+		if err := shows.Update(ctx, &show); err != nil {
+			return nil, err
+		}
+		retimed = append(retimed, Retimed{Show: show})
+	}
+	for i, r := range retimed {
+		scheduled, err := OnScreen(ctx, shows, r.Show)
+		if err != nil {
+			return nil, err
+		}
+		retimed[i].Overlaps = Overlapping(r.Show, scheduled, r.Show.ShowID, buffer)
+	}
+	return retimed, nil
+}
+
+// Screen is a schedule that shows must not overlap on: a screen of a theatre,
+// or the shows of the theatre without a screen when ScreenID is nil.
+type Screen struct {
+	TheatreID int
+	ScreenID  *int
+}
+
+// ScreenOf returns the screen show is on.
+func ScreenOf(show models.Show) Screen {
+	return Screen{TheatreID: show.TheatreID, ScreenID: show.ScreenID}
+}
+
+// key orders screens, shows without a screen come first in their theatre.
+func (s Screen) key() [2]int {
+	if s.ScreenID == nil {
+		return [2]int{s.TheatreID, 0}
+	}
+	return [2]int{s.TheatreID, *s.ScreenID}
+}
+
+// LockScreens holds the schedules of screens until the transaction it is
+// called in ends, see repository.ShowRepository.LockScreen. The screens are
+// locked once each and in a fixed order, so that two transactions locking
+// several screens cannot deadlock.
+func LockScreens(ctx context.Context, shows repository.ShowRepository, screens ...Screen) error {
+	byKey := map[[2]int]Screen{}
+	for _, screen := range screens {
+		byKey[screen.key()] = screen
+	}
+	keys := make([][2]int, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i][0] != keys[j][0] {
+			return keys[i][0] < keys[j][0]
+		}
+		return keys[i][1] < keys[j][1]
+	})
+	for _, key := range keys {
+		if err := shows.LockScreen(ctx, byKey[key].TheatreID, byKey[key].ScreenID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnScreen returns the shows on the screen of show that it can overlap, with
+// Movie populated: those of its date and of the day before, which can run
+// past midnight into it.
+func OnScreen(ctx context.Context, shows repository.ShowRepository, show models.Show) ([]models.Show, error) {
+	day := time.Date(show.Date.Year(), show.Date.Month(), show.Date.Day(), 0, 0, 0, 0, time.UTC)
+	return shows.ListOnScreen(ctx, show.TheatreID, show.ScreenID, day.AddDate(0, 0, -1), day.AddDate(0, 0, 1))
+}
+
+// Overlapping returns the shows of scheduled, but except, that are closer to
+// show than buffer.
+func Overlapping(show models.Show, scheduled []models.Show, except uint, buffer time.Duration) []models.Show {
+	start, end := show.Interval()
+	var overlaps []models.Show
+	for _, other := range scheduled {
+		otherStart, otherEnd := other.Interval()
+		if other.ShowID != except && Overlap(start, end, otherStart, otherEnd, buffer) {
+			overlaps = append(overlaps, other)
+		}
+	}
+	return overlaps
+}
@@ -0,0 +1,75 @@
+package scheduling
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	"backend/repository"
+)
+
+func TestOverlap(t *testing.T) {
+	at := func(hour, minute int) time.Time {
+		return time.Date(2026, time.May, 1, hour, minute, 0, 0, time.UTC)
+	}
+	buffer := 15 * time.Minute
+	tests := []struct {
+		name              string
+		otherStart, other time.Time
+		want              bool
+	}{
+		{"same time", at(18, 0), at(20, 0), true},
+		{"inside", at(18, 30), at(19, 0), true},
+		{"ends in the buffer", at(16, 0), at(17, 50), true},
+		{"starts in the buffer", at(20, 10), at(22, 0), true},
+		{"ends with the buffer", at(16, 0), at(17, 45), false},
+		{"starts after the buffer", at(20, 15), at(22, 0), false},
+		{"another day", at(18, 0).AddDate(0, 0, 1), at(20, 0).AddDate(0, 0, 1), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Overlap(at(18, 0), at(20, 0), tt.otherStart, tt.other, buffer); got != tt.want {
+				t.Errorf("Overlap = %v, want %v", got, tt.want)
+			}
+			if got := Overlap(tt.otherStart, tt.other, at(18, 0), at(20, 0), buffer); got != tt.want {
+				t.Errorf("reversed Overlap = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// lockRecorder records the screens locked through it.
+type lockRecorder struct {
+	repository.ShowRepository
+	locked []string
+}
+
+func (r *lockRecorder) LockScreen(ctx context.Context, theatreID int, screenID *int) error {
+	screen := "none"
+	if screenID != nil {
+		screen = strconv.Itoa(*screenID)
+	}
+	r.locked = append(r.locked, fmt.Sprintf("%d/%s", theatreID, screen))
+	return nil
+}
+
+func TestLockScreensOnceInOrder(t *testing.T) {
+	one, two := 1, 2
+	var r lockRecorder
+	err := LockScreens(context.Background(), &r,
+		Screen{TheatreID: 2, ScreenID: &one},
+		Screen{TheatreID: 1, ScreenID: &two},
+		Screen{TheatreID: 1},
+		Screen{TheatreID: 1, ScreenID: &two},
+		Screen{TheatreID: 1, ScreenID: &one},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"1/none", "1/1", "1/2", "2/1"}; !slices.Equal(r.locked, want) {
+		t.Errorf("locked %v, want %v", r.locked, want)
+	}
+}
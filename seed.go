@@ -0,0 +1,51 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"backend/config"
+	"backend/migrations"
+	"backend/models"
+	"backend/seeds"
+)
+
+func runSeed(args []string) {
+	flags := flag.NewFlagSet("seed", flag.ExitOnError)
+	profile := flags.String("profile", "demo", "embedded fixture profile to load ("+strings.Join(seeds.Profiles(), ", ")+")")
+	dir := flags.String("dir", "", "load fixture files from this directory instead of an embedded profile")
+	flags.Parse(args)
+
+	var fixtures *seeds.Fixtures
+	var err error
+	if *dir != "" {
+		fixtures, err = seeds.LoadDir(*dir)
+	} else {
+		fixtures, err = seeds.LoadProfile(*profile)
+	}
+	if err != nil {
+		log.Fatal("Failed to load fixtures: ", err)
+	}
+
+	dbConfig, err := config.LoadDatabase()
+	if err != nil {
+		log.Fatal(err)
+	}
+	db, err := models.OpenDatabase(dbConfig)
+	if err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	if err := migrations.EnsureCurrent(db); err != nil {
+		log.Fatal(err)
+	}
+
+	report, err := seeds.Apply(db, fixtures, time.Now())
+	if err != nil {
+		log.Fatal("Seeding failed, no changes were written: ", err)
+	}
+	fmt.Fprint(os.Stdout, report)
+}
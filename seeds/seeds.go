@@ -0,0 +1,555 @@
+// Package seeds loads fixture files describing states, cities, theatres,
+// screens, movies and shows and writes them to the database idempotently.
+//
+// Fixtures reference each other by name rather than by id, so the same files
+// work against any database. Every .yaml, .yml or .json file of a profile is
+// decoded into Fixtures and merged, which allows splitting a profile by
+// entity. Dates accept YYYY-MM-DD or a day offset relative to the seeding
+// date ("today", "+3d", "-10d") so demo data never goes stale.
+package seeds
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+
+	"backend/models"
+)
+
+//go:embed demo test
+var profiles embed.FS
+
+type Fixtures struct {
+	States   []StateFixture   `yaml:"states"`
+	Cities   []CityFixture    `yaml:"cities"`
+	Theatres []TheatreFixture `yaml:"theatres"`
+	Movies   []MovieFixture   `yaml:"movies"`
+	Shows    []ShowFixture    `yaml:"shows"`
+}
+
+type StateFixture struct {
+	Name string `yaml:"name"`
+}
+
+type CityFixture struct {
+	Name  string `yaml:"name"`
+	State string `yaml:"state"`
+	// Timezone defaults to models.DefaultTimezone.
+	Timezone string `yaml:"timezone"`
+}
+
+type ScreenFixture struct {
+	Name       string `yaml:"name"`
+	TotalSeats int    `yaml:"total_seats"`
+}
+
+type TheatreFixture struct {
+	Name       string          `yaml:"name"`
+	Location   string          `yaml:"location"`
+	City       string          `yaml:"city"`
+	TotalSeats int             `yaml:"total_seats"`
+	Image      string          `yaml:"image"`
+	Status     string          `yaml:"status"`
+	Screens    []ScreenFixture `yaml:"screens"`
+}
+
+type MovieFixture struct {
+	Name        string   `yaml:"name"`
+	Description string   `yaml:"description"`
+	Duration    int      `yaml:"duration"`
+	Languages   []string `yaml:"languages"`
+	Genre       string   `yaml:"genre"`
+	Genres      []string `yaml:"genres"`
+	PosterURL   string   `yaml:"poster_url"`
+	Rating      float64  `yaml:"rating"`
+	Status      string   `yaml:"status"`
+	StartDate   string   `yaml:"start_date"`
+	EndDate     string   `yaml:"end_date"`
+}
+
+type ShowFixture struct {
+	Movie     string   `yaml:"movie"`
+	Theatre   string   `yaml:"theatre"`
+	Screen    string   `yaml:"screen"`
+	Date      string   `yaml:"date"`
+	StartTime string   `yaml:"start_time"`
+	EndTime   string   `yaml:"end_time"`
+	Languages []string `yaml:"languages"`
+}
+
+// Counts reports how many records of one kind were created, updated or left
+// untouched because they already matched the fixture.
+type Counts struct {
+	Created   int
+	Updated   int
+	Unchanged int
+}
+
+type Report map[string]*Counts
+
+func (r Report) count(kind string) *Counts {
+	if r[kind] == nil {
+		r[kind] = &Counts{}
+	}
+	return r[kind]
+}
+
+func (r Report) String() string {
+	kinds := make([]string, 0, len(r))
+	for kind := range r {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+
+	var b strings.Builder
+	for _, kind := range kinds {
+		c := r[kind]
+		fmt.Fprintf(&b, "%-9s created=%d updated=%d unchanged=%d\n", kind, c.Created, c.Updated, c.Unchanged)
+	}
+	return b.String()
+}
+
+// Profiles lists the fixture profiles compiled into the binary.
+func Profiles() []string {
+	entries, _ := fs.ReadDir(profiles, ".")
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+// LoadProfile reads an embedded fixture profile such as "demo" or "test".
+func LoadProfile(name string) (*Fixtures, error) {
+	sub, err := fs.Sub(profiles, name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fs.Stat(sub, "."); err != nil {
+		return nil, fmt.Errorf("unknown seed profile %q (available: %s)", name, strings.Join(Profiles(), ", "))
+	}
+	return load(sub)
+}
+
+// LoadDir reads every fixture file of a directory on disk.
+func LoadDir(dir string) (*Fixtures, error) {
+	return load(os.DirFS(dir))
+}
+
+func load(fsys fs.FS) (*Fixtures, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	fixtures := &Fixtures{}
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		// JSON is a subset of YAML, so one decoder handles both formats
+		var f Fixtures
+		if err := yaml.Unmarshal(data, &f); err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		fixtures.States = append(fixtures.States, f.States...)
+		fixtures.Cities = append(fixtures.Cities, f.Cities...)
+		fixtures.Theatres = append(fixtures.Theatres, f.Theatres...)
+		fixtures.Movies = append(fixtures.Movies, f.Movies...)
+		fixtures.Shows = append(fixtures.Shows, f.Shows...)
+	}
+	return fixtures, nil
+}
+
+// Apply writes fixtures in a single transaction. Records are matched on their
+// natural keys (names within their parent), created when missing and updated
+// when they differ, so applying the same fixtures twice changes nothing.
+func Apply(db *gorm.DB, fixtures *Fixtures, today time.Time) (Report, error) {
+	report := Report{}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		s := &seeder{tx: tx, today: today, report: report,
+			states: map[string]int{}, cities: map[string]int{}, theatres: map[string]int{},
+			screens: map[string]int{}, movies: map[string]int{},
+			cityLocations: map[string]*time.Location{}, locations: map[string]*time.Location{}}
+		for _, step := range []func(*Fixtures) error{s.seedStates, s.seedCities, s.seedTheatres, s.seedMovies, s.seedShows} {
+			if err := step(fixtures); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return report, err
+}
+
+type seeder struct {
+	tx     *gorm.DB
+	today  time.Time
+	report Report
+
+	states   map[string]int
+	cities   map[string]int
+	theatres map[string]int
+	screens  map[string]int
+	movies   map[string]int
+	// time zones by city and by theatre name
+	cityLocations map[string]*time.Location
+	locations     map[string]*time.Location
+}
+
+func (s *seeder) seedStates(f *Fixtures) error {
+	for _, fx := range f.States {
+		var state models.State
+		err := s.tx.Where("state_name = ?", fx.Name).First(&state).Error
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			state = models.State{StateName: fx.Name}
+			if err := s.tx.Create(&state).Error; err != nil {
+				return fmt.Errorf("state %q: %w", fx.Name, err)
+			}
+			s.report.count("states").Created++
+		case err != nil:
+			return err
+		default:
+			s.report.count("states").Unchanged++
+		}
+		s.states[fx.Name] = state.StateID
+	}
+	return nil
+}
+
+func (s *seeder) seedCities(f *Fixtures) error {
+	for _, fx := range f.Cities {
+		stateID, ok := s.states[fx.State]
+		if !ok {
+			return fmt.Errorf("city %q references unknown state %q", fx.Name, fx.State)
+		}
+
+		timezone := fx.Timezone
+		if timezone == "" {
+			timezone = models.DefaultTimezone
+		}
+		loc, err := models.LoadLocation(timezone)
+		if err != nil {
+			return fmt.Errorf("city %q: %w", fx.Name, err)
+		}
+
+		var city models.City
+		err = s.tx.Where("state_id = ? AND city_name = ?", stateID, fx.Name).First(&city).Error
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			city = models.City{StateID: stateID, CityName: fx.Name, Timezone: timezone}
+			if err := s.tx.Create(&city).Error; err != nil {
+				return fmt.Errorf("city %q: %w", fx.Name, err)
+			}
+			s.report.count("cities").Created++
+		case err != nil:
+			return err
+		case city.Timezone != timezone:
+			city.Timezone = timezone
+			if err := s.tx.Save(&city).Error; err != nil {
+				return fmt.Errorf("city %q: %w", fx.Name, err)
+			}
+			s.report.count("cities").Updated++
+		default:
+			s.report.count("cities").Unchanged++
+		}
+		s.cities[fx.Name] = city.CityID
+		s.cityLocations[fx.Name] = loc
+	}
+	return nil
+}
+
+func (s *seeder) seedTheatres(f *Fixtures) error {
+	now := time.Now()
+	for _, fx := range f.Theatres {
+		cityID, ok := s.cities[fx.City]
+		if !ok {
+			return fmt.Errorf("theatre %q references unknown city %q", fx.Name, fx.City)
+		}
+
+		var theatre models.Theatre
+		err := s.tx.Where("city_id = ? AND theatre_name = ?", cityID, fx.Name).First(&theatre).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		created := err == gorm.ErrRecordNotFound
+
+		desired := models.Theatre{
+			TheatreID:       theatre.TheatreID,
+			TheatreName:     fx.Name,
+			TheatreLocation: fx.Location,
+			CityID:          cityID,
+			TotalSeats:      fx.TotalSeats,
+			TheatreImage:    fx.Image,
+			TheatreStatus:   fx.Status,
+			CreatedAt:       theatre.CreatedAt,
+			UpdatedAt:       theatre.UpdatedAt,
+		}
+		if fx.Image == theatre.TheatreImage {
+			// thumbnails of an uploaded image are kept while it stays
+			desired.ImageThumbnails = theatre.ImageThumbnails
+		}
+		if created {
+			desired.CreatedAt, desired.UpdatedAt = now, now
+			if err := s.tx.Create(&desired).Error; err != nil {
+				return fmt.Errorf("theatre %q: %w", fx.Name, err)
+			}
+			s.report.count("theatres").Created++
+		} else if !reflect.DeepEqual(desired, theatre) {
+			desired.UpdatedAt = now
+			if err := s.tx.Save(&desired).Error; err != nil {
+				return fmt.Errorf("theatre %q: %w", fx.Name, err)
+			}
+			s.report.count("theatres").Updated++
+		} else {
+			s.report.count("theatres").Unchanged++
+		}
+		s.theatres[fx.Name] = desired.TheatreID
+		s.locations[fx.Name] = s.cityLocations[fx.City]
+
+		for _, screenFx := range fx.Screens {
+			if err := s.seedScreen(desired.TheatreID, fx.Name, screenFx); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *seeder) seedScreen(theatreID int, theatreName string, fx ScreenFixture) error {
+	now := time.Now()
+
+	var screen models.Screen
+	err := s.tx.Where("theatre_id = ? AND screen_name = ?", theatreID, fx.Name).First(&screen).Error
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		screen = models.Screen{TheatreID: theatreID, ScreenName: fx.Name, TotalSeats: fx.TotalSeats, CreatedAt: now, UpdatedAt: now}
+		if err := s.tx.Create(&screen).Error; err != nil {
+			return fmt.Errorf("screen %q of %q: %w", fx.Name, theatreName, err)
+		}
+		s.report.count("screens").Created++
+	case err != nil:
+		return err
+	case screen.TotalSeats != fx.TotalSeats:
+		screen.TotalSeats = fx.TotalSeats
+		screen.UpdatedAt = now
+		if err := s.tx.Save(&screen).Error; err != nil {
+			return fmt.Errorf("screen %q of %q: %w", fx.Name, theatreName, err)
+		}
+		s.report.count("screens").Updated++
+	default:
+		s.report.count("screens").Unchanged++
+	}
+	s.screens[theatreName+"/"+fx.Name] = screen.ScreenID
+	return nil
+}
+
+func (s *seeder) seedMovies(f *Fixtures) error {
+	now := time.Now()
+	for _, fx := range f.Movies {
+		startDate, err := s.date(fx.StartDate)
+		if err != nil {
+			return fmt.Errorf("movie %q start_date: %w", fx.Name, err)
+		}
+		endDate, err := s.date(fx.EndDate)
+		if err != nil {
+			return fmt.Errorf("movie %q end_date: %w", fx.Name, err)
+		}
+		languagesJSON, err := json.Marshal(fx.Languages)
+		if err != nil {
+			return err
+		}
+		// fixtures may still name a single genre
+		genres := fx.Genres
+		if len(genres) == 0 && fx.Genre != "" {
+			genres = []string{fx.Genre}
+		}
+		genresJSON, err := json.Marshal(genres)
+		if err != nil {
+			return err
+		}
+		genre := fx.Genre
+		if len(genres) > 0 {
+			genre = genres[0]
+		}
+
+		var movie models.Movie
+		err = s.tx.Where("movie_name = ?", fx.Name).First(&movie).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return err
+		}
+		created := err == gorm.ErrRecordNotFound
+
+		changed := created ||
+			movie.MovieDescription != fx.Description ||
+			movie.Duration != fx.Duration ||
+			string(movie.Languages) != string(languagesJSON) ||
+			movie.Genre != genre ||
+			!sameGenres(movie.Genres, genres) ||
+			movie.PosterURL != fx.PosterURL ||
+			movie.Rating != fx.Rating ||
+			movie.MovieStatus != fx.Status ||
+			!movie.StartDate.Equal(startDate) ||
+			!movie.EndDate.Equal(endDate)
+
+		movie.MovieName = fx.Name
+		movie.MovieDescription = fx.Description
+		movie.Duration = fx.Duration
+		movie.Languages = languagesJSON
+		movie.Genre = genre
+		movie.Genres = genresJSON
+		if movie.PosterURL != fx.PosterURL {
+			movie.PosterThumbnails = nil
+		}
+		movie.PosterURL = fx.PosterURL
+		movie.Rating = fx.Rating
+		movie.MovieStatus = fx.Status
+		movie.StartDate = startDate
+		movie.EndDate = endDate
+
+		switch {
+		case created:
+			movie.CreatedAt, movie.UpdatedAt = now, now
+			if err := s.tx.Create(&movie).Error; err != nil {
+				return fmt.Errorf("movie %q: %w", fx.Name, err)
+			}
+			s.report.count("movies").Created++
+		case changed:
+			movie.UpdatedAt = now
+			if err := s.tx.Save(&movie).Error; err != nil {
+				return fmt.Errorf("movie %q: %w", fx.Name, err)
+			}
+			s.report.count("movies").Updated++
+		default:
+			s.report.count("movies").Unchanged++
+		}
+		s.movies[fx.Name] = movie.MovieID
+	}
+	return nil
+}
+
+// sameGenres compares decoded, jsonb does not keep the JSON text as sent.
+func sameGenres(stored datatypes.JSON, genres []string) bool {
+	var decoded []string
+	json.Unmarshal(stored, &decoded)
+	return slices.Equal(decoded, genres)
+}
+
+func (s *seeder) seedShows(f *Fixtures) error {
+	now := time.Now()
+	for _, fx := range f.Shows {
+		movieID, ok := s.movies[fx.Movie]
+		if !ok {
+			return fmt.Errorf("show references unknown movie %q", fx.Movie)
+		}
+		theatreID, ok := s.theatres[fx.Theatre]
+		if !ok {
+			return fmt.Errorf("show references unknown theatre %q", fx.Theatre)
+		}
+		var screenID *int
+		if fx.Screen != "" {
+			id, ok := s.screens[fx.Theatre+"/"+fx.Screen]
+			if !ok {
+				return fmt.Errorf("show references unknown screen %q of %q", fx.Screen, fx.Theatre)
+			}
+			screenID = &id
+		}
+
+		date, err := s.date(fx.Date)
+		if err != nil {
+			return fmt.Errorf("show of %q date: %w", fx.Movie, err)
+		}
+		startTime, err := time.Parse("15:04", fx.StartTime)
+		if err != nil {
+			return fmt.Errorf("show of %q start_time: %w", fx.Movie, err)
+		}
+		endTime, err := time.Parse("15:04", fx.EndTime)
+		if err != nil {
+			return fmt.Errorf("show of %q end_time: %w", fx.Movie, err)
+		}
+		languagesJSON, err := json.Marshal(fx.Languages)
+		if err != nil {
+			return err
+		}
+
+		query := s.tx.Where("movie_id = ? AND theatre_id = ? AND date = ? AND start_time = ?", movieID, theatreID, date, startTime)
+		if screenID != nil {
+			query = query.Where("screen_id = ?", *screenID)
+		} else {
+			query = query.Where("screen_id IS NULL")
+		}
+
+		var show models.Show
+		err = query.First(&show).Error
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			show = models.Show{
+				MovieID:   movieID,
+				TheatreID: theatreID,
+				ScreenID:  screenID,
+				Languages: languagesJSON,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			show.SetStart(date, startTime, s.locations[fx.Theatre])
+			show.SetEnd(endTime)
+			if err := s.tx.Create(&show).Error; err != nil {
+				return fmt.Errorf("show of %q: %w", fx.Movie, err)
+			}
+			s.report.count("shows").Created++
+		case err != nil:
+			return err
+		case !show.EndTime.Equal(endTime) || string(show.Languages) != string(languagesJSON):
+			show.SetEnd(endTime)
+			show.Languages = languagesJSON
+			show.UpdatedAt = now
+			if err := s.tx.Save(&show).Error; err != nil {
+				return fmt.Errorf("show of %q: %w", fx.Movie, err)
+			}
+			s.report.count("shows").Updated++
+		default:
+			s.report.count("shows").Unchanged++
+		}
+	}
+	return nil
+}
+
+// date parses YYYY-MM-DD or a relative day offset ("today", "+3d", "-1d").
+func (s *seeder) date(value string) (time.Time, error) {
+	today := time.Date(s.today.Year(), s.today.Month(), s.today.Day(), 0, 0, 0, 0, time.UTC)
+
+	value = strings.TrimSpace(value)
+	if value == "today" {
+		return today, nil
+	}
+	if strings.HasSuffix(value, "d") && (strings.HasPrefix(value, "+") || strings.HasPrefix(value, "-")) {
+		days, err := strconv.Atoi(strings.TrimSuffix(value, "d"))
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid relative date %q", value)
+		}
+		return today.AddDate(0, 0, days), nil
+	}
+	return time.Parse("2006-01-02", value)
+}
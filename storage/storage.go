@@ -0,0 +1,60 @@
+// Package storage keeps uploaded files, on the local file system or in an S3
+// compatible bucket.
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"time"
+
+	"backend/config"
+)
+
+var ErrNotFound = errors.New("object not found")
+
+// Object is a stored file, the caller closes Body.
+type Object struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+}
+
+// Storage keeps files by key. Keys are slash separated relative paths, see
+// ValidKey.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get returns ErrNotFound when nothing is stored at key.
+	Get(ctx context.Context, key string) (*Object, error)
+	// Delete succeeds when nothing is stored at key.
+	Delete(ctx context.Context, key string) error
+}
+
+// New returns the storage the configuration selects.
+func New(cfg config.StorageConfig) Storage {
+	if cfg.Driver == config.StorageS3 {
+		return NewS3(cfg.S3)
+	}
+	return NewLocal(cfg.LocalDir)
+}
+
+// ValidKey reports whether key is a relative path of lower case letters,
+// digits, dots, dashes and underscores without empty or dot segments.
+func ValidKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, segment := range strings.Split(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return false
+		}
+		for _, r := range segment {
+			if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || strings.ContainsRune("._-", r)) {
+				return false
+			}
+		}
+	}
+	return true
+}
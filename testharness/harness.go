@@ -0,0 +1,235 @@
+package testharness
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"backend/config"
+	"backend/migrations"
+	"backend/models"
+	"backend/repository/postgres"
+	"backend/routes"
+	"backend/seeds"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type Harness struct {
+	T      testing.TB
+	DB     *gorm.DB
+	Config *config.Config
+	Router *gin.Engine
+}
+
+// Config returns the settings the router is built with in tests.
+func Config() *config.Config {
+	return &config.Config{
+		Server: config.ServerConfig{Port: "0"},
+		JWT:    config.JWTConfig{Secret: "test-secret", TTL: time.Hour},
+		CORS:   config.CORSConfig{AllowOrigins: []string{"http://localhost:5173"}},
+		PayU: config.PayUConfig{
+			MerchantKey:  "test-key",
+			MerchantSalt: "test-salt",
+			BaseURL:      "https://payu.test",
+		},
+		URLs: config.URLConfig{
+			BaseURL:         "http://api.test",
+			FrontendBaseURL: "http://frontend.test",
+		},
+		Storage: config.StorageConfig{
+			Driver:        config.StorageLocal,
+			LocalDir:      filepath.Join(os.TempDir(), "backend-test-uploads"),
+			MaxUploadSize: 1 << 20,
+		},
+		Shows: config.ShowsConfig{CleaningBuffer: 15 * time.Minute, Padding: 20 * time.Minute},
+	}
+}
+
+// New returns a router backed by a fresh database that has every migration
+// applied and the "test" seed profile loaded. configure adjusts the settings
+// the router is built with.
+func New(t testing.TB, configure ...func(*config.Config)) *Harness {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db := NewDatabase(t)
+	if _, err := migrations.Up(db, 0); err != nil {
+		t.Fatalf("applying migrations: %v", err)
+	}
+
+	fixtures, err := seeds.LoadProfile("test")
+	if err != nil {
+		t.Fatalf("loading fixtures: %v", err)
+	}
+	if _, err := seeds.Apply(db, fixtures, time.Now()); err != nil {
+		t.Fatalf("seeding fixtures: %v", err)
+	}
+
+	cfg := Config()
+	cfg.Storage.LocalDir = t.TempDir()
+	for _, fn := range configure {
+		fn(cfg)
+	}
+	return &Harness{
+		T:      t,
+		DB:     db,
+		Config: cfg,
+		Router: routes.New(cfg, db, postgres.New(db)),
+	}
+}
+
+// Request is an HTTP request to send through the router.
+type Request struct {
+	Method string
+	Path   string
+	// JSON is encoded as the request body unless Form or Files is set.
+	JSON interface{}
+	Form url.Values
+	// Files are sent as a multipart form, keyed by field name.
+	Files  map[string][]byte
+	Token  string
+	Header http.Header
+}
+
+func (h *Harness) Do(r Request) *httptest.ResponseRecorder {
+	h.T.Helper()
+
+	var body io.Reader
+	contentType := ""
+	switch {
+	case r.Files != nil:
+		var buf bytes.Buffer
+		mw := multipart.NewWriter(&buf)
+		for field, data := range r.Files {
+			part, err := mw.CreateFormFile(field, field)
+			if err == nil {
+				_, err = part.Write(data)
+			}
+			if err != nil {
+				h.T.Fatalf("encoding request body: %v", err)
+			}
+		}
+		if err := mw.Close(); err != nil {
+			h.T.Fatalf("encoding request body: %v", err)
+		}
+		body = &buf
+		contentType = mw.FormDataContentType()
+	case r.Form != nil:
+		body = strings.NewReader(r.Form.Encode())
+		contentType = "application/x-www-form-urlencoded"
+	case r.JSON != nil:
+		data, err := json.Marshal(r.JSON)
+		if err != nil {
+			h.T.Fatalf("encoding request body: %v", err)
+		}
+		body = bytes.NewReader(data)
+		contentType = "application/json"
+	}
+
+	req := httptest.NewRequest(r.Method, r.Path, body)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if r.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.Token)
+	}
+	for name, values := range r.Header {
+		req.Header[name] = values
+	}
+
+	w := httptest.NewRecorder()
+	h.Router.ServeHTTP(w, req)
+	return w
+}
+
+// Decode unmarshals a JSON response body into v.
+func (h *Harness) Decode(w *httptest.ResponseRecorder, v interface{}) {
+	h.T.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		h.T.Fatalf("decoding response %q: %v", w.Body.String(), err)
+	}
+}
+
+// Expect fails the test unless w has the given status.
+func (h *Harness) Expect(w *httptest.ResponseRecorder, status int) {
+	h.T.Helper()
+	if w.Code != status {
+		h.T.Fatalf("expected status %d, got %d: %s", status, w.Code, w.Body.String())
+	}
+}
+
+// Register creates an account and returns its id and bearer token.
+func (h *Harness) Register(name, email, password string) (int, string) {
+	h.T.Helper()
+
+	w := h.Do(Request{Method: http.MethodPost, Path: "/api/v1/register", JSON: map[string]string{
+		"name":     name,
+		"email":    email,
+		"password": password,
+	}})
+	h.Expect(w, http.StatusOK)
+
+	var resp struct {
+		Token string `json:"token"`
+	}
+	h.Decode(w, &resp)
+
+	var user models.User
+	if err := h.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		h.T.Fatalf("loading registered user: %v", err)
+	}
+	return user.UserID, resp.Token
+}
+
+// Admin registers an account, promotes it to administrator and returns its id
+// and bearer token.
+func (h *Harness) Admin(name, email, password string) (int, string) {
+	h.T.Helper()
+
+	userID, token := h.Register(name, email, password)
+	if err := h.DB.Model(&models.User{}).Where("user_id = ?", userID).Update("is_admin", true).Error; err != nil {
+		h.T.Fatalf("promoting user: %v", err)
+	}
+	return userID, token
+}
+
+// Movie returns the seeded movie with the given name.
+func (h *Harness) Movie(name string) models.Movie {
+	h.T.Helper()
+	var movie models.Movie
+	if err := h.DB.Where("movie_name = ?", name).First(&movie).Error; err != nil {
+		h.T.Fatalf("loading movie %q: %v", name, err)
+	}
+	return movie
+}
+
+// Theatre returns the seeded theatre with the given name.
+func (h *Harness) Theatre(name string) models.Theatre {
+	h.T.Helper()
+	var theatre models.Theatre
+	if err := h.DB.Where("theatre_name = ?", name).First(&theatre).Error; err != nil {
+		h.T.Fatalf("loading theatre %q: %v", name, err)
+	}
+	return theatre
+}
+
+// Shows returns the seeded shows ordered by id.
+func (h *Harness) Shows() []models.Show {
+	h.T.Helper()
+	var shows []models.Show
+	if err := h.DB.Order("show_id").Find(&shows).Error; err != nil {
+		h.T.Fatalf("loading shows: %v", err)
+	}
+	return shows
+}
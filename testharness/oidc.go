@@ -0,0 +1,183 @@
+package testharness
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"backend/config"
+	"backend/oidc"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// OIDCKeyID is the id of the key the stand-in provider signs with.
+const OIDCKeyID = "test-key"
+
+// OIDCProvider is a stand-in identity provider serving discovery, JWKS and
+// token endpoints. Authorize plays the user signing in.
+type OIDCProvider struct {
+	*httptest.Server
+	ClientID string
+	// JWKSFetches counts the requests for the signing keys.
+	JWKSFetches atomic.Int32
+	// JWKSDown makes the requests for the signing keys fail.
+	JWKSDown atomic.Bool
+
+	key    *rsa.PrivateKey
+	mu     sync.Mutex
+	grants map[string]oidcGrant
+}
+
+// OIDCUser is who signs in at the provider.
+type OIDCUser struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	// Nonce replaces the nonce of the authorization request in the ID token.
+	Nonce string
+}
+
+type oidcGrant struct {
+	user      OIDCUser
+	challenge string
+	nonce     string
+}
+
+func NewOIDCProvider(t testing.TB) *OIDCProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating the provider key: %v", err)
+	}
+	p := &OIDCProvider{ClientID: "test-client", key: key, grants: map[string]oidcGrant{}}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 p.URL,
+			"authorization_endpoint": p.URL + "/authorize",
+			"token_endpoint":         p.URL + "/token",
+			"jwks_uri":               p.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("GET /jwks", func(w http.ResponseWriter, r *http.Request) {
+		p.JWKSFetches.Add(1)
+		if p.JWKSDown.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": OIDCKeyID,
+			"use": "sig",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("POST /token", p.token)
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// Configure points cfg at the provider.
+func (p *OIDCProvider) Configure(cfg *config.Config) {
+	cfg.OIDC = config.OIDCConfig{
+		IssuerURL:   p.URL,
+		ClientID:    p.ClientID,
+		RedirectURL: cfg.URLs.BaseURL + "/auth/oidc/callback",
+		Scopes:      []string{"openid", "email", "profile"},
+	}
+}
+
+// Authorize signs user in at the authorization URL a login redirected to and
+// returns the code and state the provider redirects back with.
+func (p *OIDCProvider) Authorize(t testing.TB, authURL string, user OIDCUser) (code, state string) {
+	t.Helper()
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("parsing the authorization URL: %v", err)
+	}
+	query := u.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Fatalf("authorization request without PKCE: %s", authURL)
+	}
+	code, err = oidc.RandomString()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.grants[code] = oidcGrant{user: user, challenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	return code, query.Get("state")
+}
+
+// IDToken signs claims as the provider, with the given key id.
+func (p *OIDCProvider) IDToken(t testing.TB, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+	signed, err := p.sign(kid, claims)
+	if err != nil {
+		t.Fatalf("signing an ID token: %v", err)
+	}
+	return signed
+}
+
+func (p *OIDCProvider) sign(kid string, claims jwt.MapClaims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	return token.SignedString(p.key)
+}
+
+func (p *OIDCProvider) token(w http.ResponseWriter, r *http.Request) {
+	code, verifier := r.PostFormValue("code"), r.PostFormValue("code_verifier")
+
+	p.mu.Lock()
+	grant, ok := p.grants[code]
+	delete(p.grants, code)
+	p.mu.Unlock()
+
+	if !ok || oidc.CodeChallenge(verifier) != grant.challenge || r.PostFormValue("client_id") != p.ClientID {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	nonce := grant.nonce
+	if grant.user.Nonce != "" {
+		nonce = grant.user.Nonce
+	}
+	now := time.Now()
+	idToken, err := p.sign(OIDCKeyID, jwt.MapClaims{
+		"iss":            p.URL,
+		"aud":            p.ClientID,
+		"sub":            grant.user.Subject,
+		"email":          grant.user.Email,
+		"email_verified": grant.user.EmailVerified,
+		"name":           grant.user.Name,
+		"nonce":          nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"access_token": "access-" + code,
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
@@ -0,0 +1,127 @@
+// Package tmdb imports movie metadata from TMDB exports: movie details as
+// returned by /movie/{id}?append_to_response=credits,videos,release_dates,
+// saved as a JSON array or as one object per line.
+//
+// Movies and people are matched on their TMDB id, stored as external_id, so
+// importing the same export twice changes nothing.
+package tmdb
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type Movie struct {
+	ID               int        `json:"id"`
+	Title            string     `json:"title"`
+	Overview         string     `json:"overview"`
+	Runtime          int        `json:"runtime"`
+	ReleaseDate      string     `json:"release_date"`
+	OriginalLanguage string     `json:"original_language"`
+	PosterPath       string     `json:"poster_path"`
+	VoteAverage      float64    `json:"vote_average"`
+	Genres           []Genre    `json:"genres"`
+	SpokenLanguages  []Language `json:"spoken_languages"`
+	Videos           struct {
+		Results []Video `json:"results"`
+	} `json:"videos"`
+	ReleaseDates struct {
+		Results []CountryReleases `json:"results"`
+	} `json:"release_dates"`
+	Credits Credits `json:"credits"`
+}
+
+type Genre struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+type Language struct {
+	ISO639_1    string `json:"iso_639_1"`
+	EnglishName string `json:"english_name"`
+}
+
+type Video struct {
+	Site string `json:"site"`
+	Key  string `json:"key"`
+	Type string `json:"type"`
+}
+
+type CountryReleases struct {
+	Country  string `json:"iso_3166_1"`
+	Releases []struct {
+		Certification string `json:"certification"`
+	} `json:"release_dates"`
+}
+
+type Credits struct {
+	Cast []CastMember `json:"cast"`
+	Crew []CrewMember `json:"crew"`
+}
+
+type CastMember struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	ProfilePath string `json:"profile_path"`
+	Character   string `json:"character"`
+	Order       int    `json:"order"`
+}
+
+type CrewMember struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	ProfilePath string `json:"profile_path"`
+	Job         string `json:"job"`
+}
+
+// Decode reads an export, either a JSON array of movies or movie objects one
+// after the other.
+func Decode(r io.Reader) ([]Movie, error) {
+	br := bufio.NewReader(r)
+	first, err := firstByte(br)
+	if err != nil {
+		return nil, err
+	}
+
+	dec := json.NewDecoder(br)
+	if first == '[' {
+		var movies []Movie
+		if err := dec.Decode(&movies); err != nil {
+			return nil, fmt.Errorf("decoding export: %w", err)
+		}
+		return movies, nil
+	}
+
+	var movies []Movie
+	for {
+		var movie Movie
+		err := dec.Decode(&movie)
+		if errors.Is(err, io.EOF) {
+			return movies, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("decoding movie %d of the export: %w", len(movies)+1, err)
+		}
+		movies = append(movies, movie)
+	}
+}
+
+// firstByte peeks at the first byte that is not white space.
+func firstByte(br *bufio.Reader) (byte, error) {
+	for {
+		b, err := br.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return 0, errors.New("export is empty")
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !strings.ContainsRune(" \t\r\n", rune(b)) {
+			return b, br.UnreadByte()
+		}
+	}
+}
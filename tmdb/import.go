@@ -0,0 +1,496 @@
+package tmdb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/models"
+	"backend/repository"
+	"backend/scheduling"
+)
+
+// Options tune how an export is mapped onto the catalogue.
+type Options struct {
+	// Country picks the release whose certification is used.
+	Country string
+	// RunDays is the run given to new movies from their release date,
+	// existing movies keep the run they have.
+	RunDays int
+	// MaxCast caps the actors imported per movie, in billing order.
+	MaxCast int
+	// ImageBaseURL prefixes poster and profile paths.
+	ImageBaseURL string
+	// ShowPadding is added to new runtimes when the computed end times of
+	// upcoming shows are moved.
+	ShowPadding time.Duration
+	// CleaningBuffer is the least time between two shows on a screen, a
+	// movie whose new runtime moves a show closer to another is rejected.
+	CleaningBuffer time.Duration
+	// Today is the day snapshot statuses are derived for.
+	Today time.Time
+	// DryRun reports what would change and rolls everything back.
+	DryRun bool
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Country:        "IN",
+		RunDays:        28,
+		MaxCast:        20,
+		ImageBaseURL:   "https://image.tmdb.org/t/p/w500",
+		ShowPadding:    20 * time.Minute,
+		CleaningBuffer: 15 * time.Minute,
+		Today:          time.Now(),
+	}
+}
+
+// crewJobs are the crew jobs imported, TMDB lists hundreds per movie.
+var crewJobs = map[string]bool{
+	"Screenplay":              true,
+	"Writer":                  true,
+	"Story":                   true,
+	"Producer":                true,
+	"Original Music Composer": true,
+	"Director of Photography": true,
+	"Editor":                  true,
+}
+
+// Counts reports how many records of one kind were created, updated or
+// skipped because they already matched the export.
+type Counts struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Skipped int `json:"skipped"`
+}
+
+// Rejected is a movie of the export that could not be imported.
+type Rejected struct {
+	ExternalID string `json:"external_id"`
+	Title      string `json:"title"`
+	Reason     string `json:"reason"`
+}
+
+type Report struct {
+	DryRun       bool       `json:"dry_run"`
+	Movies       Counts     `json:"movies"`
+	People       Counts     `json:"people"`
+	Credits      Counts     `json:"credits"`
+	ShowsRetimed int        `json:"shows_retimed" doc:"Upcoming shows whose computed end time moved with a new runtime"`
+	Rejected     []Rejected `json:"rejected"`
+}
+
+func (r Report) String() string {
+	var b strings.Builder
+	if r.DryRun {
+		b.WriteString("dry run, nothing was written\n")
+	}
+	for _, kind := range []struct {
+		name   string
+		counts Counts
+	}{{"movies", r.Movies}, {"people", r.People}, {"credits", r.Credits}} {
+		fmt.Fprintf(&b, "%-8s created=%d updated=%d skipped=%d\n", kind.name, kind.counts.Created, kind.counts.Updated, kind.counts.Skipped)
+	}
+	if r.ShowsRetimed > 0 {
+		fmt.Fprintf(&b, "shows    retimed=%d\n", r.ShowsRetimed)
+	}
+	for _, rejected := range r.Rejected {
+		fmt.Fprintf(&b, "rejected %s %q: %s\n", rejected.ExternalID, rejected.Title, rejected.Reason)
+	}
+	return b.String()
+}
+
+var errDryRun = errors.New("dry run")
+
+// Import writes movies in a single transaction. Movies the catalogue cannot
+// hold, such as ones without a runtime or whose new runtime makes shows
+// overlap, are rejected and reported, any other failure rolls the whole
+// import back.
+func Import(ctx context.Context, tx repository.Transactor, movies []Movie, opts Options) (Report, error) {
+	report := Report{DryRun: opts.DryRun, Rejected: []Rejected{}}
+	err := tx.Transaction(ctx, func(repos repository.Repositories) error {
+		im := &importer{ctx: ctx, repos: repos, opts: opts, report: &report, people: map[int]int{}}
+		for _, movie := range movies {
+			if err := im.movie(movie); err != nil {
+				return fmt.Errorf("movie %s: %w", externalID(movie.ID), err)
+			}
+		}
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if errors.Is(err, errDryRun) {
+		err = nil
+	}
+	return report, err
+}
+
+func externalID(tmdbID int) string {
+	return "tmdb:" + strconv.Itoa(tmdbID)
+}
+
+type importer struct {
+	ctx    context.Context
+	repos  repository.Repositories
+	opts   Options
+	report *Report
+	// people maps TMDB person ids to person ids
+	people map[int]int
+}
+
+// catalogueFields are the movie fields an import sets, decoded so that
+// values read back from the database compare equal.
+type catalogueFields struct {
+	Name, Description               string
+	Duration                        int
+	Languages, Genres               []string
+	PosterURL, TrailerURL           string
+	Certification, OriginalLanguage string
+	ReleaseDate                     string
+	Rating                          float64
+}
+
+func fieldsOf(movie *models.Movie) catalogueFields {
+	f := catalogueFields{
+		Name:             movie.MovieName,
+		Description:      movie.MovieDescription,
+		Duration:         movie.Duration,
+		PosterURL:        movie.PosterURL,
+		TrailerURL:       movie.TrailerURL,
+		Certification:    movie.Certification,
+		OriginalLanguage: movie.OriginalLanguage,
+		Rating:           movie.Rating,
+	}
+	json.Unmarshal(movie.Languages, &f.Languages)
+	json.Unmarshal(movie.Genres, &f.Genres)
+	if movie.ReleaseDate != nil {
+		f.ReleaseDate = movie.ReleaseDate.Format(time.DateOnly)
+	}
+	return f
+}
+
+// overlapError rejects a movie whose new runtime makes shows overlap.
+type overlapError struct {
+	runtime       int
+	show, overlap uint
+}
+
+func (e overlapError) Error() string {
+	return fmt.Sprintf("runtime %d makes show %d overlap show %d", e.runtime, e.show, e.overlap)
+}
+
+func (im *importer) movie(m Movie) error {
+	id := externalID(m.ID)
+	if reason := rejection(m); reason != "" {
+		im.report.Rejected = append(im.report.Rejected, Rejected{ExternalID: id, Title: m.Title, Reason: reason})
+		return nil
+	}
+
+	// each movie is written in a nested transaction so that a rejected one
+	// leaves the others alone
+	report, people := *im.report, maps.Clone(im.people)
+	err := im.repos.Transaction(im.ctx, func(repos repository.Repositories) error {
+		nested := *im
+		nested.repos = repos
+		return nested.save(id, m)
+	})
+	var overlap overlapError
+	if errors.As(err, &overlap) {
+		*im.report, im.people = report, people
+		im.report.Rejected = append(im.report.Rejected, Rejected{ExternalID: id, Title: m.Title, Reason: overlap.Error()})
+		return nil
+	}
+	return err
+}
+
+func (im *importer) save(id string, m Movie) error {
+	movie, err := im.repos.Movies.GetByExternalID(im.ctx, id)
+	created := errors.Is(err, repository.ErrNotFound)
+	if created {
+		movie = &models.Movie{}
+	} else if err != nil {
+		return err
+	}
+	before := fieldsOf(movie)
+
+	if err := im.apply(movie, m); err != nil {
+		return err
+	}
+	now := time.Now()
+	switch {
+	case created:
+		movie.ExternalID = &id
+		start := im.opts.Today
+		if movie.ReleaseDate != nil {
+			start = *movie.ReleaseDate
+		}
+		movie.StartDate = time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+		movie.EndDate = movie.StartDate.AddDate(0, 0, im.opts.RunDays)
+		movie.MovieStatus = models.MovieStatusOn(movie.StartDate, movie.EndDate, repository.Day(im.opts.Today), false)
+		movie.CreatedAt, movie.UpdatedAt = now, now
+		if err := im.repos.Movies.Create(im.ctx, movie); err != nil {
+			return err
+		}
+		im.report.Movies.Created++
+	case !reflect.DeepEqual(before, fieldsOf(movie)):
+		movie.UpdatedAt = now
+		if err := im.repos.Movies.Update(im.ctx, movie); err != nil {
+			return err
+		}
+		im.report.Movies.Updated++
+		if movie.Duration != before.Duration {
+			retimed, err := scheduling.RecomputeEndTimes(im.ctx, im.repos.Shows, movie, im.opts.ShowPadding, im.opts.CleaningBuffer, now)
+			if err != nil {
+				return err
+			}
+			for _, r := range retimed {
+				if len(r.Overlaps) > 0 {
+					return overlapError{runtime: movie.Duration, show: r.Show.ShowID, overlap: r.Overlaps[0].ShowID}
+				}
+			}
+			im.report.ShowsRetimed += len(retimed)
+		}
+	default:
+		im.report.Movies.Skipped++
+	}
+
+	return im.credits(movie.MovieID, m.Credits)
+}
+
+// rejection tells why a movie cannot be imported, or returns "".
+func rejection(m Movie) string {
+	switch {
+	case m.ID <= 0:
+		return "id is missing"
+	case strings.TrimSpace(m.Title) == "":
+		return "title is missing"
+	case m.Runtime <= 0:
+		return "runtime is missing"
+	case len(m.Genres) == 0:
+		return "genres are missing"
+	case len(m.SpokenLanguages) == 0 && m.OriginalLanguage == "":
+		return "languages are missing"
+	case m.ReleaseDate != "":
+		if _, err := time.Parse(time.DateOnly, m.ReleaseDate); err != nil {
+			return "release_date is not a date"
+		}
+	}
+	return ""
+}
+
+func (im *importer) apply(movie *models.Movie, m Movie) error {
+	genres := make([]string, len(m.Genres))
+	for i, genre := range m.Genres {
+		genres[i] = genre.Name
+	}
+	genresJSON, err := json.Marshal(genres)
+	if err != nil {
+		return err
+	}
+
+	original := m.OriginalLanguage
+	var languages []string
+	for _, language := range m.SpokenLanguages {
+		languages = append(languages, language.EnglishName)
+		if language.ISO639_1 == m.OriginalLanguage {
+			original = language.EnglishName
+		}
+	}
+	if len(languages) == 0 {
+		languages = []string{original}
+	}
+	languagesJSON, err := json.Marshal(languages)
+	if err != nil {
+		return err
+	}
+
+	movie.MovieName = m.Title
+	movie.MovieDescription = m.Overview
+	movie.Duration = m.Runtime
+	movie.Languages = languagesJSON
+	movie.Genres = genresJSON
+	movie.Genre = genres[0]
+	movie.OriginalLanguage = original
+	if poster := im.image(m.PosterPath); poster != movie.PosterURL {
+		movie.PosterURL = poster
+		movie.PosterThumbnails = nil
+	}
+	movie.TrailerURL = trailer(m.Videos.Results)
+	movie.Certification = certification(m.ReleaseDates.Results, im.opts.Country)
+	// ratings are stored as numeric(2,1)
+	movie.Rating = math.Min(math.Round(m.VoteAverage*10)/10, 9.9)
+	movie.ReleaseDate = nil
+	if m.ReleaseDate != "" {
+		date, _ := time.Parse(time.DateOnly, m.ReleaseDate)
+		movie.ReleaseDate = &date
+	}
+	return nil
+}
+
+func (im *importer) image(path string) string {
+	if path == "" {
+		return ""
+	}
+	return strings.TrimSuffix(im.opts.ImageBaseURL, "/") + path
+}
+
+// trailer returns the URL of the first YouTube trailer.
+func trailer(videos []Video) string {
+	for _, video := range videos {
+		if video.Site == "YouTube" && video.Type == "Trailer" && video.Key != "" {
+			return "https://www.youtube.com/watch?v=" + video.Key
+		}
+	}
+	return ""
+}
+
+// certification maps the first certification given in country onto U, UA or
+// A. Variants such as "UA 13+" count as UA, anything else is left out.
+func certification(releases []CountryReleases, country string) string {
+	for _, c := range releases {
+		if !strings.EqualFold(c.Country, country) {
+			continue
+		}
+		for _, release := range c.Releases {
+			cert := strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(release.Certification), "/", ""))
+			switch {
+			case strings.HasPrefix(cert, models.CertificationUA):
+				return models.CertificationUA
+			case cert == models.CertificationU, cert == models.CertificationA:
+				return cert
+			}
+		}
+	}
+	return ""
+}
+
+// creditKey identifies a credit, a person has a role, with a job, in a movie
+// once.
+type creditKey struct {
+	personID  int
+	role, job string
+}
+
+func (im *importer) credits(movieID int, credits Credits) error {
+	stored, err := im.repos.People.ListCredits(im.ctx, movieID)
+	if err != nil {
+		return err
+	}
+	existing := make(map[creditKey]models.MovieCredit, len(stored))
+	for _, credit := range stored {
+		existing[creditKey{credit.PersonID, credit.Role, credit.Job}] = credit
+	}
+
+	for i, cast := range credits.Cast {
+		if i == im.opts.MaxCast {
+			break
+		}
+		personID, err := im.person(cast.ID, cast.Name, cast.ProfilePath)
+		if err != nil {
+			return err
+		}
+		if err := im.credit(existing, models.MovieCredit{
+			MovieID: movieID, PersonID: personID, Role: models.CreditRoleActor,
+			Character: cast.Character, BillingOrder: cast.Order,
+		}); err != nil {
+			return err
+		}
+	}
+
+	for i, crew := range credits.Crew {
+		credit := models.MovieCredit{MovieID: movieID, Role: models.CreditRoleCrew, Job: crew.Job, BillingOrder: i}
+		switch {
+		case crew.Job == "Director":
+			credit.Role, credit.Job = models.CreditRoleDirector, ""
+		case !crewJobs[crew.Job]:
+			continue
+		}
+		personID, err := im.person(crew.ID, crew.Name, crew.ProfilePath)
+		if err != nil {
+			return err
+		}
+		credit.PersonID = personID
+		if err := im.credit(existing, credit); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// person creates or updates the person with the TMDB id and returns their id.
+// Each person is counted once however many credits they have.
+func (im *importer) person(tmdbID int, name, profilePath string) (int, error) {
+	if id, ok := im.people[tmdbID]; ok {
+		return id, nil
+	}
+
+	externalID := externalID(tmdbID)
+	person, err := im.repos.People.GetByExternalID(im.ctx, externalID)
+	created := errors.Is(err, repository.ErrNotFound)
+	if created {
+		person = &models.Person{}
+	} else if err != nil {
+		return 0, err
+	}
+	profileURL := im.image(profilePath)
+	changed := person.Name != name || person.ProfileURL != profileURL
+	person.Name = name
+	person.ProfileURL = profileURL
+
+	now := time.Now()
+	switch {
+	case created:
+		person.ExternalID = &externalID
+		person.CreatedAt, person.UpdatedAt = now, now
+		if err := im.repos.People.Create(im.ctx, person); err != nil {
+			return 0, err
+		}
+		im.report.People.Created++
+	case changed:
+		person.UpdatedAt = now
+		if err := im.repos.People.Update(im.ctx, person); err != nil {
+			return 0, err
+		}
+		im.report.People.Updated++
+	default:
+		im.report.People.Skipped++
+	}
+
+	im.people[tmdbID] = person.PersonID
+	return person.PersonID, nil
+}
+
+// credit creates the credit or updates the character and billing of the one
+// the person already has in the role. Credits missing from the export are
+// kept, they may have been added by hand.
+func (im *importer) credit(existing map[creditKey]models.MovieCredit, credit models.MovieCredit) error {
+	key := creditKey{credit.PersonID, credit.Role, credit.Job}
+	stored, ok := existing[key]
+	switch {
+	case !ok:
+		credit.CreatedAt = time.Now()
+		if err := im.repos.People.AddCredit(im.ctx, &credit); err != nil {
+			return err
+		}
+		existing[key] = credit
+		im.report.Credits.Created++
+	case stored.Character != credit.Character || stored.BillingOrder != credit.BillingOrder:
+		stored.Character, stored.BillingOrder = credit.Character, credit.BillingOrder
+		if err := im.repos.People.UpdateCredit(im.ctx, &stored); err != nil {
+			return err
+		}
+		existing[key] = stored
+		im.report.Credits.Updated++
+	default:
+		im.report.Credits.Skipped++
+	}
+	return nil
+}
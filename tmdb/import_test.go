@@ -0,0 +1,103 @@
+package tmdb_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"backend/models"
+	"backend/repository"
+	"backend/repository/memory"
+	"backend/tmdb"
+)
+
+func exportMovie(id int, title string, runtime int) tmdb.Movie {
+	movie := tmdb.Movie{
+		ID: id, Title: title, Runtime: runtime, ReleaseDate: "2030-01-01", OriginalLanguage: "en",
+		Genres: []tmdb.Genre{{ID: 18, Name: "Drama"}},
+	}
+	movie.Credits.Cast = []tmdb.CastMember{{ID: 819, Name: "Lead Actor", Character: "Narrator"}}
+	movie.Credits.Crew = []tmdb.CrewMember{{ID: 7467, Name: "The Director", Job: "Director"}}
+	return movie
+}
+
+func TestImportIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	export := []tmdb.Movie{exportMovie(550, "Imported", 120), {ID: 551, Title: "No Runtime"}}
+
+	opts := tmdb.DefaultOptions()
+	opts.DryRun = true
+	report, err := tmdb.Import(ctx, repos, export, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if movies, _ := repos.Movies.List(ctx); report.Movies.Created != 1 || len(movies) != 0 {
+		t.Fatalf("dry run reported %+v and left %d movies", report, len(movies))
+	}
+
+	report, err = tmdb.Import(ctx, repos, export, tmdb.DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Movies != (tmdb.Counts{Created: 1}) || report.People != (tmdb.Counts{Created: 2}) || report.Credits != (tmdb.Counts{Created: 2}) {
+		t.Errorf("first import %+v", report)
+	}
+	if len(report.Rejected) != 1 || report.Rejected[0].ExternalID != "tmdb:551" {
+		t.Errorf("rejected %+v", report.Rejected)
+	}
+
+	report, err = tmdb.Import(ctx, repos, export, tmdb.DefaultOptions())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Movies != (tmdb.Counts{Skipped: 1}) || report.People != (tmdb.Counts{Skipped: 2}) || report.Credits != (tmdb.Counts{Skipped: 2}) {
+		t.Errorf("second import %+v", report)
+	}
+}
+
+func TestImportRejectsRuntimesThatOverlapShows(t *testing.T) {
+	ctx := context.Background()
+	repos := memory.New()
+	opts := tmdb.DefaultOptions()
+	if _, err := tmdb.Import(ctx, repos, []tmdb.Movie{exportMovie(550, "Imported", 120)}, opts); err != nil {
+		t.Fatal(err)
+	}
+	movie, err := repos.Movies.GetByExternalID(ctx, "tmdb:550")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the first show ends at 12:20 with the padding, 20 minutes before the
+	// second starts
+	theatre := models.Theatre{TheatreName: "Cinema", TotalSeats: 100}
+	if err := repos.Theatres.Create(ctx, &theatre); err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2030, time.January, 10, 0, 0, 0, 0, time.UTC)
+	for _, start := range []int{10 * 60, 12*60 + 40} {
+		show := models.Show{MovieID: movie.MovieID, TheatreID: theatre.TheatreID}
+		show.SetStart(day, day.Add(time.Duration(start)*time.Minute), time.UTC)
+		show.ComputeEndTime(movie.Duration, opts.ShowPadding)
+		if err := repos.Shows.Create(ctx, &show); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := tmdb.Import(ctx, repos, []tmdb.Movie{exportMovie(550, "Imported", 130), exportMovie(552, "Another", 90)}, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Rejected) != 1 || report.Rejected[0].ExternalID != "tmdb:550" {
+		t.Errorf("rejected %+v", report.Rejected)
+	}
+	if report.Movies != (tmdb.Counts{Created: 1}) || report.ShowsRetimed != 0 {
+		t.Errorf("report %+v counts the rejected movie", report)
+	}
+	if movie, _ = repos.Movies.GetByExternalID(ctx, "tmdb:550"); movie.Duration != 120 {
+		t.Errorf("rejected runtime stored: %d", movie.Duration)
+	}
+	if _, err := repos.Movies.GetByExternalID(ctx, "tmdb:552"); err == repository.ErrNotFound {
+		t.Error("the movie after the rejected one was not imported")
+	}
+}